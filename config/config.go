@@ -0,0 +1,294 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Config is the user configuration, read from a JSON file.
+// Every section is optional; missing values fall back to Default().
+type Config struct {
+	Layout     string               `json:"layout"` // vertical|columns|rows
+	Theme      string               `json:"theme"`  // name of the active theme
+	Themes     map[string]ThemeSpec `json:"themes"` // user defined themes
+	Clock      ClockConfig          `json:"clock"`
+	SysInfo    SysInfoConfig        `json:"sysinfo"`
+	Audio      AudioConfig          `json:"audio"`
+	Media      MediaConfig          `json:"media"`
+	Containers ContainersConfig     `json:"containers"`
+	Systemd    SystemdConfig        `json:"systemd"`
+	Git        GitConfig            `json:"git"`
+	Commands   []CommandConfig      `json:"commands"`  // one widget each
+	Terminals  []TerminalConfig     `json:"terminals"` // one widget each
+	Tails      []TailConfig         `json:"tails"`     // one widget each
+	Weather    WeatherConfig        `json:"weather"`
+}
+
+type AudioConfig struct {
+	Hop          int `json:"hop"`            // volume step in percent, default 5
+	MaxOutVolume int `json:"max_out_volume"` // above 100 allows over-amplification
+	MaxInVolume  int `json:"max_in_volume"`
+
+	VADThreshold float64 `json:"vad_threshold_db"` // mic level (dBFS) that counts as voice, default -45
+	SilenceWarn  string  `json:"silence_warn"`     // warn when the open mic hears nothing this long, default "5m"
+	PTTKey       string  `json:"ptt_key"`          // push-to-talk key, default "space"
+}
+
+type MediaConfig struct {
+	Bus      string `json:"bus"`       // D-Bus address, default the session bus
+	SeekStep string `json:"seek_step"` // how far left/right seek, default "5s"
+}
+
+type ContainersConfig struct {
+	// Socket is the Engine API socket. Empty picks $DOCKER_HOST, the Docker
+	// socket or the Podman one, whichever exists.
+	Socket string `json:"socket"`
+}
+
+type SystemdConfig struct {
+	System []string `json:"system"` // units of the system manager, e.g. "sshd.service"
+	User   []string `json:"user"`   // units of the user's own manager
+}
+
+type GitConfig struct {
+	Repos []string `json:"repos"` // repository directories, ~ allowed
+	// Open is run on the selected repository; {} is replaced by its path,
+	// which is appended otherwise. Default "xdg-open {}".
+	Open           string `json:"open"`
+	OpenInTerminal bool   `json:"open_in_terminal"` // hand the terminal to Open, for editors like vim
+}
+
+type CommandConfig struct {
+	Name    string `json:"name"`    // widget name for focus commands, default "command"
+	Command string `json:"command"` // run with sh -c
+	// Interval is how often the command runs, default "10s". In follow
+	// mode the command runs continuously and is restarted this long after
+	// it exits.
+	Interval string `json:"interval"`
+	Follow   bool   `json:"follow"`
+	Timeout  string `json:"timeout"`   // kill a run after this long, default "30s"; never in follow mode
+	MaxLines int    `json:"max_lines"` // output lines kept for scrolling, default 500
+	NoColor  bool   `json:"no_color"`  // strip ANSI colours instead of passing them through
+}
+
+type TerminalConfig struct {
+	Name    string `json:"name"`    // widget name for focus commands, default "terminal"
+	Command string `json:"command"` // run with sh -c, default $SHELL
+	// Scrollback is how many lines scrolled off the top are kept, default
+	// 1000.
+	Scrollback int `json:"scrollback"`
+}
+
+type TailConfig struct {
+	Name  string   `json:"name"`  // widget name for focus commands, default "tail"
+	Files []string `json:"files"` // followed by name across rotation, like tail -F
+	// Highlight colours what matches; empty highlights errors and
+	// warnings.
+	Highlight []HighlightConfig `json:"highlight"`
+	// JSONFields shows lines that are JSON objects as these fields only,
+	// e.g. ["time", "level", "msg", "req.path"].
+	JSONFields []string `json:"json_fields"`
+	Backlog    int      `json:"backlog"`   // lines shown from the end of each file at start, default 100
+	MaxLines   int      `json:"max_lines"` // lines kept for scrolling, default 5000
+}
+
+type HighlightConfig struct {
+	Pattern string `json:"pattern"` // regular expression
+	Role    string `json:"role"`    // theme color role, e.g. "alert" or "gauge.med"; default "alert"
+	Line    bool   `json:"line"`    // colour the whole line, not only the match
+}
+
+type WeatherConfig struct {
+	Provider string `json:"provider"` // open-meteo (default) or file
+	URL      string `json:"url"`      // Open-Meteo API base, for a self-hosted instance
+	// File is the file provider's saved Open-Meteo response, for offline
+	// use and testing.
+	File      string  `json:"file"`
+	Place     string  `json:"place"` // name shown as the title
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Units     string  `json:"units"`   // metric or imperial, default metric
+	Refresh   string  `json:"refresh"` // how long a report is fresh, and cached, default "30m"
+	Days      int     `json:"days"`    // forecast days, default 5
+}
+
+type SysInfoConfig struct {
+	// Fields lists the rows to show, in order. Empty means all of them;
+	// see sysinfo.FieldNames for the accepted names.
+	Fields []string `json:"fields"`
+	Logo   string   `json:"logo"` // auto|none|<distro id>, default auto
+}
+
+type ClockConfig struct {
+	WeekStart   string         `json:"week_start"`   // monday|sunday
+	WeekNumbers bool           `json:"week_numbers"` // ISO week numbers in the calendar
+	Calendars   []string       `json:"calendars"`    // .ics files or directories of them
+	Zones       []ZoneConfig   `json:"zones"`        // extra zones for the world clock
+	Timers      []TimerConfig  `json:"timers"`       // named countdown timers
+	Pomodoro    PomodoroConfig `json:"pomodoro"`
+	Font        string         `json:"font"`         // box|block|7seg|braille or a .flf path
+	Hour12      bool           `json:"hour12"`       // 12 hour clock with AM/PM
+	HideSeconds bool           `json:"hide_seconds"` // HH:MM only
+	BlinkColon  bool           `json:"blink_colon"`
+	Snooze      string         `json:"snooze"`     // alarm snooze length, default "9m"
+	AlarmTone   bool           `json:"alarm_tone"` // new alarms play a tone
+}
+
+type TimerConfig struct {
+	Name     string `json:"name"`
+	Duration string `json:"duration"` // Go duration, e.g. "4m30s"
+}
+
+type PomodoroConfig struct {
+	Work      string `json:"work"`       // default "25m"
+	Short     string `json:"short"`      // default "5m"
+	Long      string `json:"long"`       // default "15m"
+	LongEvery int    `json:"long_every"` // work sessions before a long break, default 4
+}
+
+type ZoneConfig struct {
+	Name      string `json:"name"`       // IANA name, e.g. "Asia/Tokyo"
+	Label     string `json:"label"`      // shown instead of the city part of Name
+	WorkStart int    `json:"work_start"` // start of working hours (hour, default 9)
+	WorkEnd   int    `json:"work_end"`   // end of working hours (hour, default 17)
+}
+
+// ThemeSpec describes a user theme as overrides on top of a built-in one.
+// Colors accept anything lipgloss understands: "#rrggbb", "212", "9".
+type ThemeSpec struct {
+	Base   string            `json:"base"`   // built-in theme to start from (default "dark")
+	Border string            `json:"border"` // normal|rounded|thick|double|hidden
+	Colors map[string]string `json:"colors"` // role -> color, e.g. "gauge.high": "#ff5555"
+	Glyphs map[string]string `json:"glyphs"` // glyph -> string, e.g. "gauge.full": "▰"
+}
+
+func Default() Config {
+	return Config{
+		Layout: "vertical",
+		Theme:  "dark",
+		Clock: ClockConfig{
+			WeekStart: "monday",
+			Font:      "box",
+			Snooze:    "9m",
+			AlarmTone: true,
+			Timers: []TimerConfig{
+				{Name: "timer", Duration: "5m"},
+			},
+			Pomodoro: PomodoroConfig{
+				Work:      "25m",
+				Short:     "5m",
+				Long:      "15m",
+				LongEvery: 4,
+			},
+		},
+		SysInfo: SysInfoConfig{
+			Logo: "auto",
+		},
+		Audio: AudioConfig{
+			Hop:          5,
+			MaxOutVolume: 100,
+			MaxInVolume:  100,
+			VADThreshold: -45,
+			SilenceWarn:  "5m",
+			PTTKey:       "space",
+		},
+		Media: MediaConfig{
+			SeekStep: "5s",
+		},
+		Git: GitConfig{
+			Open: "xdg-open {}",
+		},
+	}
+}
+
+// StateDir is where runtime state (timers, alarms, caches) is kept:
+// termctrl under $XDG_STATE_HOME, or ~/.local/state.
+func StateDir() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "termctrl")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".local", "state", "termctrl")
+	}
+	return filepath.Join(os.TempDir(), "termctrl")
+}
+
+// WriteState replaces the state file at path with data, creating its
+// directory. It writes a temporary file next to it and renames it over
+// path, so a crash never leaves half a file and two instances saving at
+// once never write into the same temporary file.
+func WriteState(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // fails once renamed
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0o644); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// ExpandPath expands a leading ~ to the user's home directory.
+func ExpandPath(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, p[1:])
+		}
+	}
+	return p
+}
+
+// Path returns the config file location: $TERMCTRL_CONFIG, otherwise
+// termctrl/config.json under the user config dir.
+func Path() string {
+	if p := os.Getenv("TERMCTRL_CONFIG"); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "config.json"
+	}
+	return filepath.Join(dir, "termctrl", "config.json")
+}
+
+// Load reads the config at Path. A missing file is not an error.
+// On a parse error the defaults are returned together with the error
+// so the dashboard can still start.
+func Load() (Config, error) {
+	return LoadFile(Path())
+}
+
+func LoadFile(path string) (Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("config: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Default(), fmt.Errorf("config %s: %w", path, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,37 @@
+package control
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// Send delivers a single command to a running instance and waits for its reply.
+func Send(path, command string) error {
+	conn, err := net.DialTimeout("unix", path, 2*time.Second)
+	if err != nil {
+		return fmt.Errorf("connect %s: %w", path, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := fmt.Fprintln(conn, command); err != nil {
+		return err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("read reply: %w", err)
+	}
+	reply = strings.TrimSpace(reply)
+	if msg, ok := strings.CutPrefix(reply, "error: "); ok {
+		return errors.New(msg)
+	}
+	if reply != "ok" {
+		return fmt.Errorf("unexpected reply %q", reply)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package control
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/antiloger/termctlr/message"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Commands are plain text, one per line:
+//
+//	audio.volume +5        raise speaker volume by 5%
+//	audio.volume 40        set speaker volume to 40%
+//	audio.mute toggle      toggle|on|off
+//	audio.mic.volume -5    same as above, for the default source
+//	audio.mic.mute on
+//	screen switch <name>
+//	focus <widget>
+//	quit
+//
+// The server answers every line with "ok" or "error: <reason>".
+
+// Parse turns a single command line into the tea.Msg it should inject.
+func Parse(line string) (tea.Msg, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+
+	cmd, args := fields[0], fields[1:]
+	switch cmd {
+	case "audio.volume":
+		return parseVolume(message.AudioOut, args)
+	case "audio.mic.volume":
+		return parseVolume(message.AudioIn, args)
+	case "audio.mute":
+		return parseMute(message.AudioOut, args)
+	case "audio.mic.mute":
+		return parseMute(message.AudioIn, args)
+	case "screen":
+		if len(args) != 2 || args[0] != "switch" {
+			return nil, fmt.Errorf("usage: screen switch <name>")
+		}
+		return message.SwitchScreenMsg(args[1]), nil
+	case "focus":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("usage: focus <widget>")
+		}
+		return message.FocusMsg(args[0]), nil
+	case "quit":
+		if len(args) != 0 {
+			return nil, fmt.Errorf("usage: quit")
+		}
+		return message.QuitMsg{}, nil
+	}
+	return nil, fmt.Errorf("unknown command %q", cmd)
+}
+
+func parseVolume(dev message.AudioDevice, args []string) (tea.Msg, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("usage: audio.volume [+|-]<percent>")
+	}
+	arg := strings.TrimSuffix(args[0], "%")
+	relative := strings.HasPrefix(arg, "+") || strings.HasPrefix(arg, "-")
+	v, err := strconv.Atoi(arg)
+	if err != nil {
+		return nil, fmt.Errorf("bad volume %q", args[0])
+	}
+	if !relative && v < 0 {
+		return nil, fmt.Errorf("bad volume %q", args[0])
+	}
+	return message.AudioVolumeMsg{Device: dev, Value: v, Relative: relative}, nil
+}
+
+func parseMute(dev message.AudioDevice, args []string) (tea.Msg, error) {
+	action := message.MuteToggle
+	if len(args) > 1 {
+		return nil, fmt.Errorf("usage: audio.mute [toggle|on|off]")
+	}
+	if len(args) == 1 {
+		switch args[0] {
+		case "toggle":
+			action = message.MuteToggle
+		case "on":
+			action = message.MuteOn
+		case "off":
+			action = message.MuteOff
+		default:
+			return nil, fmt.Errorf("bad mute action %q", args[0])
+		}
+	}
+	return message.AudioMuteMsg{Device: dev, Action: action}, nil
+}
@@ -0,0 +1,127 @@
+package control
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/antiloger/termctlr/message"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SocketPath returns where the control socket lives. $TERMCTRL_SOCKET wins,
+// then $XDG_RUNTIME_DIR, then a per-user file in the temp dir.
+func SocketPath() string {
+	if p := os.Getenv("TERMCTRL_SOCKET"); p != "" {
+		return p
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "termctrl.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("termctrl-%d.sock", os.Getuid()))
+}
+
+// Targets are the screen and widget names commands may refer to.
+type Targets struct {
+	Screens []string
+	Widgets []string
+}
+
+// check refuses screen switches and focus changes to names that don't
+// exist, which the program would otherwise accept and act on.
+func (t Targets) check(msg tea.Msg) error {
+	switch msg := msg.(type) {
+	case message.SwitchScreenMsg:
+		if !slices.Contains(t.Screens, string(msg)) {
+			return fmt.Errorf("unknown screen %q (have %s)", string(msg), strings.Join(t.Screens, ", "))
+		}
+	case message.FocusMsg:
+		if !slices.Contains(t.Widgets, string(msg)) {
+			return fmt.Errorf("unknown widget %q (have %s)", string(msg), strings.Join(t.Widgets, ", "))
+		}
+	}
+	return nil
+}
+
+// Server accepts command connections on a unix socket and injects the
+// parsed commands into the program through send (usually tea.Program.Send).
+type Server struct {
+	path    string
+	ln      net.Listener
+	targets Targets
+	send    func(tea.Msg)
+}
+
+// Listen binds the socket and starts serving in the background.
+// A stale socket left behind by a crashed instance is removed; a live one
+// is reported as an error so two dashboards don't fight over it.
+func Listen(path string, targets Targets, send func(tea.Msg)) (*Server, error) {
+	if _, err := os.Stat(path); err == nil {
+		if c, err := net.DialTimeout("unix", path, 200*time.Millisecond); err == nil {
+			c.Close()
+			return nil, fmt.Errorf("control socket %s already in use", path)
+		}
+		os.Remove(path)
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("control socket: %w", err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("control socket: %w", err)
+	}
+
+	s := &Server{path: path, ln: ln, targets: targets, send: send}
+	go s.serve()
+	return s, nil
+}
+
+// Close stops accepting connections and removes the socket file.
+func (s *Server) Close() error {
+	err := s.ln.Close()
+	os.Remove(s.path)
+	return err
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		msg, err := Parse(line)
+		if err == nil {
+			err = s.targets.check(msg)
+		}
+		if err != nil {
+			fmt.Fprintf(conn, "error: %v\n", err)
+			continue
+		}
+		s.send(msg)
+		fmt.Fprintln(conn, "ok")
+	}
+}
@@ -0,0 +1,46 @@
+package control
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/antiloger/termctlr/message"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestServerChecksTargets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ctl.sock")
+	sent := make(chan tea.Msg, 4)
+	srv, err := Listen(path, Targets{Screens: []string{"weidget"}, Widgets: []string{"clock", "audio"}}, func(msg tea.Msg) { sent <- msg })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	for _, c := range []struct{ command, err string }{
+		{"focus audio", ""},
+		{"screen switch weidget", ""},
+		{"focus weather", `unknown widget "weather" (have clock, audio)`},
+		{"screen switch other", `unknown screen "other" (have weidget)`},
+		{"volume up", `unknown command "volume"`},
+	} {
+		err := Send(path, c.command)
+		if c.err == "" && err != nil || c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)) {
+			t.Errorf("%s: err = %v, want %q", c.command, err, c.err)
+		}
+	}
+
+	// only the valid commands reach the program
+	if msg := <-sent; msg != message.FocusMsg("audio") {
+		t.Errorf("first message = %#v", msg)
+	}
+	if msg := <-sent; msg != message.SwitchScreenMsg("weidget") {
+		t.Errorf("second message = %#v", msg)
+	}
+	select {
+	case msg := <-sent:
+		t.Errorf("refused command sent %#v", msg)
+	default:
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/antiloger/termctlr/control"
+)
+
+// runCtl implements `termctrl ctl <command...>`, forwarding the command to
+// the running dashboard over its control socket.
+func runCtl(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, `usage: termctrl ctl <command>
+
+commands:
+  audio.volume [+|-]<percent>
+  audio.mute [toggle|on|off]
+  audio.mic.volume [+|-]<percent>
+  audio.mic.mute [toggle|on|off]
+  screen switch <name>
+  focus <widget>
+  quit`)
+		return 2
+	}
+
+	cmd := strings.Join(args, " ")
+	if _, err := control.Parse(cmd); err != nil {
+		fmt.Fprintln(os.Stderr, "termctrl ctl:", err)
+		return 2
+	}
+	if err := control.Send(control.SocketPath(), cmd); err != nil {
+		fmt.Fprintln(os.Stderr, "termctrl ctl:", err)
+		return 1
+	}
+	return 0
+}
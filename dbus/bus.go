@@ -0,0 +1,97 @@
+package dbus
+
+import "fmt"
+
+// AddMatch subscribes to the signals described by a match rule, e.g.
+// "type='signal',interface='org.freedesktop.DBus.Properties'".
+func (c *Conn) AddMatch(rule string) error {
+	_, err := c.Call(busName, busPath, busName, "AddMatch", rule)
+	return err
+}
+
+// ListNames lists the names currently on the bus.
+func (c *Conn) ListNames() ([]string, error) {
+	body, err := c.Call(busName, busPath, busName, "ListNames")
+	if err != nil {
+		return nil, err
+	}
+	return First[[]string]("ListNames", body)
+}
+
+// GetNameOwner returns the unique name that owns a well-known name.
+// Signals carry the unique name as their sender.
+func (c *Conn) GetNameOwner(name string) (string, error) {
+	body, err := c.Call(busName, busPath, busName, "GetNameOwner", name)
+	if err != nil {
+		return "", err
+	}
+	return First[string]("GetNameOwner", body)
+}
+
+// RequestName claims a well-known name, failing if someone else has it.
+func (c *Conn) RequestName(name string) error {
+	const doNotQueue, primaryOwner = 0x4, 1
+	body, err := c.Call(busName, busPath, busName, "RequestName", name, uint32(doNotQueue))
+	if err != nil {
+		return err
+	}
+	r, err := First[uint32]("RequestName", body)
+	if err != nil {
+		return err
+	}
+	if r != primaryOwner {
+		return fmt.Errorf("dbus: name %s is taken", name)
+	}
+	return nil
+}
+
+// Get reads one property.
+func (c *Conn) Get(dest string, path ObjectPath, iface, prop string) (any, error) {
+	body, err := c.Call(dest, path, PropertiesIfc, "Get", iface, prop)
+	if err != nil {
+		return nil, err
+	}
+	v, err := First[Variant]("Get", body)
+	return v.Value, err
+}
+
+// GetAll reads every property of an interface. Values are unwrapped from
+// their variants.
+func (c *Conn) GetAll(dest string, path ObjectPath, iface string) (map[string]any, error) {
+	body, err := c.Call(dest, path, PropertiesIfc, "GetAll", iface)
+	if err != nil {
+		return nil, err
+	}
+	props, err := First[map[string]any]("GetAll", body)
+	if err != nil {
+		return nil, err
+	}
+	return Unwrap(props), nil
+}
+
+// First returns the first value of the reply to member as a T. A peer
+// may answer with anything, so an empty reply or another type is an
+// error rather than a panic or a silent zero value.
+func First[T any](member string, body []any) (T, error) {
+	var v T
+	if len(body) == 0 {
+		return v, fmt.Errorf("dbus: %s: empty reply", member)
+	}
+	v, ok := body[0].(T)
+	if !ok {
+		return v, fmt.Errorf("dbus: %s: reply is %T, want %T", member, body[0], v)
+	}
+	return v, nil
+}
+
+// Unwrap replaces the variants in an a{sv} dict with their values.
+func Unwrap(dict map[string]any) map[string]any {
+	out := make(map[string]any, len(dict))
+	for k, v := range dict {
+		if vv, ok := v.(Variant); ok {
+			v = vv.Value
+		}
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,72 @@
+package dbus
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeBus answers open's handshake and then each method call with the
+// body replies gives for its member.
+func fakeBus(t *testing.T, replies map[string][]any) net.Conn {
+	client, server := net.Pipe()
+	t.Cleanup(func() { client.Close(); server.Close() })
+	go func() {
+		r := bufio.NewReader(server)
+		for _, want := range []string{"\x00AUTH", "BEGIN"} {
+			line, err := r.ReadString('\n')
+			if err != nil || !strings.HasPrefix(line, want) {
+				return
+			}
+			if want != "BEGIN" {
+				server.Write([]byte("OK 0123456789abcdef\r\n"))
+			}
+		}
+		for {
+			call, err := readMessage(r)
+			if err != nil {
+				return
+			}
+			b, _ := (&Message{Type: TypeMethodReturn, Serial: call.Serial + 1000, ReplySerial: call.Serial, Body: replies[call.Member]}).encode()
+			if _, err := server.Write(b); err != nil {
+				return
+			}
+		}
+	}()
+	return client
+}
+
+func TestEmptyHelloReply(t *testing.T) {
+	_, err := open(fakeBus(t, nil))
+	if err == nil || err.Error() != "dbus: Hello: empty reply" {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestBadReplies(t *testing.T) {
+	c, err := open(fakeBus(t, map[string][]any{
+		"Hello":        {":1.7"},
+		"GetNameOwner": {uint32(3)},
+		"Get":          {"not a variant"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if c.Name() != ":1.7" {
+		t.Errorf("name = %q", c.Name())
+	}
+
+	for name, call := range map[string]func() error{
+		"ListNames: empty reply":                     func() error { _, err := c.ListNames(); return err },
+		"RequestName: empty reply":                   func() error { return c.RequestName("x.y") },
+		"GetAll: empty reply":                        func() error { _, err := c.GetAll("x.y", "/", "x.y"); return err },
+		"GetNameOwner: reply is uint32, want string": func() error { _, err := c.GetNameOwner("x.y"); return err },
+		"Get: reply is string, want dbus.Variant":    func() error { _, err := c.Get("x.y", "/", "x.y", "P"); return err },
+	} {
+		if err := call(); err == nil || err.Error() != "dbus: "+name {
+			t.Errorf("err = %v, want dbus: %s", err, name)
+		}
+	}
+}
@@ -0,0 +1,382 @@
+// Package dbus is a small D-Bus client: enough of the wire protocol to call
+// methods, receive signals and answer calls on the session and system
+// buses. It covers what the media widget needs to talk MPRIS (and its
+// tests a stand-in player to be one) and what the systemd widget needs.
+package dbus
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	callTimeout  = 5 * time.Second
+	signalBuffer = 64
+)
+
+// Bus names and interfaces of the message bus itself.
+const (
+	busName       = "org.freedesktop.DBus"
+	busPath       = ObjectPath("/org/freedesktop/DBus")
+	PropertiesIfc = "org.freedesktop.DBus.Properties"
+)
+
+// ErrClosed is returned for calls on a closed connection.
+var ErrClosed = errors.New("dbus: connection closed")
+
+// Resync is delivered on Signals in place of signals that were dropped
+// because the reader fell behind; state built from signals should be
+// re-read.
+var Resync = &Message{Type: TypeSignal, Member: "Resync"}
+
+// Handler answers method calls made to this connection. It returns the
+// reply body, or an error; a *Error keeps its name.
+type Handler func(m *Message) ([]any, error)
+
+// Conn is a connection to a message bus. It is safe for concurrent use.
+type Conn struct {
+	conn net.Conn
+	name string // unique name assigned by the bus
+
+	wmu sync.Mutex // serialises writes
+
+	mu      sync.Mutex
+	serial  uint32
+	pending map[uint32]chan *Message
+	handler Handler
+	signals chan *Message
+	err     error // why the connection ended
+	done    chan struct{}
+}
+
+// SessionAddress returns $DBUS_SESSION_BUS_ADDRESS, or the bus socket under
+// $XDG_RUNTIME_DIR that systemd and dbus-broker provide.
+func SessionAddress() string {
+	if a := os.Getenv("DBUS_SESSION_BUS_ADDRESS"); a != "" {
+		return a
+	}
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = fmt.Sprintf("/run/user/%d", os.Getuid())
+	}
+	return "unix:path=" + filepath.Join(dir, "bus")
+}
+
+// SystemAddress returns $DBUS_SYSTEM_BUS_ADDRESS or the well-known system
+// bus socket.
+func SystemAddress() string {
+	if a := os.Getenv("DBUS_SYSTEM_BUS_ADDRESS"); a != "" {
+		return a
+	}
+	return "unix:path=/var/run/dbus/system_bus_socket"
+}
+
+// Dial connects to the first reachable address in a D-Bus address list
+// ("unix:path=…;unix:abstract=…"), authenticates and registers with the
+// bus.
+func Dial(address string) (*Conn, error) {
+	var errs []error
+	for _, a := range strings.Split(address, ";") {
+		if a == "" {
+			continue
+		}
+		conn, err := dialAddress(a)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		c, err := open(conn)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return c, nil
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("dbus: no address in %q", address)
+	}
+	return nil, errors.Join(errs...)
+}
+
+func dialAddress(a string) (net.Conn, error) {
+	transport, params, ok := strings.Cut(a, ":")
+	if !ok || transport != "unix" {
+		return nil, fmt.Errorf("dbus: unsupported address %q", a)
+	}
+	kv := map[string]string{}
+	for _, p := range strings.Split(params, ",") {
+		k, v, _ := strings.Cut(p, "=")
+		if uv, err := url.PathUnescape(v); err == nil {
+			v = uv
+		}
+		kv[k] = v
+	}
+	switch {
+	case kv["path"] != "":
+		return net.DialTimeout("unix", kv["path"], callTimeout)
+	case kv["abstract"] != "":
+		return net.DialTimeout("unix", "@"+kv["abstract"], callTimeout)
+	}
+	return nil, fmt.Errorf("dbus: no socket in address %q", a)
+}
+
+// open authenticates with EXTERNAL (the peer credentials of the socket)
+// and says Hello.
+func open(conn net.Conn) (*Conn, error) {
+	conn.SetDeadline(time.Now().Add(callTimeout))
+	uid := hex.EncodeToString([]byte(strconv.Itoa(os.Getuid())))
+	if _, err := io.WriteString(conn, "\x00AUTH EXTERNAL "+uid+"\r\n"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// one byte at a time: whatever follows BEGIN is the message stream
+	line, err := readLine(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("dbus auth: %w", err)
+	}
+	if !strings.HasPrefix(line, "OK ") {
+		conn.Close()
+		return nil, fmt.Errorf("dbus auth: %s", line)
+	}
+	if _, err := io.WriteString(conn, "BEGIN\r\n"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+
+	c := &Conn{
+		conn:    conn,
+		pending: map[uint32]chan *Message{},
+		signals: make(chan *Message, signalBuffer),
+		done:    make(chan struct{}),
+	}
+	go c.readLoop()
+
+	reply, err := c.Call(busName, busPath, busName, "Hello")
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("dbus hello: %w", err)
+	}
+	if c.name, err = First[string]("Hello", reply); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	var b [1]byte
+	for len(line) < 512 {
+		if _, err := r.Read(b[:]); err != nil {
+			return "", err
+		}
+		if b[0] == '\n' {
+			return strings.TrimSuffix(string(line), "\r"), nil
+		}
+		line = append(line, b[0])
+	}
+	return "", errors.New("line too long")
+}
+
+// Name is the unique name (":1.42") the bus gave this connection.
+func (c *Conn) Name() string {
+	return c.name
+}
+
+// Close ends the connection. Pending calls fail with ErrClosed.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// Done is closed when the connection ends; Err says why.
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the connection ended, nil while it is up.
+func (c *Conn) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Signals delivers the signals matched by AddMatch rules. It is closed
+// with the connection.
+func (c *Conn) Signals() <-chan *Message {
+	return c.signals
+}
+
+// Handle sets the handler for incoming method calls. Without one they are
+// answered with UnknownMethod.
+func (c *Conn) Handle(h Handler) {
+	c.mu.Lock()
+	c.handler = h
+	c.mu.Unlock()
+}
+
+// Call invokes a method and waits for its reply body.
+func (c *Conn) Call(dest string, path ObjectPath, iface, method string, args ...any) ([]any, error) {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	c.serial++
+	serial := c.serial
+	ch := make(chan *Message, 1)
+	c.pending[serial] = ch
+	c.mu.Unlock()
+
+	err := c.send(&Message{
+		Type: TypeMethodCall, Serial: serial,
+		Destination: dest, Path: path, Interface: iface, Member: method, Body: args,
+	})
+	if err != nil {
+		c.mu.Lock()
+		delete(c.pending, serial)
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	select {
+	case reply, ok := <-ch:
+		if !ok {
+			return nil, c.Err()
+		}
+		if reply.Type == TypeError {
+			return nil, &Error{Name: reply.ErrorName, Body: reply.Body}
+		}
+		return reply.Body, nil
+	case <-time.After(callTimeout):
+		c.mu.Lock()
+		delete(c.pending, serial)
+		c.mu.Unlock()
+		return nil, fmt.Errorf("dbus: %s.%s on %s timed out", iface, method, dest)
+	}
+}
+
+// Emit sends a signal.
+func (c *Conn) Emit(path ObjectPath, iface, member string, args ...any) error {
+	return c.send(&Message{Type: TypeSignal, Path: path, Interface: iface, Member: member, Body: args})
+}
+
+func (c *Conn) send(m *Message) error {
+	if m.Serial == 0 {
+		c.mu.Lock()
+		c.serial++
+		m.Serial = c.serial
+		c.mu.Unlock()
+	}
+	b, err := m.encode()
+	if err != nil {
+		return err
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(callTimeout))
+	_, err = c.conn.Write(b)
+	return err
+}
+
+// readLoop dispatches replies, signals and calls until the connection
+// fails.
+func (c *Conn) readLoop() {
+	err := c.read()
+	if errors.Is(err, net.ErrClosed) || errors.Is(err, io.EOF) {
+		err = ErrClosed
+	}
+
+	c.mu.Lock()
+	c.err = err
+	for serial, ch := range c.pending {
+		close(ch)
+		delete(c.pending, serial)
+	}
+	c.mu.Unlock()
+	close(c.signals)
+	close(c.done)
+	c.conn.Close()
+}
+
+func (c *Conn) read() error {
+	r := bufio.NewReader(c.conn)
+	for {
+		m, err := readMessage(r)
+		if err != nil {
+			return err
+		}
+		switch m.Type {
+		case TypeMethodReturn, TypeError:
+			c.mu.Lock()
+			ch := c.pending[m.ReplySerial]
+			delete(c.pending, m.ReplySerial)
+			c.mu.Unlock()
+			if ch != nil {
+				ch <- m
+			}
+		case TypeSignal:
+			c.emit(m)
+		case TypeMethodCall:
+			c.mu.Lock()
+			h := c.handler
+			c.mu.Unlock()
+			// off the read loop, a handler may make calls itself
+			go c.answer(m, h)
+		}
+	}
+}
+
+// answer runs the handler for a call and sends its reply.
+func (c *Conn) answer(call *Message, h Handler) {
+	var body []any
+	err := error(&Error{Name: "org.freedesktop.DBus.Error.UnknownMethod",
+		Body: []any{fmt.Sprintf("no method %s.%s", call.Interface, call.Member)}})
+	if h != nil {
+		body, err = h(call)
+	}
+	if call.Flags&FlagNoReplyExpected != 0 {
+		return
+	}
+	reply := &Message{Type: TypeMethodReturn, ReplySerial: call.Serial, Destination: call.Sender, Body: body}
+	if err != nil {
+		var derr *Error
+		if !errors.As(err, &derr) {
+			derr = &Error{Name: "org.freedesktop.DBus.Error.Failed", Body: []any{err.Error()}}
+		}
+		reply = &Message{Type: TypeError, ReplySerial: call.Serial, Destination: call.Sender,
+			ErrorName: derr.Name, Body: derr.Body}
+	}
+	c.send(reply)
+}
+
+// emit queues a signal without ever blocking the read loop. When the
+// reader falls behind the queue is replaced by Resync.
+func (c *Conn) emit(m *Message) {
+	select {
+	case c.signals <- m:
+		return
+	default:
+	}
+	for {
+		select {
+		case <-c.signals:
+			continue
+		default:
+		}
+		break
+	}
+	c.signals <- Resync
+}
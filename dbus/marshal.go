@@ -0,0 +1,408 @@
+package dbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ObjectPath is a D-Bus object path ('o').
+type ObjectPath string
+
+// Signature is a D-Bus type signature ('g').
+type Signature string
+
+// Variant is a value together with its type ('v').
+type Variant struct {
+	Sig   Signature
+	Value any
+}
+
+// MakeVariant wraps v, taking the signature from its Go type.
+func MakeVariant(v any) Variant {
+	sig, _ := signatureOf(v)
+	return Variant{Sig: sig, Value: v}
+}
+
+const maxMessage = 128 * 1024 * 1024
+
+var errShort = errors.New("dbus: truncated message")
+
+// signatureOf maps the Go types the encoder accepts to D-Bus types.
+func signatureOf(v any) (Signature, error) {
+	switch v.(type) {
+	case byte:
+		return "y", nil
+	case bool:
+		return "b", nil
+	case int16:
+		return "n", nil
+	case uint16:
+		return "q", nil
+	case int32:
+		return "i", nil
+	case uint32:
+		return "u", nil
+	case int64:
+		return "x", nil
+	case uint64:
+		return "t", nil
+	case float64:
+		return "d", nil
+	case string:
+		return "s", nil
+	case ObjectPath:
+		return "o", nil
+	case Signature:
+		return "g", nil
+	case Variant:
+		return "v", nil
+	case []string:
+		return "as", nil
+	case []ObjectPath:
+		return "ao", nil
+	case map[string]Variant:
+		return "a{sv}", nil
+	}
+	return "", fmt.Errorf("dbus: cannot encode %T", v)
+}
+
+// alignment of a value whose signature starts with c.
+func alignment(c byte) int {
+	switch c {
+	case 'n', 'q':
+		return 2
+	case 'b', 'i', 'u', 's', 'o', 'a', 'h':
+		return 4
+	case 'x', 't', 'd', '(', '{':
+		return 8
+	}
+	return 1 // y g v
+}
+
+// encoder builds a message. Offsets are from the start of the message, so
+// a body is encoded on its own (the header ends 8 byte aligned).
+type encoder struct {
+	buf   []byte
+	order binary.AppendByteOrder
+	err   error
+}
+
+func newEncoder() *encoder {
+	return &encoder{order: binary.LittleEndian}
+}
+
+func (e *encoder) align(n int) {
+	for len(e.buf)%n != 0 {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *encoder) u32(v uint32) {
+	e.align(4)
+	e.buf = e.order.AppendUint32(e.buf, v)
+}
+
+func (e *encoder) str(s string) {
+	e.u32(uint32(len(s)))
+	e.buf = append(append(e.buf, s...), 0)
+}
+
+func (e *encoder) sig(s Signature) {
+	e.buf = append(append(append(e.buf, byte(len(s))), s...), 0)
+}
+
+// array writes the length prefix and elements written by each. The length
+// excludes the padding before the first element.
+func (e *encoder) array(elemAlign int, each func()) {
+	e.u32(0)
+	at := len(e.buf)
+	e.align(elemAlign)
+	start := len(e.buf)
+	each()
+	binary.LittleEndian.PutUint32(e.buf[at-4:], uint32(len(e.buf)-start))
+}
+
+func (e *encoder) value(v any) {
+	switch v := v.(type) {
+	case byte:
+		e.buf = append(e.buf, v)
+	case bool:
+		var b uint32
+		if v {
+			b = 1
+		}
+		e.u32(b)
+	case int16:
+		e.align(2)
+		e.buf = e.order.AppendUint16(e.buf, uint16(v))
+	case uint16:
+		e.align(2)
+		e.buf = e.order.AppendUint16(e.buf, v)
+	case int32:
+		e.u32(uint32(v))
+	case uint32:
+		e.u32(v)
+	case int64:
+		e.align(8)
+		e.buf = e.order.AppendUint64(e.buf, uint64(v))
+	case uint64:
+		e.align(8)
+		e.buf = e.order.AppendUint64(e.buf, v)
+	case float64:
+		e.align(8)
+		e.buf = e.order.AppendUint64(e.buf, math.Float64bits(v))
+	case string:
+		e.str(v)
+	case ObjectPath:
+		e.str(string(v))
+	case Signature:
+		e.sig(v)
+	case Variant:
+		sig := v.Sig
+		if sig == "" {
+			var err error
+			if sig, err = signatureOf(v.Value); err != nil {
+				e.err = err
+				return
+			}
+		}
+		e.sig(sig)
+		e.value(v.Value)
+	case []string:
+		e.array(4, func() {
+			for _, s := range v {
+				e.str(s)
+			}
+		})
+	case []ObjectPath:
+		e.array(4, func() {
+			for _, s := range v {
+				e.str(string(s))
+			}
+		})
+	case map[string]Variant:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		e.array(8, func() {
+			for _, k := range keys {
+				e.align(8)
+				e.str(k)
+				e.value(v[k])
+			}
+		})
+	default:
+		e.err = fmt.Errorf("dbus: cannot encode %T", v)
+	}
+}
+
+// decoder reads values; the first error sticks and later reads return
+// zero values.
+type decoder struct {
+	b     []byte
+	pos   int // offset in b
+	base  int // offset of b[0] in the message
+	order binary.ByteOrder
+	err   error
+}
+
+func (d *decoder) align(n int) {
+	off := d.base + d.pos
+	if pad := (n - off%n) % n; pad > 0 {
+		d.take(pad)
+	}
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || d.pos+n > len(d.b) {
+		d.err = errShort
+		return nil
+	}
+	b := d.b[d.pos : d.pos+n]
+	d.pos += n
+	return b
+}
+
+func (d *decoder) u32() uint32 {
+	d.align(4)
+	if b := d.take(4); b != nil {
+		return d.order.Uint32(b)
+	}
+	return 0
+}
+
+func (d *decoder) u64() uint64 {
+	d.align(8)
+	if b := d.take(8); b != nil {
+		return d.order.Uint64(b)
+	}
+	return 0
+}
+
+func (d *decoder) str() string {
+	n := d.u32()
+	if n > maxMessage {
+		d.err = errShort
+		return ""
+	}
+	b := d.take(int(n) + 1)
+	if b == nil {
+		return ""
+	}
+	return string(b[:n])
+}
+
+func (d *decoder) sig() Signature {
+	b := d.take(1)
+	if b == nil {
+		return ""
+	}
+	s := d.take(int(b[0]) + 1)
+	if s == nil {
+		return ""
+	}
+	return Signature(s[:b[0]])
+}
+
+// values decodes a sequence of complete types.
+func (d *decoder) values(sig Signature) []any {
+	var out []any
+	rest := string(sig)
+	for rest != "" && d.err == nil {
+		var t string
+		t, rest, d.err = nextType(rest)
+		out = append(out, d.value(t))
+	}
+	return out
+}
+
+// value decodes one complete type. Arrays of strings become []string,
+// dicts map[string]any keyed by the key as text, other arrays []any and
+// structs []any of their fields.
+func (d *decoder) value(t string) any {
+	if d.err != nil {
+		return nil
+	}
+	switch t[0] {
+	case 'y':
+		if b := d.take(1); b != nil {
+			return b[0]
+		}
+	case 'b':
+		return d.u32() != 0
+	case 'n', 'q':
+		d.align(2)
+		if b := d.take(2); b != nil {
+			if t[0] == 'n' {
+				return int16(d.order.Uint16(b))
+			}
+			return d.order.Uint16(b)
+		}
+	case 'i':
+		return int32(d.u32())
+	case 'u', 'h':
+		return d.u32()
+	case 'x':
+		return int64(d.u64())
+	case 't':
+		return d.u64()
+	case 'd':
+		return math.Float64frombits(d.u64())
+	case 's':
+		return d.str()
+	case 'o':
+		return ObjectPath(d.str())
+	case 'g':
+		return d.sig()
+	case 'v':
+		sig := d.sig()
+		vt, rest, err := nextType(string(sig))
+		if err != nil || rest != "" {
+			d.err = fmt.Errorf("dbus: bad variant signature %q", sig)
+			return nil
+		}
+		return Variant{Sig: sig, Value: d.value(vt)}
+	case '(':
+		d.align(8)
+		return d.values(Signature(t[1 : len(t)-1]))
+	case 'a':
+		return d.array(t[1:])
+	}
+	return nil
+}
+
+func (d *decoder) array(elem string) any {
+	n := d.u32()
+	if n > maxMessage {
+		d.err = errShort
+		return nil
+	}
+	d.align(alignment(elem[0]))
+	end := d.pos + int(n)
+
+	if elem[0] == '{' {
+		kt, vt, _ := nextType(elem[1 : len(elem)-1])
+		m := map[string]any{}
+		for d.pos < end && d.err == nil {
+			d.align(8)
+			k := d.value(kt)
+			m[fmt.Sprint(k)] = d.value(vt)
+		}
+		return m
+	}
+	if elem == "s" {
+		var out []string
+		for d.pos < end && d.err == nil {
+			out = append(out, d.str())
+		}
+		return out
+	}
+	var out []any
+	for d.pos < end && d.err == nil {
+		out = append(out, d.value(elem))
+	}
+	return out
+}
+
+// nextType splits the first complete type off a signature.
+func nextType(sig string) (t, rest string, err error) {
+	if sig == "" {
+		return "", "", errors.New("dbus: empty signature")
+	}
+	switch sig[0] {
+	case 'a':
+		t, rest, err = nextType(sig[1:])
+		return "a" + t, rest, err
+	case '(', '{':
+		closer := map[byte]byte{'(': ')', '{': '}'}[sig[0]]
+		depth := 0
+		for i := 0; i < len(sig); i++ {
+			switch sig[i] {
+			case '(', '{':
+				depth++
+			case ')', '}':
+				depth--
+				if depth == 0 {
+					if sig[i] != closer {
+						return "", "", fmt.Errorf("dbus: bad signature %q", sig)
+					}
+					return sig[:i+1], sig[i+1:], nil
+				}
+			}
+		}
+		return "", "", fmt.Errorf("dbus: unbalanced signature %q", sig)
+	}
+	if !strings.ContainsRune("ybnqiuxtdsogvh", rune(sig[0])) {
+		return "", "", fmt.Errorf("dbus: unknown type %q", sig[0])
+	}
+	return sig[:1], sig[1:], nil
+}
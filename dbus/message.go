@@ -0,0 +1,190 @@
+package dbus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// MessageType is the kind of a message.
+type MessageType byte
+
+const (
+	TypeMethodCall   MessageType = 1
+	TypeMethodReturn MessageType = 2
+	TypeError        MessageType = 3
+	TypeSignal       MessageType = 4
+)
+
+// Message flags.
+const (
+	FlagNoReplyExpected byte = 0x1
+	FlagNoAutoStart     byte = 0x2
+)
+
+// Header field codes.
+const (
+	fieldPath        = 1
+	fieldInterface   = 2
+	fieldMember      = 3
+	fieldErrorName   = 4
+	fieldReplySerial = 5
+	fieldDestination = 6
+	fieldSender      = 7
+	fieldSignature   = 8
+)
+
+// Message is a decoded D-Bus message.
+type Message struct {
+	Type        MessageType
+	Flags       byte
+	Serial      uint32
+	Path        ObjectPath
+	Interface   string
+	Member      string
+	ErrorName   string
+	ReplySerial uint32
+	Destination string
+	Sender      string
+	Signature   Signature
+	Body        []any
+}
+
+// encode serialises the message, little endian.
+func (m *Message) encode() ([]byte, error) {
+	body := newEncoder()
+	var sig Signature
+	for _, v := range m.Body {
+		s, err := signatureOf(v)
+		if err != nil {
+			return nil, err
+		}
+		sig += s
+		body.value(v)
+	}
+	if body.err != nil {
+		return nil, body.err
+	}
+
+	h := newEncoder()
+	h.buf = append(h.buf, 'l', byte(m.Type), m.Flags, 1)
+	h.u32(uint32(len(body.buf)))
+	h.u32(m.Serial)
+	h.array(8, func() {
+		field := func(code byte, v any) {
+			h.align(8)
+			h.buf = append(h.buf, code)
+			h.value(MakeVariant(v))
+		}
+		if m.Path != "" {
+			field(fieldPath, m.Path)
+		}
+		if m.Interface != "" {
+			field(fieldInterface, m.Interface)
+		}
+		if m.Member != "" {
+			field(fieldMember, m.Member)
+		}
+		if m.ErrorName != "" {
+			field(fieldErrorName, m.ErrorName)
+		}
+		if m.ReplySerial != 0 {
+			field(fieldReplySerial, m.ReplySerial)
+		}
+		if m.Destination != "" {
+			field(fieldDestination, m.Destination)
+		}
+		if sig != "" {
+			field(fieldSignature, sig)
+		}
+	})
+	h.align(8)
+	if h.err != nil {
+		return nil, h.err
+	}
+	return append(h.buf, body.buf...), nil
+}
+
+// readMessage reads one message from r.
+func readMessage(r io.Reader) (*Message, error) {
+	var fixed [16]byte
+	if _, err := io.ReadFull(r, fixed[:]); err != nil {
+		return nil, err
+	}
+	var order binary.ByteOrder
+	switch fixed[0] {
+	case 'l':
+		order = binary.LittleEndian
+	case 'B':
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("dbus: bad endianness %q", fixed[0])
+	}
+	bodyLen := order.Uint32(fixed[4:])
+	fieldsLen := order.Uint32(fixed[12:])
+	if bodyLen > maxMessage || fieldsLen > maxMessage {
+		return nil, fmt.Errorf("dbus: %d byte message", bodyLen+fieldsLen)
+	}
+	headerLen := 16 + int(fieldsLen)
+	headerLen += (8 - headerLen%8) % 8
+	rest := make([]byte, headerLen-16+int(bodyLen))
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return nil, err
+	}
+
+	m := &Message{
+		Type:   MessageType(fixed[1]),
+		Flags:  fixed[2],
+		Serial: order.Uint32(fixed[8:]),
+	}
+	d := &decoder{b: rest[:fieldsLen], base: 16, order: order}
+	for d.pos < len(d.b) && d.err == nil {
+		d.align(8)
+		code := d.value("y")
+		v, _ := d.value("v").(Variant)
+		switch code {
+		case byte(fieldPath):
+			p, _ := v.Value.(ObjectPath)
+			m.Path = p
+		case byte(fieldInterface):
+			m.Interface, _ = v.Value.(string)
+		case byte(fieldMember):
+			m.Member, _ = v.Value.(string)
+		case byte(fieldErrorName):
+			m.ErrorName, _ = v.Value.(string)
+		case byte(fieldReplySerial):
+			m.ReplySerial, _ = v.Value.(uint32)
+		case byte(fieldDestination):
+			m.Destination, _ = v.Value.(string)
+		case byte(fieldSender):
+			m.Sender, _ = v.Value.(string)
+		case byte(fieldSignature):
+			m.Signature, _ = v.Value.(Signature)
+		}
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	body := &decoder{b: rest[headerLen-16:], base: headerLen, order: order}
+	m.Body = body.values(m.Signature)
+	if body.err != nil {
+		return nil, fmt.Errorf("dbus: %s.%s body: %w", m.Interface, m.Member, body.err)
+	}
+	return m, nil
+}
+
+// Error is an error reply.
+type Error struct {
+	Name string
+	Body []any
+}
+
+func (e *Error) Error() string {
+	if len(e.Body) > 0 {
+		if s, ok := e.Body[0].(string); ok {
+			return fmt.Sprintf("dbus: %s: %s", e.Name, s)
+		}
+	}
+	return "dbus: " + e.Name
+}
@@ -0,0 +1,92 @@
+package dbus
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	in := &Message{
+		Type:        TypeSignal,
+		Flags:       FlagNoReplyExpected,
+		Serial:      7,
+		Path:        "/org/mpris/MediaPlayer2",
+		Interface:   PropertiesIfc,
+		Member:      "PropertiesChanged",
+		Destination: ":1.42",
+		Body: []any{
+			"org.mpris.MediaPlayer2.Player",
+			map[string]Variant{
+				"PlaybackStatus": MakeVariant("Playing"),
+				"Rate":           MakeVariant(1.5),
+				"Metadata": MakeVariant(map[string]Variant{
+					"mpris:trackid": MakeVariant(ObjectPath("/track/1")),
+					"mpris:length":  MakeVariant(int64(245_000_000)),
+					"xesam:artist":  MakeVariant([]string{"A", "B"}),
+				}),
+			},
+			[]string{},
+			byte(3), true, int16(-2), uint16(2), int32(-4), uint32(4), uint64(1 << 40),
+			Signature("a{sv}"),
+			[]ObjectPath{"/a", "/b"},
+		},
+	}
+	b, err := in.encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := readMessage(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out.Type != in.Type || out.Flags != in.Flags || out.Serial != in.Serial || out.Path != in.Path ||
+		out.Interface != in.Interface || out.Member != in.Member || out.Destination != in.Destination {
+		t.Errorf("header = %+v", out)
+	}
+	if out.Signature != "sa{sv}asybnqiutgao" {
+		t.Errorf("signature = %q", out.Signature)
+	}
+
+	// the decoder hands dicts back as map[string]any with variants inside
+	props, _ := out.Body[1].(map[string]any)
+	got := Unwrap(props)
+	md := Unwrap(got["Metadata"].(map[string]any))
+	want := map[string]any{
+		"mpris:trackid": ObjectPath("/track/1"),
+		"mpris:length":  int64(245_000_000),
+		"xesam:artist":  []string{"A", "B"},
+	}
+	if got["PlaybackStatus"] != "Playing" || got["Rate"] != 1.5 || !reflect.DeepEqual(md, want) {
+		t.Errorf("properties = %v, metadata %v", got, md)
+	}
+	if s, ok := out.Body[2].([]string); !ok || len(s) != 0 {
+		t.Errorf("empty array = %#v", out.Body[2])
+	}
+	// other arrays come back as []any
+	rest := []any{byte(3), true, int16(-2), uint16(2), int32(-4), uint32(4), uint64(1 << 40), Signature("a{sv}"),
+		[]any{ObjectPath("/a"), ObjectPath("/b")}}
+	if !reflect.DeepEqual(out.Body[3:], rest) {
+		t.Errorf("body = %#v, want %#v", out.Body[3:], rest)
+	}
+}
+
+func TestMessageUnencodable(t *testing.T) {
+	m := &Message{Type: TypeMethodCall, Member: "X", Body: []any{struct{}{}}}
+	if _, err := m.encode(); err == nil || !strings.Contains(err.Error(), "cannot encode") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestReadMessageTruncated(t *testing.T) {
+	m := &Message{Type: TypeMethodReturn, Serial: 1, ReplySerial: 3, Body: []any{"hello"}}
+	b, err := m.encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readMessage(bytes.NewReader(b[:len(b)-3])); err == nil {
+		t.Error("truncated message read without an error")
+	}
+}
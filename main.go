@@ -3,26 +3,75 @@ package main
 import (
 	"fmt"
 	"log"
+	"maps"
 	"os"
+	"slices"
 
+	"github.com/antiloger/termctlr/config"
+	"github.com/antiloger/termctlr/control"
+	"github.com/antiloger/termctlr/theme"
 	"github.com/antiloger/termctlr/weidget"
 	"github.com/antiloger/termctlr/weidget/audio"
 	"github.com/antiloger/termctlr/weidget/clock"
+	"github.com/antiloger/termctlr/weidget/command"
+	"github.com/antiloger/termctlr/weidget/containers"
+	"github.com/antiloger/termctlr/weidget/git"
+	"github.com/antiloger/termctlr/weidget/media"
 	sysinfo "github.com/antiloger/termctlr/weidget/sysInfo"
 	sysmonitor "github.com/antiloger/termctlr/weidget/sysMonitor"
+	"github.com/antiloger/termctlr/weidget/systemd"
+	"github.com/antiloger/termctlr/weidget/tail"
+	"github.com/antiloger/termctlr/weidget/terminal"
+	"github.com/antiloger/termctlr/weidget/weather"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func main() {
-	clockWidget := clock.NewClockWidget()
-	specWidget := sysinfo.NewSysInfoWidget()
-	audioWidget, err := audio.NewModel()
+	if len(os.Args) > 1 && os.Args[1] == "ctl" {
+		os.Exit(runCtl(os.Args[2:]))
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Println(err)
+	}
+	t, err := theme.Load(cfg)
+	if err != nil {
+		log.Println(err)
+	}
+	theme.Set(t)
+
+	clockWidget := clock.NewClockWidget(cfg.Clock)
+	specWidget := sysinfo.NewSysInfoWidget(cfg.SysInfo)
+	audioWidget := audio.NewModel(cfg.Audio) // connects in the background
+	mediaWidget := media.NewModel(cfg.Media)
+	containersWidget := containers.NewModel(cfg.Containers)
+	systemdWidget := systemd.NewModel(cfg.Systemd)
+	gitWidget := git.NewModel(cfg.Git)
+	weatherWidget := weather.NewModel(cfg.Weather)
 	sysMonitorWidget := sysmonitor.NewModel()
+
+	layout, err := weidget.ParseLayout(cfg.Layout)
 	if err != nil {
-		log.Fatal("Failed to initialize audio widget:", err)
+		log.Println(err)
+	}
+
+	widgets := []weidget.Weidget{&clockWidget, &specWidget, &audioWidget, &mediaWidget, &containersWidget, &systemdWidget, &gitWidget, &weatherWidget}
+	for _, c := range cfg.Commands {
+		commandWidget := command.NewModel(c)
+		widgets = append(widgets, &commandWidget)
+	}
+	for _, c := range cfg.Terminals {
+		terminalWidget := terminal.NewModel(c)
+		widgets = append(widgets, &terminalWidget)
+	}
+	for _, c := range cfg.Tails {
+		tailWidget := tail.NewModel(c)
+		widgets = append(widgets, &tailWidget)
 	}
+	widgets = append(widgets, &sysMonitorWidget)
 
-	weidgetScr := weidget.NewWeidgetScreen(weidget.Vertical, &clockWidget, &specWidget, &audioWidget, &sysMonitorWidget)
+	weidgetScr := weidget.NewWeidgetScreen(layout, widgets...)
 
 	screens := map[string]tea.Model{
 		"weidget": weidgetScr,
@@ -31,7 +80,22 @@ func main() {
 	m := NewModel(screens)
 	m.SetCurrentScreen("weidget")
 
-	if _, err := tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
+
+	// external commands (window manager hotkeys etc.) come in over a unix socket
+	targets := control.Targets{Screens: slices.Sorted(maps.Keys(screens)), Widgets: weidgetScr.Names()}
+	srv, err := control.Listen(control.SocketPath(), targets, p.Send)
+	if err != nil {
+		log.Println("control socket disabled:", err)
+	} else {
+		defer srv.Close()
+	}
+
+	final, err := p.Run()
+	if final, ok := final.(Model); ok {
+		final.Close() // stop child processes widgets started
+	}
+	if err != nil {
 		fmt.Println("Error running program:", err)
 		os.Exit(1)
 	}
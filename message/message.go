@@ -11,6 +11,56 @@ func SwitchScreen(index string) tea.Cmd {
 	}
 }
 
+// Widget focus, by widget name
+type FocusMsg string
+
+func Focus(name string) tea.Cmd {
+	return func() tea.Msg {
+		return FocusMsg(name)
+	}
+}
+
+// Audio control
+type AudioDevice int
+
+const (
+	AudioOut AudioDevice = iota // default sink (speaker)
+	AudioIn                     // default source (mic)
+)
+
+// AudioVolumeMsg sets the volume of a device. When Relative is true, Value
+// is added to the current volume instead of replacing it.
+type AudioVolumeMsg struct {
+	Device   AudioDevice
+	Value    int
+	Relative bool
+}
+
+func AudioVolume(device AudioDevice, value int, relative bool) tea.Cmd {
+	return func() tea.Msg {
+		return AudioVolumeMsg{Device: device, Value: value, Relative: relative}
+	}
+}
+
+type MuteAction int
+
+const (
+	MuteToggle MuteAction = iota
+	MuteOn
+	MuteOff
+)
+
+type AudioMuteMsg struct {
+	Device AudioDevice
+	Action MuteAction
+}
+
+func AudioMute(device AudioDevice, action MuteAction) tea.Cmd {
+	return func() tea.Msg {
+		return AudioMuteMsg{Device: device, Action: action}
+	}
+}
+
 // Other custom messages
 type QuitMsg struct{}
 
@@ -19,3 +69,19 @@ func Quit() tea.Cmd {
 		return QuitMsg{}
 	}
 }
+
+// TimerDoneMsg is emitted when a countdown or pomodoro phase completes.
+// The root model turns it into a bell and a desktop notification.
+type TimerDoneMsg struct {
+	Name string
+}
+
+// AlarmMsg is emitted when an alarm rings. With Tone set the audio widget
+// plays an alarm tone until AlarmStopMsg.
+type AlarmMsg struct {
+	Label string
+	Tone  bool
+}
+
+// AlarmStopMsg ends a ringing alarm (dismissed or snoozed).
+type AlarmStopMsg struct{}
@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/antiloger/termctlr/message"
 	"github.com/antiloger/termctlr/types"
+	"github.com/antiloger/termctlr/weidget"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -12,6 +13,7 @@ type Model struct {
 	currScrreen string
 	shared      map[string]interface{}
 	quit        bool
+	bell        bool // ring the terminal bell with the next frame
 }
 
 func NewModel(screens map[string]tea.Model) Model {
@@ -43,12 +45,25 @@ func (m Model) Init() tea.Cmd {
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
 	switch msg := msg.(type) {
 	case message.SwitchScreenMsg:
-		m.currScrreen = string(msg)
+		if _, ok := m.screens[string(msg)]; ok {
+			m.currScrreen = string(msg)
+		}
 		return m, nil
 	case message.QuitMsg:
 		return m, tea.Quit
+	case message.TimerDoneMsg:
+		// nothing on screen needs it; ring and notify
+		bell := m.ring()
+		return m, tea.Batch(bell, notify("Timer finished", msg.Name))
+	case message.AlarmMsg:
+		// notify, and still pass it on: the audio widget plays the tone
+		cmd = tea.Batch(m.ring(), notify("Alarm", msg.Label))
+	case bellDoneMsg:
+		m.bell = false
+		return m, nil
 	case tea.WindowSizeMsg:
 		m.window.X = msg.Width
 		m.window.Y = msg.Height
@@ -61,17 +76,30 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 	currM, ok := m.screens[m.currScrreen]
 	if ok {
-		updated, cmd := currM.Update(msg)
+		updated, screenCmd := currM.Update(msg)
 		m.screens[m.currScrreen] = updated
-		return m, cmd
+		return m, tea.Batch(cmd, screenCmd)
 	}
 
-	return m, nil
+	return m, cmd
+}
+
+// Close closes the screens that hold resources, once the program exited.
+func (m Model) Close() {
+	for _, screen := range m.screens {
+		if c, ok := screen.(weidget.Closer); ok {
+			c.Close()
+		}
+	}
 }
 
 func (m Model) View() string {
+	view := "no screen found: " + m.currScrreen
 	if currM, ok := m.screens[m.currScrreen]; ok {
-		return currM.View()
+		view = currM.View()
+	}
+	if m.bell {
+		view = "\a" + view
 	}
-	return "no screen found: " + m.currScrreen
+	return view
 }
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/antiloger/termctlr/message"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestAlarmRingsBellInView(t *testing.T) {
+	var m tea.Model = NewModel(map[string]tea.Model{})
+	if strings.Contains(m.View(), "\a") {
+		t.Fatal("bell before any alarm")
+	}
+	m, _ = m.Update(message.AlarmMsg{Label: "wake"})
+	if !strings.HasPrefix(m.View(), "\a") {
+		t.Errorf("view after an alarm = %q, want the bell first", m.View())
+	}
+	m, _ = m.Update(bellDoneMsg{})
+	if strings.Contains(m.View(), "\a") {
+		t.Error("bell still in the view")
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os/exec"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// bellFor is how long the bell stays in the view. The renderer skips
+// lines it drew last frame, so it writes the bell once in that time, and
+// it is long enough for a frame to be drawn.
+const bellFor = 100 * time.Millisecond
+
+type bellDoneMsg struct{}
+
+// ring puts the terminal bell in the view for bellFor. It goes out with
+// the frame instead of straight to stdout, which the renderer owns.
+func (m *Model) ring() tea.Cmd {
+	m.bell = true
+	return tea.Tick(bellFor, func(time.Time) tea.Msg { return bellDoneMsg{} })
+}
+
+// notify raises a desktop notification when notify-send is installed.
+// Failures are ignored: it is best effort.
+func notify(title, body string) tea.Cmd {
+	return func() tea.Msg {
+		if path, err := exec.LookPath("notify-send"); err == nil {
+			_ = exec.Command(path, "--app-name=termctrl", title, body).Run()
+		}
+		return nil
+	}
+}
@@ -0,0 +1,83 @@
+package theme
+
+import "github.com/charmbracelet/lipgloss"
+
+// Built-in palettes use CompleteColor so each profile gets a hand-picked
+// value instead of a lossy conversion. lipgloss detects the profile
+// (truecolor/256/16) and drops colors entirely when NO_COLOR is set.
+var builtins = map[string]func() *Theme{
+	"dark":          dark,
+	"light":         light,
+	"high-contrast": highContrast,
+}
+
+var defaultGlyphs = Glyphs{
+	GaugeFull:  "█",
+	GaugeEmpty: "░",
+	Dots:       "⠿",
+}
+
+func c(trueColor, ansi256, ansi string) lipgloss.CompleteColor {
+	return lipgloss.CompleteColor{TrueColor: trueColor, ANSI256: ansi256, ANSI: ansi}
+}
+
+func dark() *Theme {
+	return &Theme{
+		Name:   "dark",
+		Border: lipgloss.NormalBorder(),
+		Colors: map[Role]lipgloss.TerminalColor{
+			Text:       c("#d0d0d0", "252", "7"),
+			Muted:      c("#6c6c6c", "242", "8"),
+			Accent:     c("#5fafff", "75", "12"),
+			Alert:      c("#ff5f5f", "203", "9"),
+			Focus:      c("#5fafff", "75", "12"),
+			GaugeLow:   c("#87d787", "114", "10"),
+			GaugeMed:   c("#ffd75f", "221", "11"),
+			GaugeHigh:  c("#ff5f5f", "203", "9"),
+			GaugeEmpty: c("#444444", "238", "8"),
+		},
+		Glyphs: defaultGlyphs,
+	}
+}
+
+func light() *Theme {
+	return &Theme{
+		Name:   "light",
+		Border: lipgloss.NormalBorder(),
+		Colors: map[Role]lipgloss.TerminalColor{
+			Text:       c("#303030", "236", "0"),
+			Muted:      c("#8a8a8a", "245", "8"),
+			Accent:     c("#005faf", "25", "4"),
+			Alert:      c("#d70000", "160", "1"),
+			Focus:      c("#005faf", "25", "4"),
+			GaugeLow:   c("#008700", "28", "2"),
+			GaugeMed:   c("#af8700", "136", "3"),
+			GaugeHigh:  c("#d70000", "160", "1"),
+			GaugeEmpty: c("#d0d0d0", "252", "7"),
+		},
+		Glyphs: defaultGlyphs,
+	}
+}
+
+func highContrast() *Theme {
+	return &Theme{
+		Name:   "high-contrast",
+		Border: lipgloss.ThickBorder(),
+		Colors: map[Role]lipgloss.TerminalColor{
+			Text:       c("#ffffff", "15", "15"),
+			Muted:      c("#c0c0c0", "250", "7"),
+			Accent:     c("#00ffff", "51", "14"),
+			Alert:      c("#ff0000", "196", "9"),
+			Focus:      c("#ffff00", "226", "11"),
+			GaugeLow:   c("#00ff00", "46", "10"),
+			GaugeMed:   c("#ffff00", "226", "11"),
+			GaugeHigh:  c("#ff0000", "196", "9"),
+			GaugeEmpty: c("#808080", "244", "8"),
+		},
+		Glyphs: Glyphs{
+			GaugeFull:  "█",
+			GaugeEmpty: "·",
+			Dots:       "█",
+		},
+	}
+}
@@ -0,0 +1,175 @@
+package theme
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/antiloger/termctlr/config"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Role is a semantic color slot. Widgets ask for roles, never raw colors,
+// so a theme can restyle the whole dashboard.
+type Role string
+
+const (
+	Text       Role = "text"
+	Muted      Role = "muted"
+	Accent     Role = "accent"
+	Alert      Role = "alert"
+	Focus      Role = "focus" // border of the focused widget
+	GaugeLow   Role = "gauge.low"
+	GaugeMed   Role = "gauge.med"
+	GaugeHigh  Role = "gauge.high"
+	GaugeEmpty Role = "gauge.empty"
+)
+
+var roles = []Role{Text, Muted, Accent, Alert, Focus, GaugeLow, GaugeMed, GaugeHigh, GaugeEmpty}
+
+// Glyphs are the characters used to draw bars and indicators.
+type Glyphs struct {
+	GaugeFull  string
+	GaugeEmpty string
+	Dots       string
+}
+
+type Theme struct {
+	Name   string
+	Border lipgloss.Border
+	Colors map[Role]lipgloss.TerminalColor
+	Glyphs Glyphs
+}
+
+// Color returns the color of a role, or no color if the theme doesn't set it.
+func (t *Theme) Color(r Role) lipgloss.TerminalColor {
+	if c, ok := t.Colors[r]; ok {
+		return c
+	}
+	return lipgloss.NoColor{}
+}
+
+// Style returns a style with the role's foreground color.
+func (t *Theme) Style(r Role) lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(t.Color(r))
+}
+
+// FocusStyle frames the focused widget.
+func (t *Theme) FocusStyle() lipgloss.Style {
+	return lipgloss.NewStyle().Border(t.Border, true).BorderForeground(t.Color(Focus))
+}
+
+// GaugeRole picks the gauge color for a fill ratio in 0–1.
+func (t *Theme) GaugeRole(ratio float64) Role {
+	switch {
+	case ratio >= 0.85:
+		return GaugeHigh
+	case ratio >= 0.6:
+		return GaugeMed
+	}
+	return GaugeLow
+}
+
+// ── Current theme ─────────────────────────────────────────────────────────────
+
+var (
+	mu      sync.RWMutex
+	current = builtins["dark"]()
+)
+
+// Current returns the active theme. Widgets call it from View.
+func Current() *Theme {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+func Set(t *Theme) {
+	mu.Lock()
+	current = t
+	mu.Unlock()
+}
+
+// Names lists the built-in theme names.
+func Names() []string {
+	names := make([]string, 0, len(builtins))
+	for n := range builtins {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load resolves the configured theme: user themes first, then built-ins.
+func Load(cfg config.Config) (*Theme, error) {
+	name := cfg.Theme
+	if name == "" {
+		name = "dark"
+	}
+	if spec, ok := cfg.Themes[name]; ok {
+		return fromSpec(name, spec)
+	}
+	if b, ok := builtins[name]; ok {
+		return b(), nil
+	}
+	return builtins["dark"](), fmt.Errorf("theme: unknown theme %q", name)
+}
+
+func fromSpec(name string, spec config.ThemeSpec) (*Theme, error) {
+	base := spec.Base
+	if base == "" {
+		base = "dark"
+	}
+	b, ok := builtins[base]
+	if !ok {
+		return builtins["dark"](), fmt.Errorf("theme %s: unknown base %q", name, base)
+	}
+	t := b()
+	t.Name = name
+
+	if spec.Border != "" {
+		border, ok := borders[spec.Border]
+		if !ok {
+			return t, fmt.Errorf("theme %s: unknown border %q", name, spec.Border)
+		}
+		t.Border = border
+	}
+
+	for key, c := range spec.Colors {
+		if !validRole(Role(key)) {
+			return t, fmt.Errorf("theme %s: unknown color role %q", name, key)
+		}
+		t.Colors[Role(key)] = lipgloss.Color(c)
+	}
+
+	for key, g := range spec.Glyphs {
+		switch key {
+		case "gauge.full":
+			t.Glyphs.GaugeFull = g
+		case "gauge.empty":
+			t.Glyphs.GaugeEmpty = g
+		case "dots":
+			t.Glyphs.Dots = g
+		default:
+			return t, fmt.Errorf("theme %s: unknown glyph %q", name, key)
+		}
+	}
+	return t, nil
+}
+
+func validRole(r Role) bool {
+	for _, known := range roles {
+		if r == known {
+			return true
+		}
+	}
+	return false
+}
+
+var borders = map[string]lipgloss.Border{
+	"normal":  lipgloss.NormalBorder(),
+	"rounded": lipgloss.RoundedBorder(),
+	"thick":   lipgloss.ThickBorder(),
+	"double":  lipgloss.DoubleBorder(),
+	"hidden":  lipgloss.HiddenBorder(),
+}
@@ -19,3 +19,15 @@ func Tick() tea.Cmd {
 		return TickMsg(t)
 	})
 }
+
+// SizeMsg tells a widget the size it has been allotted by the layout.
+type SizeMsg struct {
+	Width  int
+	Height int
+}
+
+// MouseMsg is a mouse event delivered to a widget, with X and Y translated
+// into the widget's own coordinates (0,0 is its top-left cell).
+type MouseMsg struct {
+	tea.MouseEvent
+}
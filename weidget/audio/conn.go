@@ -0,0 +1,126 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/antiloger/termctlr/config"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Reconnect backoff while no sound server is reachable.
+const (
+	retryMin = 2 * time.Second
+	retryMax = 30 * time.Second
+)
+
+var errServerGone = errors.New("sound server went away")
+
+// connectedMsg is the result of a connection attempt made off the UI
+// goroutine. w is nil when it failed.
+type connectedMsg struct {
+	w   *AudioWidget
+	err error
+}
+
+// retryMsg asks for another attempt; gen drops timers from older attempts
+// (e.g. after a manual retry).
+type retryMsg struct{ gen int }
+
+// SubscriberClosedMsg is sent when a subscriber ended, either because it
+// was stopped or because the connection to the sound server broke.
+type SubscriberClosedMsg struct {
+	sub *Subscriber
+}
+
+// connect opens the devices and reads the volumes. Device initialisation
+// happens here and only here.
+func connect(cfg config.AudioConfig) tea.Cmd {
+	return func() tea.Msg {
+		w, err := New(cfg.Hop, cfg.MaxInVolume, cfg.MaxOutVolume)
+		return connectedMsg{w: w, err: err}
+	}
+}
+
+func retryAfter(d time.Duration, gen int) tea.Cmd {
+	return tea.Tick(d, func(time.Time) tea.Msg {
+		return retryMsg{gen: gen}
+	})
+}
+
+// handleConn deals with the connection lifecycle messages.
+func (m Model) handleConn(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case connectedMsg:
+		m.connecting = false
+		if msg.err != nil {
+			return m.disconnected(msg.err)
+		}
+		m.audio, m.reason, m.backoff = msg.w, nil, 0
+		m.sub = NewSubscriber(msg.w.client)
+		m.conn++
+		m.mic.ptt = false
+		return m, tea.Batch(WaitForEvents(m.sub), levelTick(m.conn))
+
+	case retryMsg:
+		if msg.gen != m.gen || m.audio != nil || m.connecting {
+			return m, nil
+		}
+		m.connecting = true
+		return m, connect(m.cfg)
+
+	case SubscriberClosedMsg:
+		if msg.sub != m.sub || m.audio == nil {
+			return m, nil // stopped on purpose
+		}
+		err := errServerGone
+		if cerr := m.audio.client.Err(); cerr != nil {
+			err = fmt.Errorf("%w: %v", errServerGone, cerr)
+		}
+		m.drop()
+		return m.disconnected(err)
+
+	case refreshedMsg:
+		if m.audio == nil {
+			return m, nil
+		}
+		if msg.err != nil {
+			// the server stopped answering; drop the devices and reconnect
+			m.drop()
+			return m.disconnected(fmt.Errorf("%w: %v", errServerGone, msg.err))
+		}
+		m.audio.apply(msg)
+	}
+	return m, nil
+}
+
+// drop closes the current connection. The mic test panel closes with it,
+// so it does not reopen on the next connection's devices.
+func (m *Model) drop() {
+	m.sub.Stop()
+	m.audio.Close()
+	m.audio, m.sub = nil, nil
+	m.test = testState{level: signalLevel}
+}
+
+// disconnected records why the widget has no devices and schedules the
+// next attempt with exponential backoff.
+func (m Model) disconnected(reason error) (Model, tea.Cmd) {
+	m.reason = reason
+	m.backoff = min(max(m.backoff*2, retryMin), retryMax)
+	m.retryAt = m.now.Add(m.backoff)
+	m.gen++
+	return m, retryAfter(m.backoff, m.gen)
+}
+
+// retryNow skips the remaining backoff.
+func (m Model) retryNow() (Model, tea.Cmd) {
+	if m.audio != nil || m.connecting {
+		return m, nil
+	}
+	m.gen++
+	m.backoff = 0
+	m.connecting = true
+	return m, connect(m.cfg)
+}
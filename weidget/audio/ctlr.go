@@ -2,13 +2,11 @@ package audio
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"math"
-	"os/exec"
-	"regexp"
-	"strconv"
-	"strings"
 
+	"github.com/antiloger/termctlr/weidget/audio/pulse"
 	"github.com/gen2brain/malgo"
 )
 
@@ -16,6 +14,8 @@ import (
 
 // New initialises the AudioWidget and starts the input/output monitor streams.
 // hop is the volume step size (e.g. 5 for 5%), max values cap Inc operations.
+// It fails when there is no sound server to control; a missing capture or
+// playback device only disables the level meters (see MonitorErr).
 func New(hop, maxInVolume, maxOutVolume int) (*AudioWidget, error) {
 	if hop <= 0 {
 		hop = 5
@@ -31,32 +31,52 @@ func New(hop, maxInVolume, maxOutVolume int) (*AudioWidget, error) {
 		Hop:          hop,
 		MaxInVolume:  maxInVolume,
 		MaxOutVolume: maxOutVolume,
+		outIndex:     -1,
+		inIndex:      -1,
+	}
+
+	// no sound server, no widget
+	client, err := pulse.Dial(pulse.SocketPath())
+	if err != nil {
+		return nil, fmt.Errorf("sound server: %w", err)
+	}
+	w.client = client
+	if err := client.Subscribe(pulse.MaskSink | pulse.MaskSource | pulse.MaskServer); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("sound server: %w", err)
+	}
+	// pull current OS volumes into struct fields
+	if err := w.Sync(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("sound server: %w", err)
 	}
 
 	// init malgo context (no system libs needed — C is bundled)
 	ctx, err := malgo.InitContext(nil, malgo.ContextConfig{}, func(string) {})
 	if err != nil {
+		client.Close()
 		return nil, fmt.Errorf("malgo context: %w", err)
 	}
 	w.ctx = ctx
 
 	if err := w.startInputMonitor(); err != nil {
-		ctx.Uninit()
-		return nil, fmt.Errorf("input monitor: %w", err)
+		w.monitorErr = fmt.Errorf("input monitor: %w", err)
 	}
 	if err := w.startOutputMonitor(); err != nil {
-		w.inDevice.Uninit()
-		ctx.Uninit()
-		return nil, fmt.Errorf("output monitor: %w", err)
+		w.monitorErr = errors.Join(w.monitorErr, fmt.Errorf("output monitor: %w", err))
 	}
-
-	// pull current OS volumes into struct fields
-	_ = w.Sync()
 	return w, nil
 }
 
+// MonitorErr reports why the level meters are unavailable, if they are.
+func (w *AudioWidget) MonitorErr() error {
+	return w.monitorErr
+}
+
 // Close stops all streams and frees resources.
 func (w *AudioWidget) Close() {
+	w.StopTone()
+	w.StopTest()
 	if w.outDevice != nil {
 		w.outDevice.Stop()
 		w.outDevice.Uninit()
@@ -68,28 +88,45 @@ func (w *AudioWidget) Close() {
 	if w.ctx != nil {
 		w.ctx.Uninit()
 	}
+	if w.client != nil {
+		w.client.Close()
+	}
 }
 
-// ── OS Volume control (pactl) ─────────────────────────────────────────────────
+// ── OS Volume control (pulse) ────────────────────────────────────────────────
 
 // IncOut raises speaker volume by Hop, capped at MaxOutVolume.
-func (w *AudioWidget) IncOut() error {
-	return w.setOutVol(clamp(w.OutVolume+w.Hop, 0, w.MaxOutVolume))
-}
+func (w *AudioWidget) IncOut() error { return w.AddOut(w.Hop) }
 
 // DecOut lowers speaker volume by Hop.
-func (w *AudioWidget) DecOut() error {
-	return w.setOutVol(clamp(w.OutVolume-w.Hop, 0, w.MaxOutVolume))
-}
+func (w *AudioWidget) DecOut() error { return w.AddOut(-w.Hop) }
 
 // IncIn raises mic volume by Hop, capped at MaxInVolume.
-func (w *AudioWidget) IncIn() error {
-	return w.setInVol(clamp(w.InVolume+w.Hop, 0, w.MaxInVolume))
-}
+func (w *AudioWidget) IncIn() error { return w.AddIn(w.Hop) }
 
 // DecIn lowers mic volume by Hop.
-func (w *AudioWidget) DecIn() error {
-	return w.setInVol(clamp(w.InVolume-w.Hop, 0, w.MaxInVolume))
+func (w *AudioWidget) DecIn() error { return w.AddIn(-w.Hop) }
+
+// AddOut changes speaker volume by d percent, capped at MaxOutVolume.
+// A volume already above the cap (set by another mixer) is never lowered
+// by raising it.
+func (w *AudioWidget) AddOut(d int) error {
+	return w.setOutVol(step(w.OutVolume, d, w.MaxOutVolume))
+}
+
+// AddIn changes mic volume by d percent, like AddOut.
+func (w *AudioWidget) AddIn(d int) error {
+	return w.setInVol(step(w.InVolume, d, w.MaxInVolume))
+}
+
+// SetOut sets speaker volume to v percent, clamped to 0–MaxOutVolume.
+func (w *AudioWidget) SetOut(v int) error {
+	return w.setOutVol(clamp(v, 0, w.MaxOutVolume))
+}
+
+// SetIn sets mic volume to v percent, clamped to 0–MaxInVolume.
+func (w *AudioWidget) SetIn(v int) error {
+	return w.setInVol(clamp(v, 0, w.MaxInVolume))
 }
 
 // MuteOut mutes the speaker.
@@ -110,24 +147,112 @@ func (w *AudioWidget) UnmuteIn() error { return w.setInMute(false) }
 // ToggleMuteIn flips the mic mute state.
 func (w *AudioWidget) ToggleMuteIn() error { return w.setInMute(!w.InMuted) }
 
-// Sync reads current OS volume and mute state into the struct fields.
-func (w *AudioWidget) Sync() error {
+// balanceStep is how far one balance key press moves it (-1…1 range).
+const balanceStep = 0.1
+
+// OutSides returns the speaker's left and right volume in percent, and
+// whether the sink has separate sides at all.
+func (w *AudioWidget) OutSides() (left, right int, stereo bool) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
+	return sides(w.outMap, w.outChannels, w.OutVolume)
+}
 
-	vol, muted, err := osGetVolume("sink", "@DEFAULT_SINK@")
-	if err != nil {
+// InSides is OutSides for the mic.
+func (w *AudioWidget) InSides() (left, right int, stereo bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return sides(w.inMap, w.inChannels, w.InVolume)
+}
+
+func sides(m pulse.ChannelMap, vols pulse.ChannelVolumes, vol int) (left, right int, stereo bool) {
+	if !m.CanBalance() {
+		return vol, vol, false
+	}
+	l, r := m.Sides(vols)
+	return l.Percent(), r.Percent(), true
+}
+
+// OutBalance is the speaker balance, -1 (left) … 1 (right).
+func (w *AudioWidget) OutBalance() float64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.outMap.Balance(w.outChannels)
+}
+
+// InBalance is the mic balance, -1 (left) … 1 (right).
+func (w *AudioWidget) InBalance() float64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.inMap.Balance(w.inChannels)
+}
+
+// ShiftOutBalance moves the speaker balance by d, keeping the louder side
+// at its volume.
+func (w *AudioWidget) ShiftOutBalance(d float64) error {
+	return w.SetOutBalance(snapBalance(w.OutBalance() + d))
+}
+
+// ShiftInBalance moves the mic balance by d, like ShiftOutBalance.
+func (w *AudioWidget) ShiftInBalance(d float64) error {
+	return w.SetInBalance(snapBalance(w.InBalance() + d))
+}
+
+// snapBalance rounds to the step grid so repeated presses land back on
+// center.
+func snapBalance(b float64) float64 {
+	return math.Round(b/balanceStep) * balanceStep
+}
+
+// SetOutBalance sets the speaker balance, -1 (left) … 1 (right).
+func (w *AudioWidget) SetOutBalance(b float64) error {
+	w.mu.Lock()
+	if !w.outMap.CanBalance() {
+		w.mu.Unlock()
+		return errors.New("sink has no left/right channels")
+	}
+	vols := w.outMap.SetBalance(w.outChannels, b)
+	w.mu.Unlock()
+
+	if err := w.client.SetSinkVolume(pulse.DefaultSink, vols); err != nil {
 		return err
 	}
-	w.OutVolume = vol
-	w.OutMuted = muted
+	w.mu.Lock()
+	w.outChannels = vols
+	w.mu.Unlock()
+	return nil
+}
 
-	vol, muted, err = osGetVolume("source", "@DEFAULT_SOURCE@")
+// SetInBalance sets the mic balance, -1 (left) … 1 (right).
+func (w *AudioWidget) SetInBalance(b float64) error {
+	w.mu.Lock()
+	if !w.inMap.CanBalance() {
+		w.mu.Unlock()
+		return errors.New("source has no left/right channels")
+	}
+	vols := w.inMap.SetBalance(w.inChannels, b)
+	w.mu.Unlock()
+
+	if err := w.client.SetSourceVolume(pulse.DefaultSource, vols); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	w.inChannels = vols
+	w.mu.Unlock()
+	return nil
+}
+
+// Sync reads current OS volume and mute state into the struct fields.
+func (w *AudioWidget) Sync() error {
+	out, err := w.client.Sink(pulse.DefaultSink)
 	if err != nil {
 		return err
 	}
-	w.InVolume = vol
-	w.InMuted = muted
+	in, err := w.client.Source(pulse.DefaultSource)
+	if err != nil {
+		return err
+	}
+	w.apply(refreshedMsg{out: &out, in: &in})
 	return nil
 }
 
@@ -149,28 +274,38 @@ func (w *AudioWidget) OutLevel() (rms, db float64) {
 
 // ── Internal helpers ──────────────────────────────────────────────────────────
 
+// setOutVol scales the sink so its loudest channel is at v percent;
+// the other channels keep their balance.
 func (w *AudioWidget) setOutVol(v int) error {
-	if err := osSetVolume("sink", "@DEFAULT_SINK@", v); err != nil {
+	w.mu.Lock()
+	vols := w.outChannels.Scale(pulse.VolumeFromPercent(v))
+	w.mu.Unlock()
+	if err := w.client.SetSinkVolume(pulse.DefaultSink, vols); err != nil {
 		return err
 	}
 	w.mu.Lock()
 	w.OutVolume = v
+	w.outChannels = vols
 	w.mu.Unlock()
 	return nil
 }
 
 func (w *AudioWidget) setInVol(v int) error {
-	if err := osSetVolume("source", "@DEFAULT_SOURCE@", v); err != nil {
+	w.mu.Lock()
+	vols := w.inChannels.Scale(pulse.VolumeFromPercent(v))
+	w.mu.Unlock()
+	if err := w.client.SetSourceVolume(pulse.DefaultSource, vols); err != nil {
 		return err
 	}
 	w.mu.Lock()
 	w.InVolume = v
+	w.inChannels = vols
 	w.mu.Unlock()
 	return nil
 }
 
 func (w *AudioWidget) setOutMute(mute bool) error {
-	if err := osSetMute("sink", "@DEFAULT_SINK@", mute); err != nil {
+	if err := w.client.SetSinkMute(pulse.DefaultSink, mute); err != nil {
 		return err
 	}
 	w.mu.Lock()
@@ -180,7 +315,7 @@ func (w *AudioWidget) setOutMute(mute bool) error {
 }
 
 func (w *AudioWidget) setInMute(mute bool) error {
-	if err := osSetMute("source", "@DEFAULT_SOURCE@", mute); err != nil {
+	if err := w.client.SetSourceMute(pulse.DefaultSource, mute); err != nil {
 		return err
 	}
 	w.mu.Lock()
@@ -254,6 +389,11 @@ func rmsToDb(rms float64) float64 {
 	return 20 * math.Log10(rms)
 }
 
+// step moves v by d within 0–hi, or within 0–v when v is already past hi.
+func step(v, d, hi int) int {
+	return clamp(v+d, 0, max(hi, v))
+}
+
 func clamp(v, lo, hi int) int {
 	if v < lo {
 		return lo
@@ -263,40 +403,3 @@ func clamp(v, lo, hi int) int {
 	}
 	return v
 }
-
-// ── pactl OS calls ────────────────────────────────────────────────────────────
-
-var volRe = regexp.MustCompile(`(\d+)%`)
-
-func osGetVolume(kind, target string) (vol int, muted bool, err error) {
-	out, err := exec.Command("pactl", "get-"+kind+"-volume", target).Output()
-	if err != nil {
-		return 0, false, fmt.Errorf("pactl get-%s-volume: %w", kind, err)
-	}
-	m := volRe.FindStringSubmatch(string(out))
-	if m == nil {
-		return 0, false, fmt.Errorf("pactl: could not parse volume: %q", out)
-	}
-	vol, _ = strconv.Atoi(m[1])
-
-	muteOut, _ := exec.Command("pactl", "get-"+kind+"-mute", target).Output()
-	muted = strings.Contains(string(muteOut), "yes")
-	return vol, muted, nil
-}
-
-func osSetVolume(kind, target string, percent int) error {
-	_, err := exec.Command("pactl",
-		"set-"+kind+"-volume", target,
-		strconv.Itoa(percent)+"%",
-	).Output()
-	return err
-}
-
-func osSetMute(kind, target string, mute bool) error {
-	val := "0"
-	if mute {
-		val = "1"
-	}
-	_, err := exec.Command("pactl", "set-"+kind+"-mute", target, val).Output()
-	return err
-}
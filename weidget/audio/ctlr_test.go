@@ -0,0 +1,110 @@
+package audio
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/antiloger/termctlr/config"
+	"github.com/antiloger/termctlr/theme"
+	"github.com/antiloger/termctlr/weidget/audio/pulse"
+	"github.com/antiloger/termctlr/weidget/components"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+)
+
+func TestStep(t *testing.T) {
+	for _, c := range []struct{ v, d, hi, want int }{
+		{50, 5, 100, 55},
+		{98, 5, 100, 100},
+		{3, -5, 100, 0},
+		{130, 5, 100, 130}, // raising never lowers a volume above the cap
+		{130, -5, 100, 125},
+		{100, 5, 150, 105},
+	} {
+		if got := step(c.v, c.d, c.hi); got != c.want {
+			t.Errorf("step(%d, %d, %d) = %d, want %d", c.v, c.d, c.hi, got, c.want)
+		}
+	}
+}
+
+func TestDisconnectClosesMicTest(t *testing.T) {
+	m := NewModel(config.AudioConfig{})
+	m.audio = &AudioWidget{}
+	m.sub = &Subscriber{stop: make(chan struct{})}
+	m.test = testState{open: true, level: -6, path: "mic-test.wav", saved: &WAV{}}
+
+	next, _ := m.Update(refreshedMsg{err: errors.New("connection reset")})
+	m = next.(Model)
+	if m.audio != nil {
+		t.Fatal("still connected")
+	}
+	if m.test.open || m.test.saved != nil || m.test.level != signalLevel {
+		t.Errorf("mic test after disconnect = %+v", m.test)
+	}
+	if _, ok := m.Overlay(); ok {
+		t.Error("mic test overlay shown while disconnected")
+	}
+}
+
+func TestVolumeBarOverAmplified(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.ANSI256)
+	defer lipgloss.SetColorProfile(termenv.Ascii)
+	g := theme.Current().Glyphs
+	normal := barWidth * 100 / 150
+
+	// 100% of 150 fills the normal two thirds and leaves the tail empty
+	bar := ansi.Strip(volumeBar(100, 150, false))
+	if want := strings.Repeat(g.GaugeFull, normal) + strings.Repeat(g.GaugeEmpty, barWidth-normal); bar != want {
+		t.Errorf("100%% of 150 = %q, want %q", bar, want)
+	}
+	// above 100 the tail fills in the alert color, the rest as usual
+	bar = volumeBar(150, 150, false)
+	tail := theme.Current().Style(theme.Alert).Render(strings.Repeat(g.GaugeFull, barWidth-normal))
+	if !strings.HasPrefix(bar, components.NewGauge(1, 1).Render(normal)) || !strings.Contains(bar, tail) {
+		t.Errorf("150%% of 150 = %q, want an alert tail %q", bar, tail)
+	}
+	if w := ansi.StringWidth(volumeBar(40, 100, false)); w != barWidth {
+		t.Errorf("width without over-amplification %d", w)
+	}
+}
+
+func TestMicRows(t *testing.T) {
+	stereo := pulse.ChannelMap{pulse.ChannelFrontLeft, pulse.ChannelFrontRight}
+	even := pulse.ChannelVolumes{pulse.VolumeFromPercent(50), pulse.VolumeFromPercent(50)}
+	split := pulse.ChannelVolumes{pulse.VolumeFromPercent(50), pulse.VolumeFromPercent(20)}
+	m := NewModel(config.AudioConfig{})
+	m.audio = &AudioWidget{MaxOutVolume: 100, MaxInVolume: 100, OutVolume: 50, InVolume: 50,
+		outMap: stereo, inMap: stereo, outChannels: even, inChannels: even}
+	m.sub = &Subscriber{stop: make(chan struct{})}
+
+	for _, c := range []struct {
+		name     string
+		out, in  pulse.ChannelVolumes
+		mic      []int
+		micLines []string
+	}{
+		{"centered", even, even, []int{2}, []string{"Mic: "}},
+		{"speaker split", split, even, []int{3}, []string{"Mic: "}},
+		{"mic split", even, split, []int{2, 3}, []string{"MicL ", "MicR "}},
+		{"both split", split, split, []int{3, 4}, []string{"MicL ", "MicR "}},
+	} {
+		m.audio.outChannels, m.audio.inChannels = c.out, c.in
+		lines := strings.Split(ansi.Strip(m.View()), "\n")
+		for y := range 6 {
+			want := y >= c.mic[0] && y <= c.mic[len(c.mic)-1]
+			if m.onMic(y) != want {
+				t.Errorf("%s: line %d on mic = %v", c.name, y, !want)
+			}
+		}
+		for i, prefix := range c.micLines {
+			if l := lines[c.mic[i]]; !strings.HasPrefix(l, prefix) {
+				t.Errorf("%s: line %d = %q, want %q…", c.name, c.mic[i], l, prefix)
+			}
+		}
+	}
+	if l, r, ok := m.audio.InSides(); !ok || l != 50 || r != 20 {
+		t.Errorf("mic sides = %d, %d, %v", l, r, ok)
+	}
+}
@@ -0,0 +1,255 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gen2brain/malgo"
+)
+
+// TestKind is what the loopback tester is doing.
+type TestKind int
+
+const (
+	TestIdle TestKind = iota
+	TestRecording
+	TestPlaying
+	TestSine
+	TestPink
+)
+
+func (k TestKind) String() string {
+	return [...]string{"idle", "recording", "playing", "sine", "pink noise"}[k]
+}
+
+// Signal generates test signal samples in -1…1.
+type Signal interface {
+	Next() float64
+}
+
+// Sine is a sine wave at Freq Hz.
+type Sine struct {
+	Freq  float64
+	phase float64
+}
+
+func (s *Sine) Next() float64 {
+	v := math.Sin(2 * math.Pi * s.phase)
+	s.phase += s.Freq / toneRate
+	if s.phase >= 1 {
+		s.phase--
+	}
+	return v
+}
+
+// Pink is pink (1/f) noise using Paul Kellet's filter on white noise.
+type Pink struct {
+	b [7]float64
+}
+
+func (p *Pink) Next() float64 {
+	white := rand.Float64()*2 - 1
+	b := &p.b
+	b[0] = 0.99886*b[0] + white*0.0555179
+	b[1] = 0.99332*b[1] + white*0.0750759
+	b[2] = 0.96900*b[2] + white*0.1538520
+	b[3] = 0.86650*b[3] + white*0.3104856
+	b[4] = 0.55000*b[4] + white*0.5329522
+	b[5] = -0.7616*b[5] - white*0.0168980
+	v := b[0] + b[1] + b[2] + b[3] + b[4] + b[5] + b[6] + white*0.5362
+	b[6] = white * 0.115926
+	return v * 0.11 // roughly unity peak
+}
+
+// tester runs one mic test or test signal at a time on its own device, so
+// it never disturbs the level monitors.
+type tester struct {
+	mu      sync.Mutex
+	kind    TestKind
+	dev     *malgo.Device
+	samples []int16 // recording buffer, or what is being played
+	pos     int     // samples recorded or played so far
+	done    bool    // recording full or playback finished
+	gain    float64
+	level   atomic.Uint64 // float64 bits of RMS of the last buffer
+}
+
+// Test returns what the tester is doing, its progress (0–1 for recording
+// and playback) and whether that has finished and is waiting for the
+// caller to collect it.
+func (w *AudioWidget) Test() (kind TestKind, progress float64, done bool) {
+	t := &w.test
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.samples) > 0 {
+		progress = float64(t.pos) / float64(len(t.samples))
+	}
+	return t.kind, progress, t.done
+}
+
+// TestLevel is the RMS level, in dBFS, of the test audio.
+func (w *AudioWidget) TestLevel() float64 {
+	return rmsToDb(math.Float64frombits(w.test.level.Load()))
+}
+
+// StartRecording records d of mono audio from the default source.
+func (w *AudioWidget) StartRecording(d time.Duration) error {
+	w.StopTest()
+	t := &w.test
+	t.mu.Lock()
+	t.kind, t.samples, t.pos, t.done = TestRecording, make([]int16, int(d.Seconds()*toneRate)), 0, false
+	t.mu.Unlock()
+
+	cfg := malgo.DefaultDeviceConfig(malgo.Capture)
+	cfg.Capture.Format = malgo.FormatS16
+	cfg.Capture.Channels = 1
+	cfg.SampleRate = toneRate
+	cfg.Alsa.NoMMap = 1
+	return w.startTest(cfg, malgo.DeviceCallbacks{
+		Data: func(_, input []byte, _ uint32) {
+			t.level.Store(math.Float64bits(calcRMS(input)))
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			for i := 0; i+1 < len(input) && t.pos < len(t.samples); i += 2 {
+				t.samples[t.pos] = int16(binary.LittleEndian.Uint16(input[i:]))
+				t.pos++
+			}
+			t.done = t.pos >= len(t.samples)
+		},
+	})
+}
+
+// Recording stops a finished recording and returns it.
+func (w *AudioWidget) Recording() (WAV, error) {
+	t := &w.test
+	t.mu.Lock()
+	if t.kind != TestRecording {
+		t.mu.Unlock()
+		return WAV{}, errors.New("not recording")
+	}
+	rec := WAV{Rate: toneRate, Channels: 1, Samples: t.samples[:t.pos]}
+	t.mu.Unlock()
+	w.StopTest()
+	return rec, nil
+}
+
+// Play plays mono 16 bit audio through the default sink.
+func (w *AudioWidget) Play(wav WAV) error {
+	if wav.Channels != 1 {
+		return errors.New("only mono recordings can be played back")
+	}
+	w.StopTest()
+	t := &w.test
+	t.mu.Lock()
+	t.kind, t.samples, t.pos, t.done = TestPlaying, wav.Samples, 0, false
+	t.mu.Unlock()
+
+	cfg := malgo.DefaultDeviceConfig(malgo.Playback)
+	cfg.Playback.Format = malgo.FormatS16
+	cfg.Playback.Channels = 1
+	cfg.SampleRate = uint32(wav.Rate)
+	cfg.Alsa.NoMMap = 1
+	return w.startTest(cfg, malgo.DeviceCallbacks{
+		Data: func(output, _ []byte, _ uint32) {
+			t.mu.Lock()
+			for i := 0; i+1 < len(output); i += 2 {
+				var v int16
+				if t.pos < len(t.samples) {
+					v = t.samples[t.pos]
+					t.pos++
+				}
+				binary.LittleEndian.PutUint16(output[i:], uint16(v))
+			}
+			t.done = t.pos >= len(t.samples)
+			t.mu.Unlock()
+			t.level.Store(math.Float64bits(calcRMS(output)))
+		},
+	})
+}
+
+// PlaySignal plays a continuous test signal at level dBFS until StopTest.
+func (w *AudioWidget) PlaySignal(kind TestKind, level float64) error {
+	var sig Signal
+	switch kind {
+	case TestSine:
+		sig = &Sine{Freq: 440}
+	case TestPink:
+		sig = &Pink{}
+	default:
+		return errors.New("not a test signal")
+	}
+	w.StopTest()
+	t := &w.test
+	t.mu.Lock()
+	t.kind, t.samples, t.done = kind, nil, false
+	t.mu.Unlock()
+	w.SetSignalLevel(level)
+
+	cfg := malgo.DefaultDeviceConfig(malgo.Playback)
+	cfg.Playback.Format = malgo.FormatS16
+	cfg.Playback.Channels = 1
+	cfg.SampleRate = toneRate
+	cfg.Alsa.NoMMap = 1
+	return w.startTest(cfg, malgo.DeviceCallbacks{
+		Data: func(output, _ []byte, _ uint32) {
+			t.mu.Lock()
+			for i := 0; i+1 < len(output); i += 2 {
+				v := max(-1, min(1, sig.Next()*t.gain))
+				binary.LittleEndian.PutUint16(output[i:], uint16(int16(v*32767)))
+			}
+			t.mu.Unlock()
+			t.level.Store(math.Float64bits(calcRMS(output)))
+		},
+	})
+}
+
+// SetSignalLevel changes the test signal level (dBFS peak) while it plays.
+func (w *AudioWidget) SetSignalLevel(level float64) {
+	w.test.mu.Lock()
+	w.test.gain = math.Pow(10, level/20)
+	w.test.mu.Unlock()
+}
+
+func (w *AudioWidget) startTest(cfg malgo.DeviceConfig, cb malgo.DeviceCallbacks) error {
+	dev, err := malgo.InitDevice(w.ctx.Context, cfg, cb)
+	if err != nil {
+		w.resetTest()
+		return err
+	}
+	if err := dev.Start(); err != nil {
+		dev.Uninit()
+		w.resetTest()
+		return err
+	}
+	w.test.mu.Lock()
+	w.test.dev = dev
+	w.test.mu.Unlock()
+	return nil
+}
+
+// StopTest stops whatever the tester is doing.
+func (w *AudioWidget) StopTest() {
+	w.test.mu.Lock()
+	dev := w.test.dev
+	w.test.dev = nil
+	w.test.mu.Unlock()
+	// outside the lock: Stop waits for the callback, which takes it
+	if dev != nil {
+		dev.Stop()
+		dev.Uninit()
+	}
+	w.resetTest()
+}
+
+func (w *AudioWidget) resetTest() {
+	t := &w.test
+	t.mu.Lock()
+	t.kind, t.samples, t.pos, t.done = TestIdle, nil, 0, false
+	t.mu.Unlock()
+	t.level.Store(0)
+}
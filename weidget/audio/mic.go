@@ -0,0 +1,134 @@
+package audio
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// levelInterval is how often the mic level is sampled for voice
+	// activity; the shared one second tick is too slow for that.
+	levelInterval = 100 * time.Millisecond
+	// vadHangover keeps the indicator lit across short pauses in speech.
+	vadHangover = 300 * time.Millisecond
+	// pttHold is how long after the last key repeat push-to-talk stays
+	// open. Terminals report no key release, so a held key is a stream of
+	// repeats; it has to outlast the initial repeat delay.
+	pttHold = 600 * time.Millisecond
+	// mutedTalkWarn is how much speech into a muted mic triggers a warning.
+	mutedTalkWarn = time.Second
+)
+
+// levelTickMsg drives the voice activity sampling of one connection.
+type levelTickMsg struct {
+	conn int
+	at   time.Time
+}
+
+func levelTick(conn int) tea.Cmd {
+	return tea.Tick(levelInterval, func(t time.Time) tea.Msg {
+		return levelTickMsg{conn: conn, at: t}
+	})
+}
+
+// micState tracks voice activity and push-to-talk for the mic.
+type micState struct {
+	threshold float64       // dBFS above which the mic counts as live
+	silence   time.Duration // unmuted this long without voice warns
+
+	live      bool
+	lastVoice time.Time
+	quietFrom time.Time     // start of the current unmuted silence
+	mutedTalk time.Duration // speech picked up while muted
+
+	ptt      bool      // push-to-talk mode
+	pttUntil time.Time // open until then, zero when closed
+	pttMuted bool      // mute state before push-to-talk, restored on exit
+}
+
+// sample feeds one level reading. It reports whether the mic mute state
+// should change because push-to-talk was released.
+func (s *micState) sample(now time.Time, db float64, muted bool) (release bool) {
+	voice := db >= s.threshold
+	if voice {
+		s.lastVoice = now
+	}
+	s.live = !muted && now.Sub(s.lastVoice) <= vadHangover
+
+	switch {
+	case muted:
+		s.quietFrom = time.Time{}
+		if voice {
+			s.mutedTalk += levelInterval
+		} else if now.Sub(s.lastVoice) > 2*time.Second {
+			s.mutedTalk = 0
+		}
+	case voice:
+		s.quietFrom = time.Time{}
+		s.mutedTalk = 0
+	case s.quietFrom.IsZero():
+		s.quietFrom = now
+	}
+
+	if s.ptt && !s.pttUntil.IsZero() && now.After(s.pttUntil) {
+		s.pttUntil = time.Time{}
+		return true
+	}
+	return false
+}
+
+// warning describes a likely mistake: talking into a muted mic, or an
+// open mic that has heard nothing for a long time. Talking while muted is
+// only visible when the capture monitor still gets signal, which depends
+// on the backend miniaudio picked (servers that mute the source zero it).
+func (s micState) warning(now time.Time, muted bool) string {
+	if muted && s.mutedTalk >= mutedTalkWarn {
+		return "you are talking while muted"
+	}
+	if !muted && !s.ptt && !s.quietFrom.IsZero() {
+		if quiet := now.Sub(s.quietFrom); s.silence > 0 && quiet >= s.silence {
+			return fmt.Sprintf("mic open but silent for %s", quiet.Truncate(time.Minute))
+		}
+	}
+	return ""
+}
+
+// handleLevel samples the mic, closes push-to-talk once the key is no
+// longer held and collects a finished mic test.
+func (m Model) handleLevel(msg levelTickMsg) (Model, tea.Cmd) {
+	if m.audio == nil || msg.conn != m.conn {
+		return m, nil // connection gone, let the loop end
+	}
+	_, db := m.audio.InLevel()
+	if m.mic.sample(msg.at, db, m.audio.InMuted) {
+		m.err = m.audio.MuteIn()
+	}
+	m, cmd := m.pollTest()
+	return m, tea.Batch(levelTick(m.conn), cmd)
+}
+
+// togglePTT enters or leaves push-to-talk. Entering mutes the mic;
+// leaving restores the mute state from before.
+func (m Model) togglePTT() Model {
+	if m.mic.ptt {
+		m.mic.ptt = false
+		m.mic.pttUntil = time.Time{}
+		m.err = m.audio.setInMute(m.mic.pttMuted)
+		return m
+	}
+	m.mic.ptt = true
+	m.mic.pttMuted = m.audio.InMuted
+	m.err = m.audio.MuteIn()
+	return m
+}
+
+// talk opens the mic (or keeps it open) for one push-to-talk key press.
+func (m Model) talk() Model {
+	if m.mic.pttUntil.IsZero() {
+		m.err = m.audio.UnmuteIn()
+	}
+	m.mic.pttUntil = time.Now().Add(pttHold)
+	return m
+}
@@ -0,0 +1,169 @@
+package audio
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/antiloger/termctlr/config"
+	"github.com/antiloger/termctlr/theme"
+	"github.com/antiloger/termctlr/weidget/components"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// recordFor is how long the mic test records.
+	recordFor = 3 * time.Second
+	// Test signal level in dBFS: default, step and range.
+	signalLevel    = -20
+	signalStep     = 3
+	signalLevelMin = -60
+	// meterFloor is the bottom of the test level meter in dBFS.
+	meterFloor = -60
+)
+
+// testState is the mic test panel.
+type testState struct {
+	open  bool
+	level float64 // test signal level, dBFS
+	path  string  // where the last recording was saved
+	saved *WAV    // last recording, for replay
+}
+
+// savedMsg reports the mic test recording written to disk and read back.
+type savedMsg struct {
+	wav  WAV
+	path string
+	err  error
+}
+
+// saveRecording writes the recording and reads it back, so what plays is
+// what the file holds.
+func saveRecording(rec WAV) tea.Cmd {
+	return func() tea.Msg {
+		path := filepath.Join(config.StateDir(), "mic-test.wav")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return savedMsg{err: err}
+		}
+		if err := WriteWAV(path, rec); err != nil {
+			return savedMsg{err: err}
+		}
+		wav, err := ReadWAV(path)
+		return savedMsg{wav: wav, path: path, err: err}
+	}
+}
+
+// pollTest collects a finished recording or playback; the audio thread
+// only flags it, devices are stopped from here.
+func (m Model) pollTest() (Model, tea.Cmd) {
+	kind, _, done := m.audio.Test()
+	if !done {
+		return m, nil
+	}
+	switch kind {
+	case TestRecording:
+		rec, err := m.audio.Recording()
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		return m, saveRecording(rec)
+	case TestPlaying:
+		m.audio.StopTest()
+	}
+	return m, nil
+}
+
+func (m Model) handleSaved(msg savedMsg) Model {
+	if msg.err != nil {
+		m.err = fmt.Errorf("mic test: %w", msg.err)
+		return m
+	}
+	m.test.path, m.test.saved = msg.path, &msg.wav
+	if m.audio != nil {
+		m.err = m.audio.Play(msg.wav)
+	}
+	return m
+}
+
+// updateTest handles keys while the mic test panel is open.
+func (m Model) updateTest(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "r":
+		m.err = m.audio.StartRecording(recordFor)
+	case "enter":
+		if m.test.saved != nil {
+			m.err = m.audio.Play(*m.test.saved)
+		}
+	case "s":
+		m.err = m.audio.PlaySignal(TestSine, m.test.level)
+	case "n":
+		m.err = m.audio.PlaySignal(TestPink, m.test.level)
+	case "+", "=":
+		m.test.level = min(m.test.level+signalStep, 0)
+		m.audio.SetSignalLevel(m.test.level)
+	case "-":
+		m.test.level = max(m.test.level-signalStep, signalLevelMin)
+		m.audio.SetSignalLevel(m.test.level)
+	case "x":
+		m.audio.StopTest()
+	case "t", "esc":
+		m.audio.StopTest()
+		m.test.open = false
+	}
+	return m, nil
+}
+
+// Overlay shows the mic test panel while it is open.
+func (m Model) Overlay() (string, bool) {
+	if !m.test.open || m.audio == nil {
+		return "", false
+	}
+	t := theme.Current()
+	kind, progress, _ := m.audio.Test()
+
+	status := kind.String()
+	switch kind {
+	case TestRecording, TestPlaying:
+		status += fmt.Sprintf(" %.1fs / %.1fs", progress*recordFor.Seconds(), recordFor.Seconds())
+	case TestSine:
+		status = fmt.Sprintf("sine 440 Hz at %d dBFS", int(m.test.level))
+	case TestPink:
+		status = fmt.Sprintf("pink noise at %d dBFS", int(m.test.level))
+	}
+
+	db := m.audio.TestLevel()
+	meter := components.NewGauge(max(db-meterFloor, 0), -meterFloor)
+	if db > -3 {
+		meter.Role = theme.Alert
+	}
+	level := "   —"
+	if kind != TestIdle {
+		level = fmt.Sprintf("%4.0f dB", max(db, meterFloor))
+	}
+
+	saved := "no recording yet"
+	if m.test.saved != nil {
+		saved = fmt.Sprintf("%s (%.1fs)", m.test.path, m.test.saved.Duration())
+	}
+
+	body := lipgloss.JoinVertical(lipgloss.Left,
+		t.Style(theme.Accent).Bold(true).Render("mic test"),
+		"",
+		status,
+		lipgloss.JoinHorizontal(lipgloss.Center, "Level: ", meter.Render(barWidth), "  ", level),
+		t.Style(theme.Muted).Render(saved),
+		"",
+		t.Style(theme.Muted).Render(strings.Join([]string{
+			"r record", "enter replay", "s sine", "n pink", "+ - level", "x stop", "t/esc close",
+		}, " · ")),
+	)
+	return lipgloss.NewStyle().
+		Border(t.Border, true).
+		BorderForeground(t.Color(theme.Focus)).
+		Padding(0, 2).
+		Render(body), true
+}
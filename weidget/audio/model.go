@@ -1,11 +1,15 @@
 package audio
 
 import (
-	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/antiloger/termctlr/config"
+	"github.com/antiloger/termctlr/message"
+	"github.com/antiloger/termctlr/theme"
 	"github.com/antiloger/termctlr/types"
+	"github.com/antiloger/termctlr/weidget/audio/pulse"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/gen2brain/malgo"
@@ -27,78 +31,246 @@ type AudioWidget struct {
 	Hop          int  // step size for Inc/Dec (e.g. 5 = 5%)
 
 	// internal — not exported
-	ctx       *malgo.AllocatedContext
-	inDevice  *malgo.Device
-	outDevice *malgo.Device
-	inLevel   atomic.Uint64 // float64 bits of RMS
-	outLevel  atomic.Uint64 // float64 bits of RMS
-	mu        sync.Mutex
+	ctx         *malgo.AllocatedContext
+	inDevice    *malgo.Device
+	outDevice   *malgo.Device
+	toneDevice  *malgo.Device // alarm tone, nil when silent
+	test        tester        // mic test and test signals
+	monitorErr  error         // level meters unavailable
+	client      *pulse.Client
+	outChannels pulse.ChannelVolumes // per channel sink volume
+	inChannels  pulse.ChannelVolumes // per channel source volume
+	outMap      pulse.ChannelMap
+	inMap       pulse.ChannelMap
+	outIndex    int           // default sink index, -1 unknown
+	inIndex     int           // default source index, -1 unknown
+	inLevel     atomic.Uint64 // float64 bits of RMS
+	outLevel    atomic.Uint64 // float64 bits of RMS
+	mu          sync.Mutex
 }
 
+// Model is the audio widget. It starts disconnected and connects in the
+// background, so a machine without a sound server (ssh, containers) just
+// shows why and keeps retrying.
 type Model struct {
 	outVolume int
-	audio     *AudioWidget
+	audio     *AudioWidget // nil while disconnected
 	pos       types.Position
 	err       error
-	pactlCh   chan struct{} // held for lifetime of model
+	sub       *Subscriber // held for lifetime of the connection
+
+	cfg        config.AudioConfig
+	now        time.Time
+	connecting bool
+	reason     error         // why the widget is disconnected
+	backoff    time.Duration // current retry delay
+	retryAt    time.Time
+	gen        int // retry generation
+	conn       int // connection generation, for the level sampling loop
+
+	mic    micState
+	pttKey string
+	test   testState
 }
 
-func NewModel() (Model, error) {
-	w, err := New(5, 100, 100)
-	if err := w.startInputMonitor(); err != nil {
-		return Model{}, err
-	}
-	if err := w.startOutputMonitor(); err != nil {
-		return Model{}, err
-	}
+func NewModel(cfg config.AudioConfig) Model {
+	silence, err := time.ParseDuration(cfg.SilenceWarn)
 	if err != nil {
-		return Model{}, err
+		silence = 0 // warning disabled
+	}
+	pttKey := cfg.PTTKey
+	if pttKey == "" || pttKey == "space" {
+		pttKey = " " // how bubbletea reports the space bar
 	}
 	return Model{
-		audio:   w,
-		pactlCh: NewPactlSubscriber(),
-	}, nil
+		cfg:        cfg,
+		now:        time.Now(),
+		connecting: true,
+		mic:        micState{threshold: cfg.VADThreshold, silence: silence},
+		pttKey:     pttKey,
+		test:       testState{level: signalLevel},
+	}
 }
 
 func (m Model) Init() tea.Cmd {
-	return WaitForVolumeChange(m.pactlCh)
+	return connect(m.cfg)
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case types.TickMsg:
+		m.now = time.Time(msg)
+		return m, nil
+	case connectedMsg, retryMsg, SubscriberClosedMsg, refreshedMsg:
+		return m.handleConn(msg)
+	case levelTickMsg:
+		return m.handleLevel(msg)
+	case savedMsg:
+		return m.handleSaved(msg), nil
+	}
+	if m.audio == nil {
+		return m.updateDisconnected(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.test.open {
+			return m.updateTest(msg)
+		}
+		if m.mic.ptt && msg.String() == m.pttKey {
+			return m.talk(), nil
+		}
 		switch msg.String() {
+		case "p":
+			return m.togglePTT(), nil
+		case "t":
+			m.test.open = true
 		case "=":
 			m.err = m.audio.IncOut() // mutates through pointer — safe
 		case "-":
 			m.err = m.audio.DecOut()
 		case "m":
 			m.err = m.audio.ToggleMuteOut()
+		case "[":
+			m.err = m.audio.ShiftOutBalance(-balanceStep)
+		case "]":
+			m.err = m.audio.ShiftOutBalance(balanceStep)
+		case "b":
+			m.err = m.audio.SetOutBalance(0)
+		case "{":
+			m.err = m.audio.ShiftInBalance(-balanceStep)
+		case "}":
+			m.err = m.audio.ShiftInBalance(balanceStep)
+		case "B":
+			m.err = m.audio.SetInBalance(0)
 		case "q":
+			m.sub.Stop()
 			m.audio.Close()
 			return m, tea.Quit
 		}
-	case VolumeChangedMsg:
-		_ = m.audio.Sync()
-		return m, WaitForVolumeChange(m.pactlCh) // re-issue to keep waiting for next event
+	case types.MouseMsg:
+		// wheel over the mic row adjusts the mic, anywhere else the speaker
+		switch msg.Button {
+		case tea.MouseButtonWheelUp:
+			if m.onMic(msg.Y) {
+				m.err = m.audio.IncIn()
+			} else {
+				m.err = m.audio.IncOut()
+			}
+		case tea.MouseButtonWheelDown:
+			if m.onMic(msg.Y) {
+				m.err = m.audio.DecIn()
+			} else {
+				m.err = m.audio.DecOut()
+			}
+		}
+	case message.AlarmMsg:
+		if msg.Tone {
+			m.err = m.audio.PlayTone(880, time.Minute)
+		}
+	case message.AlarmStopMsg:
+		m.audio.StopTone()
+	case message.AudioVolumeMsg:
+		m.err = m.applyVolume(msg)
+	case message.AudioMuteMsg:
+		m.err = m.applyMute(msg)
+	case EventsMsg:
+		// re-issue to keep waiting for the next batch
+		return m, tea.Batch(m.audio.refreshFor(msg.Events), WaitForEvents(m.sub))
 	}
 	return m, nil
 }
 
+// updateDisconnected handles input while there are no devices: quit and
+// an immediate retry.
+func (m Model) updateDisconnected(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "q":
+			return m, tea.Quit
+		case "r":
+			return m.retryNow()
+		}
+	}
+	return m, nil
+}
+
+// applyVolume handles volume requests coming from outside the widget
+// (control socket, other widgets).
+func (m Model) applyVolume(msg message.AudioVolumeMsg) error {
+	switch {
+	case msg.Device == message.AudioIn && msg.Relative:
+		return m.audio.AddIn(msg.Value)
+	case msg.Device == message.AudioIn:
+		return m.audio.SetIn(msg.Value)
+	case msg.Relative:
+		return m.audio.AddOut(msg.Value)
+	}
+	return m.audio.SetOut(msg.Value)
+}
+
+func (m Model) applyMute(msg message.AudioMuteMsg) error {
+	in := msg.Device == message.AudioIn
+	switch msg.Action {
+	case message.MuteOn:
+		if in {
+			return m.audio.MuteIn()
+		}
+		return m.audio.MuteOut()
+	case message.MuteOff:
+		if in {
+			return m.audio.UnmuteIn()
+		}
+		return m.audio.UnmuteOut()
+	}
+	if in {
+		return m.audio.ToggleMuteIn()
+	}
+	return m.audio.ToggleMuteOut()
+}
+
+// onMic reports whether line y of View is a mic bar: they follow one
+// speaker bar, or two when its sides differ, and a blank line.
+func (m Model) onMic(y int) bool {
+	first, n := 2, 1
+	if left, right, stereo := m.audio.OutSides(); stereo && left != right {
+		first = 3
+	}
+	if left, right, stereo := m.audio.InSides(); stereo && left != right {
+		n = 2
+	}
+	return y >= first && y < first+n
+}
+
 func (m Model) View() string {
 	// rms, db := m.audio.OutLevel() // atomic.Load inside — safe
 	// return fmt.Sprintf("Vol: %d%%  Muted: %v  RMS: %.3f  dB: %.1f | scr x:%d y:%d ",
 	// 	m.audio.OutVolume, m.audio.OutMuted, rms, db, m.pos.X, m.pos.Y)
-	outVol := fmt.Sprintf("%d%%", m.audio.OutVolume)
-	inVol := fmt.Sprintf("%d%%", m.audio.InVolume)
-	return lipgloss.JoinVertical(lipgloss.Left,
-		lipgloss.JoinHorizontal(lipgloss.Center, "Vol: ", m.UIVolumeOut(), "  ", outVol),
-		" ",
-		lipgloss.JoinHorizontal(lipgloss.Center, "Mic: ", m.UIVolumeIn(), "  ", inVol),
-	)
+	if m.audio == nil {
+		return m.disconnectedView()
+	}
+	lines := append(m.outView(), " ")
+	lines = append(lines, m.inView()...)
+	t := theme.Current()
+	if warn := m.mic.warning(m.now, m.audio.InMuted); warn != "" {
+		lines = append(lines, t.Style(theme.Alert).Bold(true).Render("⚠ "+warn))
+	}
+	lines = append(lines, m.healthView())
+	if err := m.audio.MonitorErr(); err != nil {
+		lines = append(lines, t.Style(theme.Muted).Render("levels unavailable: "+err.Error()))
+	}
+	if m.err != nil {
+		lines = append(lines, t.Style(theme.Alert).Render(m.err.Error()))
+	}
+	lines = append(lines, t.Style(theme.Muted).Render("= - volume · [ ] { } balance · b B center · m mute · p push-to-talk · t mic test"))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
 }
 
 func (m Model) SetPosition(x, y int) {
 	m.pos.X = x
 	m.pos.Y = y
 }
+
+func (m Model) Name() string {
+	return "audio"
+}
@@ -0,0 +1,323 @@
+// Package pulse is a small client for the PulseAudio native protocol, as
+// served by PulseAudio and pipewire-pulse on $XDG_RUNTIME_DIR/pulse/native.
+// It covers what the audio widget needs: server, sink and source info,
+// volume and mute control and change subscriptions.
+package pulse
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// protocolVersion is the native protocol version we speak. Servers answer
+// with their own; the lower one is used.
+const protocolVersion = 32
+
+// Commands, from pulsecore/native-common.h.
+const (
+	cmdError           = 0
+	cmdTimeout         = 1
+	cmdReply           = 2
+	cmdAuth            = 8
+	cmdSetClientName   = 9
+	cmdGetServerInfo   = 20
+	cmdGetSinkInfo     = 21
+	cmdGetSourceInfo   = 23
+	cmdSubscribe       = 35
+	cmdSetSinkVolume   = 36
+	cmdSetSourceVolume = 38
+	cmdSetSinkMute     = 39
+	cmdSetSourceMute   = 40
+	cmdSubscribeEvent  = 66
+)
+
+const (
+	headerSize     = 20
+	controlChannel = 0xFFFFFFFF
+	requestTimeout = 5 * time.Second
+	eventBuffer    = 64
+)
+
+// ErrClosed is returned for requests on a closed connection.
+var ErrClosed = errors.New("pulse: connection closed")
+
+// ServerError is an error code sent by the server (PA_ERR_*).
+type ServerError uint32
+
+var serverErrors = map[ServerError]string{
+	1: "access denied", 2: "unknown command", 3: "invalid argument", 4: "entity exists",
+	5: "no such entity", 6: "connection refused", 7: "protocol error", 8: "timeout",
+	9: "no authentication key", 10: "internal error", 11: "connection terminated",
+	12: "entity killed", 13: "invalid server", 14: "module initialization failed",
+	15: "bad state", 16: "no data", 17: "incompatible protocol version", 18: "too large",
+	19: "not supported", 20: "unknown error code", 21: "no such extension",
+	22: "obsolete functionality", 23: "missing implementation", 24: "client forked",
+	25: "input/output error", 26: "device or resource busy",
+}
+
+func (e ServerError) Error() string {
+	if s, ok := serverErrors[e]; ok {
+		return "pulse: " + s
+	}
+	return fmt.Sprintf("pulse: error %d", uint32(e))
+}
+
+// Client is a connection to the sound server. It is safe for concurrent
+// use.
+type Client struct {
+	conn    net.Conn
+	version uint32
+
+	wmu sync.Mutex // serialises writes
+
+	mu      sync.Mutex
+	seq     uint32
+	pending map[uint32]chan *decoder
+	events  chan Event
+	err     error // why the connection ended
+	done    chan struct{}
+}
+
+// SocketPath returns the native socket: $PULSE_SERVER when it names a unix
+// socket, otherwise pulse/native under $XDG_RUNTIME_DIR.
+func SocketPath() string {
+	if s := os.Getenv("PULSE_SERVER"); s != "" {
+		s = strings.TrimPrefix(s, "unix:")
+		if strings.HasPrefix(s, "/") {
+			return s
+		}
+	}
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = fmt.Sprintf("/run/user/%d", os.Getuid())
+	}
+	return filepath.Join(dir, "pulse", "native")
+}
+
+// Dial connects, authenticates and names the client.
+func Dial(path string) (*Client, error) {
+	conn, err := net.DialTimeout("unix", path, requestTimeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		conn:    conn,
+		version: protocolVersion,
+		pending: map[uint32]chan *decoder{},
+		events:  make(chan Event, eventBuffer),
+		done:    make(chan struct{}),
+	}
+	go c.readLoop()
+
+	reply, err := c.request(cmdAuth, new(encoder).u32(protocolVersion).arbitrary(cookie()))
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("pulse auth: %w", err)
+	}
+	// the high bits are shm/memfd flags
+	if v := reply.u32() & 0xFFFF; v < c.version {
+		c.version = v
+	}
+	if c.version < 13 {
+		c.Close()
+		return nil, fmt.Errorf("pulse: server protocol %d is too old", c.version)
+	}
+
+	props := map[string]string{"application.name": "termctrl", "application.process.id": fmt.Sprint(os.Getpid())}
+	if _, err := c.request(cmdSetClientName, new(encoder).proplist(props)); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("pulse client name: %w", err)
+	}
+	return c, nil
+}
+
+// cookie reads the auth cookie. PipeWire and same-user PulseAudio accept
+// a zero cookie, so a missing file is not an error.
+func cookie() []byte {
+	paths := []string{os.Getenv("PULSE_COOKIE")}
+	if dir, err := os.UserConfigDir(); err == nil {
+		paths = append(paths, filepath.Join(dir, "pulse", "cookie"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".pulse-cookie"))
+	}
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		if b, err := os.ReadFile(p); err == nil && len(b) == 256 {
+			return b
+		}
+	}
+	return make([]byte, 256)
+}
+
+// Close ends the connection. Pending requests fail with ErrClosed.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Done is closed when the connection ends; Err says why.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the connection ended, nil while it is up.
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Version is the negotiated protocol version.
+func (c *Client) Version() uint32 {
+	return c.version
+}
+
+// request sends a command and waits for its reply.
+func (c *Client) request(cmd uint32, args *encoder) (*decoder, error) {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	c.seq++
+	tag := c.seq
+	ch := make(chan *decoder, 1)
+	c.pending[tag] = ch
+	c.mu.Unlock()
+
+	var body encoder
+	body.u32(cmd).u32(tag)
+	if args != nil {
+		body.buf.Write(args.buf.Bytes())
+	}
+	if err := c.write(body.buf.Bytes()); err != nil {
+		c.mu.Lock()
+		delete(c.pending, tag)
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	select {
+	case d, ok := <-ch:
+		if !ok {
+			return nil, c.Err()
+		}
+		return d, d.err
+	case <-time.After(requestTimeout):
+		c.mu.Lock()
+		delete(c.pending, tag)
+		c.mu.Unlock()
+		return nil, fmt.Errorf("pulse: command %d timed out", cmd)
+	}
+}
+
+func (c *Client) write(payload []byte) error {
+	var hdr [headerSize]byte
+	binary.BigEndian.PutUint32(hdr[0:], uint32(len(payload)))
+	binary.BigEndian.PutUint32(hdr[4:], controlChannel)
+	// offset (8 bytes) and flags stay zero for control packets
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(requestTimeout))
+	_, err := c.conn.Write(append(hdr[:], payload...))
+	return err
+}
+
+// readLoop dispatches replies to their requests and collects events until
+// the connection fails.
+func (c *Client) readLoop() {
+	err := c.read()
+	if errors.Is(err, net.ErrClosed) || errors.Is(err, io.EOF) {
+		err = ErrClosed
+	}
+
+	c.mu.Lock()
+	c.err = err
+	for tag, ch := range c.pending {
+		close(ch)
+		delete(c.pending, tag)
+	}
+	c.mu.Unlock()
+	close(c.events)
+	close(c.done)
+	c.conn.Close()
+}
+
+func (c *Client) read() error {
+	var hdr [headerSize]byte
+	for {
+		if _, err := io.ReadFull(c.conn, hdr[:]); err != nil {
+			return err
+		}
+		size := binary.BigEndian.Uint32(hdr[0:])
+		channel := binary.BigEndian.Uint32(hdr[4:])
+		if size > maxTagstruct {
+			return fmt.Errorf("pulse: %d byte packet", size)
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(c.conn, payload); err != nil {
+			return err
+		}
+		if channel != controlChannel {
+			continue // audio data, we open no streams
+		}
+
+		d := &decoder{b: payload}
+		cmd, tag := d.u32(), d.u32()
+		if d.err != nil {
+			return d.err
+		}
+		switch cmd {
+		case cmdReply, cmdError, cmdTimeout:
+			if cmd == cmdError {
+				d.err = ServerError(d.u32())
+			} else if cmd == cmdTimeout {
+				d.err = ServerError(8)
+			}
+			c.mu.Lock()
+			ch := c.pending[tag]
+			delete(c.pending, tag)
+			c.mu.Unlock()
+			if ch != nil {
+				ch <- d
+			}
+		case cmdSubscribeEvent:
+			ev := Event{Type: d.u32(), Index: d.u32()}
+			if d.err != nil {
+				return d.err
+			}
+			c.emit(ev)
+		}
+	}
+}
+
+// emit queues an event without ever blocking the read loop. When the
+// reader falls behind the queue is replaced by a single server change,
+// which tells it to re-read everything.
+func (c *Client) emit(ev Event) {
+	select {
+	case c.events <- ev:
+		return
+	default:
+	}
+	for {
+		select {
+		case <-c.events:
+			continue
+		default:
+		}
+		break
+	}
+	c.events <- Event{Type: uint32(FacilityServer) | uint32(EventChange), Index: invalidIndex}
+}
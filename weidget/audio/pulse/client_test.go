@@ -0,0 +1,352 @@
+package pulse
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer speaks enough of the native protocol for the client: one
+// sink, "speakers", which is also the default.
+type fakeServer struct {
+	t       *testing.T
+	path    string
+	version uint32 // answered to AUTH
+
+	mu       sync.Mutex
+	conn     net.Conn
+	auth     *decoder // the AUTH arguments
+	props    map[string]string
+	volume   ChannelVolumes
+	muted    bool
+	mask     uint32
+	commands []uint32
+}
+
+func newFakeServer(t *testing.T, version uint32) *fakeServer {
+	t.Helper()
+	s := &fakeServer{t: t, path: filepath.Join(t.TempDir(), "native"), version: version, volume: ChannelVolumes{VolumeNorm, VolumeNorm}}
+	l, err := net.Listen("unix", s.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conn = conn
+		s.mu.Unlock()
+		t.Cleanup(func() { conn.Close() })
+		s.serve(conn)
+	}()
+	return s
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	var hdr [headerSize]byte
+	for {
+		if _, err := io.ReadFull(conn, hdr[:]); err != nil {
+			return
+		}
+		if ch := binary.BigEndian.Uint32(hdr[4:]); ch != controlChannel {
+			s.t.Errorf("packet on channel %d", ch)
+		}
+		payload := make([]byte, binary.BigEndian.Uint32(hdr[0:]))
+		if _, err := io.ReadFull(conn, payload); err != nil {
+			return
+		}
+		d := &decoder{b: payload}
+		cmd, tag := d.u32(), d.u32()
+		s.mu.Lock()
+		s.commands = append(s.commands, cmd)
+		s.mu.Unlock()
+		if reply := s.handle(cmd, d); reply != nil {
+			s.send(new(encoder).u32(cmdReply).u32(tag).buf.Bytes(), reply.buf.Bytes())
+		} else {
+			s.send(new(encoder).u32(cmdError).u32(tag).u32(5).buf.Bytes()) // no such entity
+		}
+	}
+}
+
+// handle answers a command, or returns nil for an error reply.
+func (s *fakeServer) handle(cmd uint32, d *decoder) *encoder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch cmd {
+	case cmdAuth:
+		s.auth = d
+		return new(encoder).u32(s.version | 0x80000000) // shm flag
+	case cmdSetClientName:
+		s.props = d.proplist()
+		return new(encoder).u32(42) // client index
+	case cmdGetServerInfo:
+		return new(encoder).str("pulseaudio").str("17.0").str("me").str("box").
+			sampleSpec(SampleSpec{Format: 3, Channels: 2, Rate: 48000}).
+			str("speakers").str("mic").
+			u32(0x1234) // cookie, ignored
+	case cmdGetSinkInfo:
+		if d.u32(); d.str() != "speakers" {
+			return nil
+		}
+		return s.sinkInfo()
+	case cmdSetSinkVolume:
+		if d.u32(); d.str() != DefaultSink {
+			return nil
+		}
+		s.volume = d.cvolume()
+		return new(encoder)
+	case cmdSetSinkMute:
+		if d.u32(); d.str() != DefaultSink {
+			return nil
+		}
+		s.muted = d.bool()
+		return new(encoder)
+	case cmdSubscribe:
+		s.mask = d.u32()
+		return new(encoder)
+	}
+	return nil
+}
+
+func (s *fakeServer) sinkInfo() *encoder {
+	e := new(encoder).u32(3).str("speakers").str("Built-in Audio").
+		sampleSpec(SampleSpec{Format: 3, Channels: 2, Rate: 48000}).
+		channelMap(ChannelMap{ChannelFrontLeft, ChannelFrontRight}).
+		u32(7). // owner module
+		cvolume(s.volume).
+		bool(s.muted).
+		u32(4).str("speakers.monitor").
+		u64(tagUsec, 0).
+		str("module-alsa-card.c").
+		u32(0).
+		proplist(map[string]string{"device.class": "sound"}).
+		u64(tagUsec, 0)
+	if s.version >= 15 {
+		e.volume(VolumeNorm / 2)
+	}
+	return e.u32(0) // what follows is ignored
+}
+
+func (s *fakeServer) send(parts ...[]byte) {
+	var payload []byte
+	for _, p := range parts {
+		payload = append(payload, p...)
+	}
+	var hdr [headerSize]byte
+	binary.BigEndian.PutUint32(hdr[0:], uint32(len(payload)))
+	binary.BigEndian.PutUint32(hdr[4:], controlChannel)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conn.Write(append(hdr[:], payload...))
+}
+
+// event sends a subscription event.
+func (s *fakeServer) event(f Facility, kind EventType, index uint32) {
+	s.send(new(encoder).u32(cmdSubscribeEvent).u32(tagEventPacket).u32(uint32(f) | uint32(kind)).u32(index).buf.Bytes())
+}
+
+func dial(t *testing.T, s *fakeServer) *Client {
+	t.Helper()
+	c, err := Dial(s.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestDialHandshake(t *testing.T) {
+	s := newFakeServer(t, 30)
+	c := dial(t, s)
+	if c.Version() != 30 {
+		t.Errorf("version = %d, want the server's lower 30 without flag bits", c.Version())
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v := s.auth.u32(); v != protocolVersion {
+		t.Errorf("AUTH version = %d", v)
+	}
+	if ck := s.auth.arbitrary(); len(ck) != 256 || s.auth.err != nil {
+		t.Errorf("AUTH cookie is %d bytes (%v), want 256", len(ck), s.auth.err)
+	}
+	if s.props["application.name"] != "termctrl" || s.props["application.process.id"] == "" {
+		t.Errorf("client properties = %v", s.props)
+	}
+	if want := []uint32{cmdAuth, cmdSetClientName}; !reflect.DeepEqual(s.commands, want) {
+		t.Errorf("commands = %v, want %v", s.commands, want)
+	}
+}
+
+func TestDialOldServer(t *testing.T) {
+	s := newFakeServer(t, 12)
+	if c, err := Dial(s.path); err == nil {
+		c.Close()
+		t.Fatal("protocol 12 accepted")
+	}
+}
+
+func TestServerInfo(t *testing.T) {
+	c := dial(t, newFakeServer(t, protocolVersion))
+	info, err := c.ServerInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ServerInfo{
+		Package: "pulseaudio", Version: "17.0", User: "me", Host: "box",
+		SampleSpec:  SampleSpec{Format: 3, Channels: 2, Rate: 48000},
+		DefaultSink: "speakers", DefaultSource: "mic",
+	}
+	if info != want {
+		t.Errorf("info = %+v, want %+v", info, want)
+	}
+}
+
+func TestSink(t *testing.T) {
+	for _, version := range []uint32{14, protocolVersion} {
+		c := dial(t, newFakeServer(t, version))
+		dev, err := c.Sink("speakers")
+		if err != nil {
+			t.Fatalf("protocol %d: %v", version, err)
+		}
+		base := VolumeNorm / 2
+		if version < 15 {
+			base = VolumeNorm // not sent
+		}
+		want := Device{
+			Index: 3, Name: "speakers", Description: "Built-in Audio",
+			SampleSpec: SampleSpec{Format: 3, Channels: 2, Rate: 48000},
+			ChannelMap: ChannelMap{ChannelFrontLeft, ChannelFrontRight},
+			Volume:     ChannelVolumes{VolumeNorm, VolumeNorm},
+			BaseVolume: base,
+		}
+		if !reflect.DeepEqual(dev, want) {
+			t.Errorf("protocol %d: sink = %+v, want %+v", version, dev, want)
+		}
+	}
+}
+
+func TestServerErrorReply(t *testing.T) {
+	c := dial(t, newFakeServer(t, protocolVersion))
+	_, err := c.Sink("headphones")
+	if !errors.Is(err, ServerError(5)) || err.Error() != "pulse: no such entity" {
+		t.Errorf("err = %v, want no such entity", err)
+	}
+	// the connection survives an error reply
+	if _, err := c.ServerInfo(); err != nil {
+		t.Errorf("after an error reply: %v", err)
+	}
+}
+
+func TestSetSinkVolumeAndMute(t *testing.T) {
+	s := newFakeServer(t, protocolVersion)
+	c := dial(t, s)
+	vol := ChannelVolumes{VolumeFromPercent(30), VolumeFromPercent(40)}
+	if err := c.SetSinkVolume(DefaultSink, vol); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetSinkMute(DefaultSink, true); err != nil {
+		t.Fatal(err)
+	}
+	dev, err := c.Sink("speakers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dev.Volume, vol) || !dev.Muted {
+		t.Errorf("sink volume %v muted %v, want %v muted", dev.Volume, dev.Muted, vol)
+	}
+	if err := c.SetSinkMute("headphones", false); !errors.Is(err, ServerError(5)) {
+		t.Errorf("mute of an unknown sink: %v", err)
+	}
+}
+
+func nextEvent(t *testing.T, c *Client) Event {
+	t.Helper()
+	select {
+	case ev, ok := <-c.Events():
+		if !ok {
+			t.Fatal("events closed")
+		}
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event")
+	}
+	return Event{}
+}
+
+func TestSubscribeEvents(t *testing.T) {
+	s := newFakeServer(t, protocolVersion)
+	c := dial(t, s)
+	if err := c.Subscribe(MaskSink | MaskServer); err != nil {
+		t.Fatal(err)
+	}
+	s.mu.Lock()
+	mask := s.mask
+	s.mu.Unlock()
+	if mask != MaskSink|MaskServer {
+		t.Errorf("mask = %#x", mask)
+	}
+
+	s.event(FacilitySink, EventChange, 3)
+	s.event(FacilityCard, EventRemove, 1)
+	if ev := nextEvent(t, c); ev.Facility() != FacilitySink || ev.Kind() != EventChange || ev.Index != 3 {
+		t.Errorf("event = %v %v %d", ev.Facility(), ev.Kind(), ev.Index)
+	}
+	if ev := nextEvent(t, c); ev.Facility() != FacilityCard || ev.Kind() != EventRemove || ev.Facility().String() != "card" {
+		t.Errorf("event = %v %v", ev.Facility(), ev.Kind())
+	}
+}
+
+func TestEventsOverflow(t *testing.T) {
+	s := newFakeServer(t, protocolVersion)
+	c := dial(t, s)
+	for i := range eventBuffer + 10 {
+		s.event(FacilitySink, EventChange, uint32(i))
+	}
+	// a request after the events is answered after they were all queued
+	if _, err := c.ServerInfo(); err != nil {
+		t.Fatal(err)
+	}
+	// the queue that overflowed was replaced by one server change,
+	// followed by the events after it
+	ev := nextEvent(t, c)
+	if ev.Facility() != FacilityServer || ev.Kind() != EventChange || ev.Index != invalidIndex {
+		t.Errorf("first event after overflow = %+v, want a server change", ev)
+	}
+	for i := eventBuffer + 1; i < eventBuffer+10; i++ {
+		if ev := nextEvent(t, c); ev.Index != uint32(i) {
+			t.Errorf("event index = %d, want %d", ev.Index, i)
+		}
+	}
+}
+
+func TestConnectionEnds(t *testing.T) {
+	s := newFakeServer(t, protocolVersion)
+	c := dial(t, s)
+	s.mu.Lock()
+	s.conn.Close()
+	s.mu.Unlock()
+
+	select {
+	case <-c.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Done not closed")
+	}
+	if !errors.Is(c.Err(), ErrClosed) {
+		t.Errorf("Err = %v, want ErrClosed", c.Err())
+	}
+	if _, ok := <-c.Events(); ok {
+		t.Error("events open after the connection ended")
+	}
+	if _, err := c.ServerInfo(); !errors.Is(err, ErrClosed) {
+		t.Errorf("request after the end: %v, want ErrClosed", err)
+	}
+}
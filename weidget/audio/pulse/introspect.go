@@ -0,0 +1,195 @@
+package pulse
+
+// Facility is the kind of object a subscription event is about.
+type Facility uint32
+
+const (
+	FacilitySink         Facility = 0
+	FacilitySource       Facility = 1
+	FacilitySinkInput    Facility = 2
+	FacilitySourceOutput Facility = 3
+	FacilityModule       Facility = 4
+	FacilityClient       Facility = 5
+	FacilitySampleCache  Facility = 6
+	FacilityServer       Facility = 7
+	FacilityCard         Facility = 9
+	facilityMask                  = 0x0F
+)
+
+// String names the facility the way pactl does.
+func (f Facility) String() string {
+	switch f {
+	case FacilitySink:
+		return "sink"
+	case FacilitySource:
+		return "source"
+	case FacilitySinkInput:
+		return "sink-input"
+	case FacilitySourceOutput:
+		return "source-output"
+	case FacilityModule:
+		return "module"
+	case FacilityClient:
+		return "client"
+	case FacilitySampleCache:
+		return "sample-cache"
+	case FacilityServer:
+		return "server"
+	case FacilityCard:
+		return "card"
+	}
+	return "unknown"
+}
+
+// EventType is what happened to the object.
+type EventType uint32
+
+const (
+	EventNew    EventType = 0x00
+	EventChange EventType = 0x10
+	EventRemove EventType = 0x20
+	eventMask             = 0x30
+)
+
+// Subscription masks for Subscribe.
+const (
+	MaskSink         uint32 = 1 << FacilitySink
+	MaskSource       uint32 = 1 << FacilitySource
+	MaskSinkInput    uint32 = 1 << FacilitySinkInput
+	MaskSourceOutput uint32 = 1 << FacilitySourceOutput
+	MaskServer       uint32 = 1 << FacilityServer
+	MaskCard         uint32 = 1 << FacilityCard
+)
+
+// Event is a subscription event as sent by the server.
+type Event struct {
+	Type  uint32 // facility | event type
+	Index uint32
+}
+
+func (e Event) Facility() Facility { return Facility(e.Type & facilityMask) }
+func (e Event) Kind() EventType    { return EventType(e.Type & eventMask) }
+
+// ServerInfo is the reply to GET_SERVER_INFO.
+type ServerInfo struct {
+	Package       string
+	Version       string
+	User          string
+	Host          string
+	SampleSpec    SampleSpec
+	DefaultSink   string
+	DefaultSource string
+}
+
+// Device describes a sink or source.
+type Device struct {
+	Index       uint32
+	Name        string
+	Description string
+	SampleSpec  SampleSpec
+	ChannelMap  ChannelMap
+	Volume      ChannelVolumes
+	Muted       bool
+	BaseVolume  Volume // hardware 0dB point, VolumeNorm when unknown
+}
+
+// DefaultSink and DefaultSource name the current defaults in requests.
+const (
+	DefaultSink   = "@DEFAULT_SINK@"
+	DefaultSource = "@DEFAULT_SOURCE@"
+)
+
+// ServerInfo asks for the server name and default devices.
+func (c *Client) ServerInfo() (ServerInfo, error) {
+	d, err := c.request(cmdGetServerInfo, nil)
+	if err != nil {
+		return ServerInfo{}, err
+	}
+	info := ServerInfo{
+		Package:    d.str(),
+		Version:    d.str(),
+		User:       d.str(),
+		Host:       d.str(),
+		SampleSpec: d.sampleSpec(),
+	}
+	info.DefaultSink = d.str()
+	info.DefaultSource = d.str()
+	return info, d.err
+}
+
+// Sink looks a sink up by name (DefaultSink works).
+func (c *Client) Sink(name string) (Device, error) {
+	return c.device(cmdGetSinkInfo, name)
+}
+
+// Source looks a source up by name (DefaultSource works).
+func (c *Client) Source(name string) (Device, error) {
+	return c.device(cmdGetSourceInfo, name)
+}
+
+// device decodes the sink/source info reply. Both share the fields read
+// here; what follows (ports, formats) is ignored.
+func (c *Client) device(cmd uint32, name string) (Device, error) {
+	d, err := c.request(cmd, new(encoder).u32(invalidIndex).name(name))
+	if err != nil {
+		return Device{}, err
+	}
+	dev := Device{
+		Index:       d.u32(),
+		Name:        d.str(),
+		Description: d.str(),
+		SampleSpec:  d.sampleSpec(),
+		ChannelMap:  d.channelMap(),
+	}
+	d.u32() // owner module
+	dev.Volume = d.cvolume()
+	dev.Muted = d.bool()
+	d.u32()        // monitor source / monitored sink
+	d.str()        // its name
+	d.u64(tagUsec) // latency
+	d.str()        // driver
+	d.u32()        // flags
+	d.proplist()   // since 13
+	d.u64(tagUsec) // configured latency
+	dev.BaseVolume = VolumeNorm
+	if c.version >= 15 {
+		dev.BaseVolume = d.volume()
+	}
+	return dev, d.err
+}
+
+// SetSinkVolume sets every channel of a sink.
+func (c *Client) SetSinkVolume(name string, v ChannelVolumes) error {
+	_, err := c.request(cmdSetSinkVolume, new(encoder).u32(invalidIndex).name(name).cvolume(v))
+	return err
+}
+
+// SetSourceVolume sets every channel of a source.
+func (c *Client) SetSourceVolume(name string, v ChannelVolumes) error {
+	_, err := c.request(cmdSetSourceVolume, new(encoder).u32(invalidIndex).name(name).cvolume(v))
+	return err
+}
+
+// SetSinkMute mutes or unmutes a sink.
+func (c *Client) SetSinkMute(name string, mute bool) error {
+	_, err := c.request(cmdSetSinkMute, new(encoder).u32(invalidIndex).name(name).bool(mute))
+	return err
+}
+
+// SetSourceMute mutes or unmutes a source.
+func (c *Client) SetSourceMute(name string, mute bool) error {
+	_, err := c.request(cmdSetSourceMute, new(encoder).u32(invalidIndex).name(name).bool(mute))
+	return err
+}
+
+// Subscribe asks for events on the facilities in mask. They arrive on
+// Events until the connection ends.
+func (c *Client) Subscribe(mask uint32) error {
+	_, err := c.request(cmdSubscribe, new(encoder).u32(mask))
+	return err
+}
+
+// Events delivers subscription events. It is closed with the connection.
+func (c *Client) Events() <-chan Event {
+	return c.events
+}
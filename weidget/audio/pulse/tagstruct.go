@@ -0,0 +1,291 @@
+package pulse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Tagstruct value tags. Every value on the wire is preceded by its tag.
+const (
+	tagString      = 't'
+	tagStringNull  = 'N'
+	tagU32         = 'L'
+	tagU8          = 'B'
+	tagU64         = 'R'
+	tagS64         = 'r'
+	tagSampleSpec  = 'x'
+	tagArbitrary   = 'a'
+	tagBoolTrue    = '1'
+	tagBoolFalse   = '0'
+	tagTimeval     = 'T'
+	tagUsec        = 'U'
+	tagChannelMap  = 'm'
+	tagCVolume     = 'v'
+	tagPropList    = 'P'
+	tagVolume      = 'V'
+	tagFormatInfo  = 'f'
+	maxChannels    = 32
+	maxTagstruct   = 16 * 1024 * 1024
+	invalidIndex   = 0xFFFFFFFF
+	tagEventPacket = 0xFFFFFFFF // tag of server initiated packets
+)
+
+var errShort = errors.New("pulse: truncated tagstruct")
+
+// encoder builds a tagstruct.
+type encoder struct {
+	buf bytes.Buffer
+}
+
+func (e *encoder) u32(v uint32) *encoder {
+	e.buf.WriteByte(tagU32)
+	e.buf.Write(binary.BigEndian.AppendUint32(nil, v))
+	return e
+}
+
+func (e *encoder) str(s string) *encoder {
+	e.buf.WriteByte(tagString)
+	e.buf.WriteString(s)
+	e.buf.WriteByte(0)
+	return e
+}
+
+// name writes a device name, or a null string for "by index".
+func (e *encoder) name(s string) *encoder {
+	if s == "" {
+		e.buf.WriteByte(tagStringNull)
+		return e
+	}
+	return e.str(s)
+}
+
+func (e *encoder) bool(v bool) *encoder {
+	if v {
+		e.buf.WriteByte(tagBoolTrue)
+	} else {
+		e.buf.WriteByte(tagBoolFalse)
+	}
+	return e
+}
+
+func (e *encoder) arbitrary(b []byte) *encoder {
+	e.buf.WriteByte(tagArbitrary)
+	e.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(len(b))))
+	e.buf.Write(b)
+	return e
+}
+
+func (e *encoder) cvolume(v ChannelVolumes) *encoder {
+	e.buf.WriteByte(tagCVolume)
+	e.buf.WriteByte(byte(len(v)))
+	for _, c := range v {
+		e.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(c)))
+	}
+	return e
+}
+
+// proplist writes string properties; values carry their NUL like
+// pa_proplist_sets stores them.
+func (e *encoder) proplist(props map[string]string) *encoder {
+	e.buf.WriteByte(tagPropList)
+	for k, v := range props {
+		e.str(k)
+		e.u32(uint32(len(v) + 1))
+		e.arbitrary(append([]byte(v), 0))
+	}
+	e.buf.WriteByte(tagStringNull)
+	return e
+}
+
+// decoder reads a tagstruct. The first error sticks; later reads return
+// zero values so callers can check err once at the end.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+func (d *decoder) tag(want byte) bool {
+	if d.err != nil {
+		return false
+	}
+	if len(d.b) == 0 {
+		d.fail(errShort)
+		return false
+	}
+	if d.b[0] != want {
+		d.fail(fmt.Errorf("pulse: tagstruct: want tag %q, got %q", want, d.b[0]))
+		return false
+	}
+	d.b = d.b[1:]
+	return true
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if len(d.b) < n {
+		d.fail(errShort)
+		return nil
+	}
+	v := d.b[:n]
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) rawU32() uint32 {
+	b := d.take(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+func (d *decoder) rawU8() uint8 {
+	b := d.take(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (d *decoder) u32() uint32 {
+	if !d.tag(tagU32) {
+		return 0
+	}
+	return d.rawU32()
+}
+
+func (d *decoder) u64(tag byte) uint64 {
+	if !d.tag(tag) {
+		return 0
+	}
+	b := d.take(8)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+// str reads a string; a null string reads as "".
+func (d *decoder) str() string {
+	if d.err == nil && len(d.b) > 0 && d.b[0] == tagStringNull {
+		d.b = d.b[1:]
+		return ""
+	}
+	if !d.tag(tagString) {
+		return ""
+	}
+	i := bytes.IndexByte(d.b, 0)
+	if i < 0 {
+		d.fail(errShort)
+		return ""
+	}
+	s := string(d.b[:i])
+	d.b = d.b[i+1:]
+	return s
+}
+
+func (d *decoder) bool() bool {
+	if d.err != nil {
+		return false
+	}
+	if len(d.b) == 0 {
+		d.fail(errShort)
+		return false
+	}
+	switch d.b[0] {
+	case tagBoolTrue:
+		d.b = d.b[1:]
+		return true
+	case tagBoolFalse:
+		d.b = d.b[1:]
+		return false
+	}
+	d.fail(fmt.Errorf("pulse: tagstruct: want bool, got %q", d.b[0]))
+	return false
+}
+
+func (d *decoder) arbitrary() []byte {
+	if !d.tag(tagArbitrary) {
+		return nil
+	}
+	return d.take(int(d.rawU32()))
+}
+
+func (d *decoder) sampleSpec() SampleSpec {
+	if !d.tag(tagSampleSpec) {
+		return SampleSpec{}
+	}
+	return SampleSpec{Format: d.rawU8(), Channels: d.rawU8(), Rate: d.rawU32()}
+}
+
+func (d *decoder) channelMap() ChannelMap {
+	if !d.tag(tagChannelMap) {
+		return nil
+	}
+	n := int(d.rawU8())
+	if n > maxChannels {
+		d.fail(fmt.Errorf("pulse: %d channels", n))
+		return nil
+	}
+	m := make(ChannelMap, n)
+	for i := range m {
+		m[i] = ChannelPosition(d.rawU8())
+	}
+	return m
+}
+
+func (d *decoder) cvolume() ChannelVolumes {
+	if !d.tag(tagCVolume) {
+		return nil
+	}
+	n := int(d.rawU8())
+	if n > maxChannels {
+		d.fail(fmt.Errorf("pulse: %d channels", n))
+		return nil
+	}
+	v := make(ChannelVolumes, n)
+	for i := range v {
+		v[i] = Volume(d.rawU32())
+	}
+	return v
+}
+
+func (d *decoder) volume() Volume {
+	if !d.tag(tagVolume) {
+		return 0
+	}
+	return Volume(d.rawU32())
+}
+
+// proplist reads a property list, keeping values as strings without the
+// trailing NUL.
+func (d *decoder) proplist() map[string]string {
+	if !d.tag(tagPropList) {
+		return nil
+	}
+	props := map[string]string{}
+	for d.err == nil {
+		if len(d.b) > 0 && d.b[0] == tagStringNull {
+			d.b = d.b[1:]
+			return props
+		}
+		key := d.str()
+		n := d.u32()
+		v := d.arbitrary()
+		if d.err == nil && uint32(len(v)) != n {
+			d.fail(errors.New("pulse: proplist length mismatch"))
+		}
+		props[key] = string(bytes.TrimSuffix(v, []byte{0}))
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package pulse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The client only sends what it needs; the fake server also writes the
+// values it only reads.
+
+func (e *encoder) sampleSpec(s SampleSpec) *encoder {
+	e.buf.Write([]byte{tagSampleSpec, s.Format, s.Channels})
+	e.buf.Write(binary.BigEndian.AppendUint32(nil, s.Rate))
+	return e
+}
+
+func (e *encoder) channelMap(m ChannelMap) *encoder {
+	e.buf.Write([]byte{tagChannelMap, byte(len(m))})
+	for _, p := range m {
+		e.buf.WriteByte(byte(p))
+	}
+	return e
+}
+
+func (e *encoder) u64(tag byte, v uint64) *encoder {
+	e.buf.WriteByte(tag)
+	e.buf.Write(binary.BigEndian.AppendUint64(nil, v))
+	return e
+}
+
+func (e *encoder) volume(v Volume) *encoder {
+	e.buf.WriteByte(tagVolume)
+	e.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(v)))
+	return e
+}
+
+func (e *encoder) decoder() *decoder {
+	return &decoder{b: bytes.Clone(e.buf.Bytes())}
+}
+
+func TestTagstructRoundTrip(t *testing.T) {
+	spec := SampleSpec{Format: 3, Channels: 2, Rate: 48000}
+	cmap := ChannelMap{ChannelFrontLeft, ChannelFrontRight}
+	vol := ChannelVolumes{VolumeNorm, VolumeNorm / 2}
+	props := map[string]string{"application.name": "termctrl", "empty": ""}
+	blob := []byte{0, 1, 2, 0xFF}
+
+	d := new(encoder).
+		u32(0xDEADBEEF).
+		str("alsa_output.pci").
+		name("").
+		name("@DEFAULT_SINK@").
+		bool(true).
+		bool(false).
+		arbitrary(blob).
+		sampleSpec(spec).
+		channelMap(cmap).
+		cvolume(vol).
+		proplist(props).
+		u64(tagUsec, 1<<40).
+		volume(VolumeNorm).
+		decoder()
+
+	if v := d.u32(); v != 0xDEADBEEF {
+		t.Errorf("u32 = %#x", v)
+	}
+	if s := d.str(); s != "alsa_output.pci" {
+		t.Errorf("str = %q", s)
+	}
+	if s := d.str(); s != "" {
+		t.Errorf("null name = %q, want empty", s)
+	}
+	if s := d.str(); s != "@DEFAULT_SINK@" {
+		t.Errorf("name = %q", s)
+	}
+	if !d.bool() || d.bool() {
+		t.Error("bools do not round-trip")
+	}
+	if b := d.arbitrary(); !bytes.Equal(b, blob) {
+		t.Errorf("arbitrary = %v", b)
+	}
+	if s := d.sampleSpec(); s != spec {
+		t.Errorf("sample spec = %+v", s)
+	}
+	if m := d.channelMap(); !reflect.DeepEqual(m, cmap) {
+		t.Errorf("channel map = %v", m)
+	}
+	if v := d.cvolume(); !reflect.DeepEqual(v, vol) {
+		t.Errorf("cvolume = %v", v)
+	}
+	if p := d.proplist(); !reflect.DeepEqual(p, props) {
+		t.Errorf("proplist = %v", p)
+	}
+	if v := d.u64(tagUsec); v != 1<<40 {
+		t.Errorf("usec = %d", v)
+	}
+	if v := d.volume(); v != VolumeNorm {
+		t.Errorf("volume = %#x", v)
+	}
+	if d.err != nil {
+		t.Fatal(d.err)
+	}
+	if len(d.b) != 0 {
+		t.Errorf("%d bytes left over", len(d.b))
+	}
+}
+
+func TestProplistValueNUL(t *testing.T) {
+	// values are stored with their NUL, like pa_proplist_sets does
+	e := new(encoder).proplist(map[string]string{"k": "v"})
+	want := []byte{tagPropList, tagString, 'k', 0, tagU32, 0, 0, 0, 2, tagArbitrary, 0, 0, 0, 2, 'v', 0, tagStringNull}
+	if !bytes.Equal(e.buf.Bytes(), want) {
+		t.Errorf("proplist = %q, want %q", e.buf.Bytes(), want)
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		b    []byte
+		read func(d *decoder)
+		want string
+	}{
+		{"wrong tag", new(encoder).str("x").buf.Bytes(), func(d *decoder) { d.u32() }, "want tag 'L'"},
+		{"truncated u32", []byte{tagU32, 0, 0}, func(d *decoder) { d.u32() }, errShort.Error()},
+		{"empty", nil, func(d *decoder) { d.bool() }, errShort.Error()},
+		{"unterminated string", []byte{tagString, 'a', 'b'}, func(d *decoder) { d.str() }, errShort.Error()},
+		{"not a bool", []byte{tagU32}, func(d *decoder) { d.bool() }, "want bool"},
+		{"too many channels", []byte{tagCVolume, maxChannels + 1}, func(d *decoder) { d.cvolume() }, "33 channels"},
+		{"arbitrary longer than packet", []byte{tagArbitrary, 0, 0, 1, 0, 'x'}, func(d *decoder) { d.arbitrary() }, errShort.Error()},
+		{"proplist length mismatch",
+			append([]byte{tagPropList}, new(encoder).str("k").u32(5).arbitrary([]byte("v\x00")).buf.Bytes()...),
+			func(d *decoder) { d.proplist() }, "length mismatch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &decoder{b: tt.b}
+			tt.read(d)
+			if d.err == nil || !strings.Contains(d.err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", d.err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecoderErrorSticks(t *testing.T) {
+	d := new(encoder).str("x").u32(7).decoder()
+	d.u32() // wrong tag
+	first := d.err
+	if v := d.str(); v != "" || !errors.Is(d.err, first) {
+		t.Errorf("read after an error = %q, err %v; want zero and the first error", v, d.err)
+	}
+}
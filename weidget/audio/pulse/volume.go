@@ -0,0 +1,230 @@
+package pulse
+
+import "math"
+
+// Volume is a PulseAudio software volume; VolumeNorm is 100%.
+type Volume uint32
+
+const (
+	VolumeMuted Volume = 0
+	VolumeNorm  Volume = 0x10000
+	VolumeMax   Volume = math.MaxUint32 / 2
+)
+
+// Percent rounds the volume to a percentage the way pactl prints it.
+func (v Volume) Percent() int {
+	return int((uint64(v)*100 + uint64(VolumeNorm)/2) / uint64(VolumeNorm))
+}
+
+// VolumeFromPercent converts a percentage, clamped to VolumeMax.
+func VolumeFromPercent(p int) Volume {
+	if p <= 0 {
+		return VolumeMuted
+	}
+	v := uint64(p) * uint64(VolumeNorm) / 100
+	return Volume(min(v, uint64(VolumeMax)))
+}
+
+// ChannelVolumes holds one volume per channel, in channel map order.
+type ChannelVolumes []Volume
+
+// Avg is the mean of the channels.
+func (c ChannelVolumes) Avg() Volume {
+	if len(c) == 0 {
+		return VolumeMuted
+	}
+	var sum uint64
+	for _, v := range c {
+		sum += uint64(v)
+	}
+	return Volume(sum / uint64(len(c)))
+}
+
+// Max is the loudest channel.
+func (c ChannelVolumes) Max() Volume {
+	var m Volume
+	for _, v := range c {
+		m = max(m, v)
+	}
+	return m
+}
+
+// Set returns a copy with every channel at v, like `pactl set-sink-volume`.
+func (c ChannelVolumes) Set(v Volume) ChannelVolumes {
+	out := make(ChannelVolumes, len(c))
+	for i := range out {
+		out[i] = v
+	}
+	return out
+}
+
+// Scale returns a copy where the loudest channel is v and the others keep
+// their proportion to it, so balance survives volume changes.
+func (c ChannelVolumes) Scale(v Volume) ChannelVolumes {
+	m := c.Max()
+	if m == VolumeMuted {
+		return c.Set(v)
+	}
+	out := make(ChannelVolumes, len(c))
+	for i, cv := range c {
+		out[i] = Volume(uint64(cv) * uint64(v) / uint64(m))
+	}
+	return out
+}
+
+// SampleSpec describes a stream format.
+type SampleSpec struct {
+	Format   uint8
+	Channels uint8
+	Rate     uint32
+}
+
+// ChannelPosition is the speaker a channel is routed to.
+type ChannelPosition uint8
+
+const (
+	ChannelMono ChannelPosition = iota
+	ChannelFrontLeft
+	ChannelFrontRight
+	ChannelFrontCenter
+	ChannelRearCenter
+	ChannelRearLeft
+	ChannelRearRight
+	ChannelLFE
+	ChannelFrontLeftOfCenter
+	ChannelFrontRightOfCenter
+	ChannelSideLeft
+	ChannelSideRight
+)
+
+// The top positions follow 32 aux channels.
+const (
+	ChannelTopCenter ChannelPosition = 44 + iota
+	ChannelTopFrontLeft
+	ChannelTopFrontRight
+	ChannelTopFrontCenter
+	ChannelTopRearLeft
+	ChannelTopRearRight
+)
+
+// Left reports whether the channel is on the left side.
+func (p ChannelPosition) Left() bool {
+	switch p {
+	case ChannelFrontLeft, ChannelRearLeft, ChannelFrontLeftOfCenter,
+		ChannelSideLeft, ChannelTopFrontLeft, ChannelTopRearLeft:
+		return true
+	}
+	return false
+}
+
+// Right reports whether the channel is on the right side.
+func (p ChannelPosition) Right() bool {
+	switch p {
+	case ChannelFrontRight, ChannelRearRight, ChannelFrontRightOfCenter,
+		ChannelSideRight, ChannelTopFrontRight, ChannelTopRearRight:
+		return true
+	}
+	return false
+}
+
+// ChannelMap lists the position of every channel.
+type ChannelMap []ChannelPosition
+
+// CanBalance reports whether the map has both left and right channels.
+func (m ChannelMap) CanBalance() bool {
+	var l, r bool
+	for _, p := range m {
+		l = l || p.Left()
+		r = r || p.Right()
+	}
+	return l && r
+}
+
+// sides averages the left and right channels of v.
+func (m ChannelMap) sides(v ChannelVolumes) (left, right float64) {
+	var nl, nr int
+	for i, p := range m {
+		if i >= len(v) {
+			break
+		}
+		switch {
+		case p.Left():
+			left += float64(v[i])
+			nl++
+		case p.Right():
+			right += float64(v[i])
+			nr++
+		}
+	}
+	if nl > 0 {
+		left /= float64(nl)
+	}
+	if nr > 0 {
+		right /= float64(nr)
+	}
+	return left, right
+}
+
+// Sides averages the left and right channels of v. Both are zero when
+// the map has no left/right channels.
+func (m ChannelMap) Sides(v ChannelVolumes) (left, right Volume) {
+	if !m.CanBalance() {
+		return 0, 0
+	}
+	l, r := m.sides(v)
+	return Volume(math.Round(l)), Volume(math.Round(r))
+}
+
+// Balance is -1 (all left) … 0 (centered) … 1 (all right), computed like
+// pa_cvolume_get_balance.
+func (m ChannelMap) Balance(v ChannelVolumes) float64 {
+	if !m.CanBalance() {
+		return 0
+	}
+	left, right := m.sides(v)
+	switch {
+	case left == right:
+		return 0
+	case left > right:
+		return right/left - 1
+	}
+	return 1 - left/right
+}
+
+// SetBalance returns a copy of v moved to balance b, keeping the louder
+// side's volume, like pa_cvolume_set_balance.
+func (m ChannelMap) SetBalance(v ChannelVolumes, b float64) ChannelVolumes {
+	out := append(ChannelVolumes(nil), v...)
+	if !m.CanBalance() {
+		return out
+	}
+	b = max(-1, min(1, b))
+	left, right := m.sides(v)
+	top := max(left, right)
+	nleft, nright := top, top
+	if b < 0 {
+		nright = (b + 1) * top
+	} else {
+		nleft = (1 - b) * top
+	}
+
+	for i, p := range m {
+		if i >= len(out) {
+			break
+		}
+		switch {
+		case p.Left():
+			out[i] = rescale(out[i], left, nleft)
+		case p.Right():
+			out[i] = rescale(out[i], right, nright)
+		}
+	}
+	return out
+}
+
+func rescale(v Volume, from, to float64) Volume {
+	if from == 0 {
+		return Volume(to)
+	}
+	return Volume(math.Round(float64(v) * to / from))
+}
@@ -0,0 +1,79 @@
+package audio
+
+import (
+	"github.com/antiloger/termctlr/weidget/audio/pulse"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// refreshedMsg carries device state re-read after sound server events.
+// Parts that were not refreshed are nil.
+type refreshedMsg struct {
+	out, in *pulse.Device
+	err     error
+}
+
+// refreshFor works out what a batch of events invalidates: a change on
+// the default sink or source re-reads just that device, objects coming or
+// going and server changes (default device switched) re-read both.
+func (w *AudioWidget) refreshFor(events []Event) tea.Cmd {
+	w.mu.Lock()
+	outIndex, inIndex := w.outIndex, w.inIndex
+	w.mu.Unlock()
+
+	var out, in bool
+	for _, ev := range events {
+		switch ev.Facility {
+		case FacilityServer:
+			out, in = true, true
+		case FacilitySink:
+			out = out || ev.Kind != EventChange || outIndex < 0 || ev.Index == outIndex
+		case FacilitySource:
+			in = in || ev.Kind != EventChange || inIndex < 0 || ev.Index == inIndex
+		}
+	}
+	if !out && !in {
+		return nil
+	}
+	return w.refresh(out, in)
+}
+
+// refresh reads the requested devices off the UI goroutine.
+func (w *AudioWidget) refresh(out, in bool) tea.Cmd {
+	c := w.client
+	return func() tea.Msg {
+		var msg refreshedMsg
+		if out {
+			dev, err := c.Sink(pulse.DefaultSink)
+			if err != nil {
+				msg.err = err
+				return msg
+			}
+			msg.out = &dev
+		}
+		if in {
+			dev, err := c.Source(pulse.DefaultSource)
+			if err != nil {
+				msg.err = err
+				return msg
+			}
+			msg.in = &dev
+		}
+		return msg
+	}
+}
+
+// apply stores refreshed state.
+func (w *AudioWidget) apply(msg refreshedMsg) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if d := msg.out; d != nil {
+		w.outIndex = int(d.Index)
+		w.outChannels, w.outMap = d.Volume, d.ChannelMap
+		w.OutVolume, w.OutMuted = d.Volume.Max().Percent(), d.Muted
+	}
+	if d := msg.in; d != nil {
+		w.inIndex = int(d.Index)
+		w.inChannels, w.inMap = d.Volume, d.ChannelMap
+		w.InVolume, w.InMuted = d.Volume.Max().Percent(), d.Muted
+	}
+}
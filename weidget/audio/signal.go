@@ -1,48 +1,177 @@
 package audio
 
 import (
-	"bufio"
-	"os/exec"
-	"strings"
+	"fmt"
+	"math"
+	"sync"
+	"time"
 
+	"github.com/antiloger/termctlr/weidget/audio/pulse"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-type VolumeChangedMsg struct{}
-
-// NewPactlSubscriber starts a single long-lived goroutine that watches
-// pactl subscribe and forwards sink/source events to the returned channel.
-// Call this once (e.g. in NewModel) and hold onto the channel.
-func NewPactlSubscriber() chan struct{} {
-	ch := make(chan struct{})
-	go func() {
-		cmd := exec.Command("pactl", "subscribe")
-		out, err := cmd.StdoutPipe()
-		if err != nil {
-			return
-		}
-		if err := cmd.Start(); err != nil {
+// EventKind is what happened to a sound server object.
+type EventKind int
+
+const (
+	EventNew EventKind = iota
+	EventChange
+	EventRemove
+)
+
+func (k EventKind) String() string {
+	return [...]string{"new", "change", "remove"}[k]
+}
+
+// Facility is the kind of object an event is about, named like pactl
+// names it: sink, source, sink-input, source-output, server, card, client.
+type Facility string
+
+const (
+	FacilitySink         Facility = "sink"
+	FacilitySource       Facility = "source"
+	FacilitySinkInput    Facility = "sink-input"
+	FacilitySourceOutput Facility = "source-output"
+	FacilityServer       Facility = "server"
+)
+
+// Event is one sound server change notification.
+type Event struct {
+	Kind     EventKind
+	Facility Facility
+	Index    int // object index, -1 for the server
+}
+
+func (e Event) String() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("%s %s", e.Kind, e.Facility)
+	}
+	return fmt.Sprintf("%s %s #%d", e.Kind, e.Facility, e.Index)
+}
+
+// fromPulse converts a native protocol subscription event.
+func fromPulse(ev pulse.Event) Event {
+	e := Event{Facility: Facility(ev.Facility().String()), Index: int(ev.Index)}
+	switch ev.Kind() {
+	case pulse.EventNew:
+		e.Kind = EventNew
+	case pulse.EventRemove:
+		e.Kind = EventRemove
+	default:
+		e.Kind = EventChange
+	}
+	if ev.Facility() == pulse.FacilityServer || ev.Index == math.MaxUint32 {
+		e.Index = -1
+	}
+	return e
+}
+
+// debounce is how long a burst of events is collected before it is
+// delivered as one batch.
+const debounce = 150 * time.Millisecond
+
+// SubscriberHealth describes the event subscription.
+type SubscriberHealth struct {
+	Running bool
+	LastErr error     // why it ended
+	Since   time.Time // start of the current state
+}
+
+// Subscriber turns the connection's subscription events into debounced
+// batches. It ends with the connection.
+type Subscriber struct {
+	client *pulse.Client
+	events chan []Event
+	stop   chan struct{}
+	once   sync.Once
+
+	mu     sync.Mutex
+	health SubscriberHealth
+}
+
+// NewSubscriber starts delivering the events of c. Call this once per
+// connection and Stop it when the connection is dropped.
+func NewSubscriber(c *pulse.Client) *Subscriber {
+	s := &Subscriber{
+		client: c,
+		events: make(chan []Event),
+		stop:   make(chan struct{}),
+		health: SubscriberHealth{Running: true, Since: time.Now()},
+	}
+	go s.run()
+	return s
+}
+
+// Health returns a snapshot of the subscriber state.
+func (s *Subscriber) Health() SubscriberHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.health
+}
+
+// Stop ends delivery; the event stream is closed.
+func (s *Subscriber) Stop() {
+	s.once.Do(func() { close(s.stop) })
+}
+
+func (s *Subscriber) run() {
+	defer close(s.events)
+	defer func() {
+		s.mu.Lock()
+		s.health = SubscriberHealth{LastErr: s.client.Err(), Since: time.Now()}
+		s.mu.Unlock()
+	}()
+
+	in := s.client.Events()
+	var pending []Event
+	var flush <-chan time.Time
+	for {
+		select {
+		case ev, ok := <-in:
+			if !ok {
+				return
+			}
+			if flush == nil {
+				flush = time.After(debounce)
+			}
+			pending = appendEvent(pending, fromPulse(ev))
+		case <-flush:
+			select {
+			case s.events <- pending:
+			case <-s.stop:
+				return
+			}
+			pending, flush = nil, nil
+		case <-s.stop:
 			return
 		}
-		defer cmd.Process.Kill()
+	}
+}
 
-		scanner := bufio.NewScanner(out)
-		for scanner.Scan() {
-			line := scanner.Text()
-			if strings.Contains(line, "sink") || strings.Contains(line, "source") {
-				ch <- struct{}{}
-			}
+// appendEvent adds ev to a batch unless the same event is already in it.
+func appendEvent(batch []Event, ev Event) []Event {
+	for _, e := range batch {
+		if e == ev {
+			return batch
 		}
-	}()
-	return ch
+	}
+	return append(batch, ev)
 }
 
-// WaitForVolumeChange returns a tea.Cmd that blocks until the next event
-// arrives on the channel, then emits VolumeChangedMsg.
-// This is safe to re-issue after every message — the goroutine is NOT restarted.
-func WaitForVolumeChange(ch chan struct{}) tea.Cmd {
+// EventsMsg is a debounced batch of sound server events.
+type EventsMsg struct {
+	Events []Event
+}
+
+// WaitForEvents returns a tea.Cmd that blocks until the next batch of
+// events arrives, then emits EventsMsg, or SubscriberClosedMsg once the
+// subscriber ended. Re-issue it after every message.
+func WaitForEvents(s *Subscriber) tea.Cmd {
 	return func() tea.Msg {
-		<-ch // blocks until the goroutine sends
-		return VolumeChangedMsg{}
+		batch, ok := <-s.events
+		if !ok {
+			return SubscriberClosedMsg{sub: s}
+		}
+		return EventsMsg{Events: batch}
 	}
 }
@@ -0,0 +1,63 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/gen2brain/malgo"
+)
+
+const toneRate = 44100
+
+// PlayTone plays a beeping sine tone (250ms on, 250ms off) through the
+// default sink until StopTone is called or maxDur elapses. It opens its
+// own playback device on the shared malgo context.
+func (w *AudioWidget) PlayTone(freq float64, maxDur time.Duration) error {
+	w.StopTone()
+
+	cfg := malgo.DefaultDeviceConfig(malgo.Playback)
+	cfg.Playback.Format = malgo.FormatS16
+	cfg.Playback.Channels = 1
+	cfg.SampleRate = toneRate
+	cfg.Alsa.NoMMap = 1
+
+	var n int // samples written, only touched by the audio thread
+	total := int(maxDur.Seconds() * toneRate)
+	dev, err := malgo.InitDevice(w.ctx.Context, cfg, malgo.DeviceCallbacks{
+		Data: func(output, _ []byte, frames uint32) {
+			for i := 0; i < int(frames); i++ {
+				var v float64
+				if n < total && (n/(toneRate/4))%2 == 0 {
+					v = 0.3 * math.Sin(2*math.Pi*freq*float64(n)/toneRate)
+				}
+				binary.LittleEndian.PutUint16(output[i*2:], uint16(int16(v*32767)))
+				n++
+			}
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if err := dev.Start(); err != nil {
+		dev.Uninit()
+		return err
+	}
+
+	w.mu.Lock()
+	w.toneDevice = dev
+	w.mu.Unlock()
+	return nil
+}
+
+// StopTone stops a tone started by PlayTone, if any.
+func (w *AudioWidget) StopTone() {
+	w.mu.Lock()
+	dev := w.toneDevice
+	w.toneDevice = nil
+	w.mu.Unlock()
+	if dev != nil {
+		dev.Stop()
+		dev.Uninit()
+	}
+}
@@ -1,45 +1,126 @@
 package audio
 
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antiloger/termctlr/theme"
+	"github.com/antiloger/termctlr/weidget/components"
+	"github.com/charmbracelet/lipgloss"
+)
+
 const (
-	FildBox  = "█"
-	EmptyBox = "░"
-	MutedBox = "░░░░░░░ mute ░░░░░░░"
+	barWidth   = 20
+	MutedLabel = " mute "
 )
 
 func (m *Model) UIVolumeOut() string {
-	if m.audio.OutMuted {
-		return MutedBox
+	return volumeBar(m.audio.OutVolume, m.audio.MaxOutVolume, m.audio.OutMuted)
+}
+
+func (m *Model) UIVolumeIn() string {
+	return volumeBar(m.audio.InVolume, m.audio.MaxInVolume, m.audio.InMuted)
+}
+
+// outView is the speaker bar, split into a left and a right bar when the
+// sides are at different volumes.
+func (m *Model) outView() []string {
+	left, right, stereo := m.audio.OutSides()
+	return sidesView("Vol", m.UIVolumeOut(), m.audio.OutVolume, left, right, stereo && left != right,
+		func(v int) string { return volumeBar(v, m.audio.MaxOutVolume, m.audio.OutMuted) })
+}
+
+// inView is outView for the mic; the indicator follows the first line.
+func (m *Model) inView() []string {
+	left, right, stereo := m.audio.InSides()
+	lines := sidesView("Mic", m.UIVolumeIn(), m.audio.InVolume, left, right, stereo && left != right,
+		func(v int) string { return volumeBar(v, m.audio.MaxInVolume, m.audio.InMuted) })
+	lines[0] = lipgloss.JoinHorizontal(lipgloss.Center, lines[0], "  ", m.micIndicator())
+	return lines
+}
+
+// sidesView lays out one bar labelled "<label>: ", or one per side
+// ("<label>L", "<label>R") when split.
+func sidesView(label, bar string, vol, left, right int, split bool, sideBar func(int) string) []string {
+	if !split {
+		return []string{lipgloss.JoinHorizontal(lipgloss.Center, label+": ", bar, "  ", fmt.Sprintf("%d%%", vol))}
 	}
-	maxblock := 20
+	return []string{
+		lipgloss.JoinHorizontal(lipgloss.Center, label+"L ", sideBar(left), "  ", fmt.Sprintf("%d%%", left)),
+		lipgloss.JoinHorizontal(lipgloss.Center, label+"R ", sideBar(right), "  ", fmt.Sprintf("%d%%", right)),
+	}
+}
 
-	filled := int(float64(m.audio.OutVolume) / float64(m.audio.MaxInVolume) * float64(maxblock))
-	var bar string
-	for i := range maxblock {
-		if i < filled {
-			bar += FildBox
-			continue
-		}
-		bar += EmptyBox
+// volumeBar draws a volume out of maxVol. When the max allows
+// over-amplification the bar's tail stands for 100%…maxVol and is drawn
+// as an alert, so the normal range keeps its usual colors.
+func volumeBar(vol, maxVol int, muted bool) string {
+	if muted {
+		return mutedBox(barWidth)
 	}
+	if maxVol <= 100 {
+		return components.NewGauge(float64(vol), float64(maxVol)).Render(barWidth)
+	}
+	normal := barWidth * 100 / maxVol
+	over := components.NewGauge(float64(vol-100), float64(maxVol-100))
+	over.Role = theme.Alert
+	return components.NewGauge(float64(min(vol, 100)), 100).Render(normal) + over.Render(barWidth-normal)
+}
 
-	return bar
+func mutedBox(width int) string {
+	t := theme.Current()
+	empty := t.Style(theme.GaugeEmpty)
+	side := (width - len(MutedLabel)) / 2
+	rest := width - side - len(MutedLabel)
+	return empty.Render(strings.Repeat(t.Glyphs.GaugeEmpty, side)) +
+		t.Style(theme.Alert).Render(MutedLabel) +
+		empty.Render(strings.Repeat(t.Glyphs.GaugeEmpty, rest))
 }
 
-func (m *Model) UIVolumeIn() string {
-	if m.audio.InMuted {
-		return MutedBox
+// disconnectedView explains why there are no volume controls and when the
+// next attempt is due.
+func (m *Model) disconnectedView() string {
+	t := theme.Current()
+	status := "connecting…"
+	if !m.connecting && m.reason != nil {
+		wait := max(m.retryAt.Sub(m.now).Round(time.Second), 0)
+		status = fmt.Sprintf("retrying in %s · r retry now", wait)
 	}
-	maxblock := 20
+	lines := []string{t.Style(theme.Alert).Bold(true).Render("audio: disconnected")}
+	if m.reason != nil {
+		lines = append(lines, t.Style(theme.Muted).Render(m.reason.Error()))
+	}
+	lines = append(lines, t.Style(theme.Muted).Render(status))
+	return strings.Join(lines, "\n")
+}
 
-	filled := int(float64(m.audio.InVolume) / float64(m.audio.MaxInVolume) * float64(maxblock))
-	var bar string
-	for i := range maxblock {
-		if i < filled {
-			bar += FildBox
-			continue
+// healthView is a one line summary of the event subscription.
+func (m *Model) healthView() string {
+	t := theme.Current()
+	h := m.sub.Health()
+	if !h.Running {
+		status := "● events down"
+		if h.LastErr != nil {
+			status += " · " + h.LastErr.Error()
 		}
-		bar += EmptyBox
+		return t.Style(theme.Alert).Render(status)
 	}
+	return t.Style(theme.Muted).Render(fmt.Sprintf("● events live · protocol %d", m.audio.client.Version()))
+}
 
-	return bar
+// micIndicator shows whether the mic is picking up voice, and the
+// push-to-talk state.
+func (m *Model) micIndicator() string {
+	t := theme.Current()
+	var s string
+	if m.mic.live {
+		s = t.Style(theme.Accent).Bold(true).Render("● live")
+	} else {
+		s = t.Style(theme.Muted).Render("○ quiet")
+	}
+	if m.mic.ptt {
+		s += " " + t.Style(theme.Focus).Render("[PTT]")
+	}
+	return s
 }
@@ -0,0 +1,146 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// wavChunk is how many samples DecodeWAV reads at a time.
+const wavChunk = 64 << 10
+
+// WAV is 16 bit PCM audio, samples interleaved by channel.
+type WAV struct {
+	Rate     int
+	Channels int
+	Samples  []int16
+}
+
+// Duration is the length of the audio in seconds.
+func (w WAV) Duration() float64 {
+	if w.Rate == 0 || w.Channels == 0 {
+		return 0
+	}
+	return float64(len(w.Samples)) / float64(w.Rate*w.Channels)
+}
+
+// WriteWAV writes a canonical 44 byte header RIFF/WAVE file.
+func WriteWAV(path string, w WAV) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := EncodeWAV(f, w); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// EncodeWAV writes w as a WAV stream.
+func EncodeWAV(out io.Writer, w WAV) error {
+	if w.Rate <= 0 || w.Channels <= 0 {
+		return errors.New("wav: missing rate or channels")
+	}
+	data := uint32(len(w.Samples) * 2)
+	bw := bufio.NewWriter(out)
+	le := binary.LittleEndian
+
+	bw.WriteString("RIFF")
+	binary.Write(bw, le, 36+data)
+	bw.WriteString("WAVE")
+
+	bw.WriteString("fmt ")
+	binary.Write(bw, le, uint32(16))                  // chunk size
+	binary.Write(bw, le, uint16(1))                   // PCM
+	binary.Write(bw, le, uint16(w.Channels))          //
+	binary.Write(bw, le, uint32(w.Rate))              //
+	binary.Write(bw, le, uint32(w.Rate*w.Channels*2)) // byte rate
+	binary.Write(bw, le, uint16(w.Channels*2))        // block align
+	binary.Write(bw, le, uint16(16))                  // bits per sample
+
+	bw.WriteString("data")
+	binary.Write(bw, le, data)
+	if err := binary.Write(bw, le, w.Samples); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// ReadWAV reads a 16 bit PCM WAV file.
+func ReadWAV(path string) (WAV, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return WAV{}, err
+	}
+	defer f.Close()
+	return DecodeWAV(bufio.NewReader(f))
+}
+
+// DecodeWAV reads a 16 bit PCM WAV stream, skipping chunks other than
+// "fmt " and "data".
+func DecodeWAV(in io.Reader) (WAV, error) {
+	le := binary.LittleEndian
+	var hdr [12]byte
+	if _, err := io.ReadFull(in, hdr[:]); err != nil {
+		return WAV{}, fmt.Errorf("wav: %w", err)
+	}
+	if string(hdr[0:4]) != "RIFF" || string(hdr[8:12]) != "WAVE" {
+		return WAV{}, errors.New("wav: not a RIFF/WAVE file")
+	}
+
+	var w WAV
+	var haveFmt bool
+	for {
+		var ch [8]byte
+		if _, err := io.ReadFull(in, ch[:]); err != nil {
+			return WAV{}, fmt.Errorf("wav: no data chunk: %w", err)
+		}
+		id, size := string(ch[0:4]), le.Uint32(ch[4:8])
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return WAV{}, errors.New("wav: short fmt chunk")
+			}
+			var f struct {
+				Format, Channels     uint16
+				Rate, ByteRate       uint32
+				Align, BitsPerSample uint16
+			}
+			if err := binary.Read(in, le, &f); err != nil {
+				return WAV{}, fmt.Errorf("wav: %w", err)
+			}
+			if f.Format != 1 || f.BitsPerSample != 16 {
+				return WAV{}, fmt.Errorf("wav: format %d/%d bit not supported, want 16 bit PCM", f.Format, f.BitsPerSample)
+			}
+			w.Rate, w.Channels = int(f.Rate), int(f.Channels)
+			haveFmt = true
+			size -= 16
+		case "data":
+			if !haveFmt {
+				return WAV{}, errors.New("wav: data before fmt")
+			}
+			// the header's size is only a claim: grow as samples arrive
+			// rather than allocating it up front
+			left := int(size / 2)
+			buf := make([]int16, min(left, wavChunk))
+			for left > 0 {
+				n := min(left, wavChunk)
+				if err := binary.Read(in, le, buf[:n]); err != nil {
+					return WAV{}, fmt.Errorf("wav: %w", err)
+				}
+				w.Samples = append(w.Samples, buf[:n]...)
+				left -= n
+			}
+			return w, nil
+		}
+		// skip the rest of the chunk, chunks are word aligned
+		if _, err := io.CopyN(io.Discard, in, int64(size+size%2)); err != nil {
+			return WAV{}, fmt.Errorf("wav: %w", err)
+		}
+	}
+}
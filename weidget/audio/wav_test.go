@@ -0,0 +1,55 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+)
+
+func TestWAVRoundTrip(t *testing.T) {
+	in := WAV{Rate: 8000, Channels: 2, Samples: make([]int16, 2*wavChunk+3)}
+	for i := range in.Samples {
+		in.Samples[i] = int16(i*7 - 30000)
+	}
+	var buf bytes.Buffer
+	if err := EncodeWAV(&buf, in); err != nil {
+		t.Fatal(err)
+	}
+	out, err := DecodeWAV(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Rate != in.Rate || out.Channels != in.Channels || !slices.Equal(out.Samples, in.Samples) {
+		t.Errorf("decoded %d Hz %d ch %d samples, want %d Hz %d ch %d", out.Rate, out.Channels, len(out.Samples), in.Rate, in.Channels, len(in.Samples))
+	}
+}
+
+func TestDecodeTruncatedWAV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeWAV(&buf, WAV{Rate: 8000, Channels: 1, Samples: make([]int16, 100)}); err != nil {
+		t.Fatal(err)
+	}
+	full := buf.Bytes()
+
+	// cut inside the samples, and inside the fmt chunk
+	for _, n := range []int{len(full) - 11, 30} {
+		if _, err := DecodeWAV(bytes.NewReader(full[:n])); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("cut at %d: err = %v, want unexpected EOF", n, err)
+		}
+	}
+
+	// a data chunk claiming 4 GiB fails on the missing samples instead of
+	// allocating them
+	huge := bytes.Clone(full)
+	binary.LittleEndian.PutUint32(huge[40:44], 0xFFFFFFFE)
+	if _, err := DecodeWAV(bytes.NewReader(huge)); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("oversized data chunk: err = %v", err)
+	}
+
+	if _, err := DecodeWAV(bytes.NewReader([]byte("RIFF\x00\x00\x00\x00AVI "))); err == nil {
+		t.Error("decoded a non-WAVE file")
+	}
+}
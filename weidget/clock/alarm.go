@@ -0,0 +1,159 @@
+package clock
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Repeat presets; anything else in Alarm.Repeat is a cron expression.
+var repeatPresets = []string{"once", "daily", "weekdays", "weekends"}
+
+// Alarm is one entry of the alarm list, persisted in alarms.json.
+type Alarm struct {
+	Label   string    `json:"label"`
+	Hour    int       `json:"hour"`
+	Minute  int       `json:"minute"`
+	Repeat  string    `json:"repeat"` // once|daily|weekdays|weekends|"<cron expr>"
+	Enabled bool      `json:"enabled"`
+	Tone    bool      `json:"tone"`
+	Created time.Time `json:"created"`
+	Last    time.Time `json:"last,omitempty"`   // last time it rang
+	Snooze  time.Time `json:"snooze,omitempty"` // snoozed until
+}
+
+func NewAlarm(now time.Time, tone bool) Alarm {
+	next := now.Truncate(time.Hour).Add(time.Hour)
+	return Alarm{
+		Label:   "alarm",
+		Hour:    next.Hour(),
+		Minute:  0,
+		Repeat:  "once",
+		Enabled: true,
+		Tone:    tone,
+		Created: now,
+	}
+}
+
+// cron turns the alarm's schedule into a cron spec.
+func (a Alarm) cron() (*cronSpec, error) {
+	switch a.Repeat {
+	case "", "once", "daily":
+		return parseCron(fmt.Sprintf("%d %d * * *", a.Minute, a.Hour))
+	case "weekdays":
+		return parseCron(fmt.Sprintf("%d %d * * 1-5", a.Minute, a.Hour))
+	case "weekends":
+		return parseCron(fmt.Sprintf("%d %d * * 0,6", a.Minute, a.Hour))
+	}
+	return parseCron(a.Repeat)
+}
+
+// Due returns when the alarm next rings: the snooze time if snoozed,
+// otherwise the first scheduled time after it last rang (or was set).
+// One-shot alarms are disabled once they rang, so they only come due once.
+func (a Alarm) Due() (time.Time, error) {
+	if !a.Snooze.IsZero() {
+		return a.Snooze, nil
+	}
+	spec, err := a.cron()
+	if err != nil {
+		return time.Time{}, err
+	}
+	from := a.Created
+	if a.Last.After(from) {
+		from = a.Last
+	}
+	due, ok := spec.Next(from)
+	if !ok {
+		return time.Time{}, fmt.Errorf("alarm %q never rings", a.Label)
+	}
+	return due, nil
+}
+
+func (a Alarm) Custom() bool {
+	for _, p := range repeatPresets {
+		if a.Repeat == p {
+			return false
+		}
+	}
+	return a.Repeat != ""
+}
+
+// Schedule is the human readable time + repeat column.
+func (a Alarm) Schedule() string {
+	if a.Custom() {
+		return "cron " + a.Repeat
+	}
+	return fmt.Sprintf("%02d:%02d %s", a.Hour, a.Minute, a.Repeat)
+}
+
+// missedGrace is how late an alarm may still ring, e.g. after the
+// dashboard was closed over its time; older ones are skipped quietly.
+const missedGrace = 5 * time.Minute
+
+// Check reports whether a rings at now, and whether its state changed and
+// needs saving. Alarms found long overdue are caught up without ringing.
+func (a *Alarm) Check(now time.Time) (ring, changed bool) {
+	if !a.Enabled {
+		return false, false
+	}
+	due, err := a.Due()
+	if err != nil || now.Before(due) {
+		return false, false
+	}
+	a.Last, a.Snooze = now, time.Time{}
+	if a.Repeat == "once" || a.Repeat == "" {
+		a.Enabled = false
+	}
+	return now.Sub(due) <= missedGrace, true
+}
+
+// editable fields of an alarm, in cursor order
+type alarmField int
+
+const (
+	fieldHour alarmField = iota
+	fieldMinute
+	fieldRepeat
+	fieldTone
+	fieldLabel
+	fieldCount
+)
+
+func (f alarmField) String() string {
+	return [...]string{"hour", "minute", "repeat", "tone", "label"}[f]
+}
+
+// adjust changes a field by one step up (+1) or down (-1).
+func (a *Alarm) adjust(f alarmField, dir int) {
+	switch f {
+	case fieldHour:
+		a.Hour = (a.Hour + dir + 24) % 24
+	case fieldMinute:
+		a.Minute = (a.Minute + dir + 60) % 60
+	case fieldRepeat:
+		i := 0
+		for j, p := range repeatPresets {
+			if a.Repeat == p {
+				i = j
+			}
+		}
+		a.Repeat = repeatPresets[(i+dir+len(repeatPresets))%len(repeatPresets)]
+	case fieldTone:
+		a.Tone = !a.Tone
+	}
+}
+
+func (a *Alarm) typeLabel(key string) {
+	switch {
+	case key == "backspace":
+		if r := []rune(a.Label); len(r) > 0 {
+			a.Label = string(r[:len(r)-1])
+		}
+	case key == " ":
+		a.Label += " "
+	case len([]rune(key)) == 1:
+		a.Label += key
+	}
+	a.Label = strings.TrimLeft(a.Label, " ")
+}
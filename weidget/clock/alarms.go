@@ -0,0 +1,236 @@
+package clock
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/antiloger/termctlr/message"
+	"github.com/antiloger/termctlr/theme"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// alarmFile is the persisted alarm list.
+type alarmFile struct {
+	Alarms []Alarm `json:"alarms"`
+}
+
+func (C ClockModel) saveAlarms() tea.Cmd {
+	return saveJSON(C.alarmPath, alarmFile{Alarms: append([]Alarm(nil), C.alarms...)})
+}
+
+// checkAlarms rings the first due alarm. While one is ringing the others
+// wait their turn on the next ticks.
+func (C *ClockModel) checkAlarms() tea.Cmd {
+	if C.ringing >= 0 {
+		return nil
+	}
+	var cmds []tea.Cmd
+	changed := false
+	for i := range C.alarms {
+		ring, ch := C.alarms[i].Check(C.ct)
+		changed = changed || ch
+		if ring {
+			C.ringing = i
+			a := C.alarms[i]
+			cmds = append(cmds, func() tea.Msg {
+				return message.AlarmMsg{Label: a.Label, Tone: a.Tone}
+			})
+			break
+		}
+	}
+	if changed {
+		cmds = append(cmds, C.saveAlarms())
+	}
+	return tea.Batch(cmds...)
+}
+
+// handleRingingKey is active while the banner is up: s snoozes, anything
+// in the dismiss set stops the alarm. Other keys are swallowed.
+func (C ClockModel) handleRingingKey(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "s":
+		C.alarms[C.ringing].Snooze = C.ct.Add(C.snooze)
+		C.alarms[C.ringing].Enabled = true
+	case "d", "enter", "esc", " ":
+	default:
+		return C, nil
+	}
+	C.ringing = -1
+	return C, tea.Batch(C.saveAlarms(), func() tea.Msg { return message.AlarmStopMsg{} })
+}
+
+// Overlay shows the banner of a ringing alarm over the whole dashboard.
+func (C ClockModel) Overlay() (string, bool) {
+	if C.ringing < 0 {
+		return "", false
+	}
+	t := theme.Current()
+	a := C.alarms[C.ringing]
+	body := lipgloss.JoinVertical(lipgloss.Center,
+		t.Style(theme.Alert).Bold(true).Render("⏰  "+a.Label),
+		"",
+		t.Style(theme.Alert).Bold(true).Render(C.renderBig(fmt.Sprintf("%02d:%02d", a.Hour, a.Minute))),
+		"",
+		t.Style(theme.Muted).Render(fmt.Sprintf("s snooze %s · d dismiss", C.snooze)),
+	)
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder(), true).
+		BorderForeground(t.Color(theme.Alert)).
+		Padding(1, 4).
+		Render(body), true
+}
+
+// CapturesKeys keeps every key, tab and the digits included, in the alarm
+// editor while it is open, so the label can be typed and tab moves fields.
+func (C ClockModel) CapturesKeys() bool {
+	return C.mode == AlarmMode && C.editing
+}
+
+func (C ClockModel) handleAlarmKey(key string) (tea.Model, tea.Cmd) {
+	if C.editing {
+		return C.handleAlarmEditKey(key)
+	}
+	switch key {
+	case "up", "k":
+		if len(C.alarms) > 0 {
+			C.alarmSel = (C.alarmSel - 1 + len(C.alarms)) % len(C.alarms)
+		}
+		return C, nil
+	case "down", "j":
+		if len(C.alarms) > 0 {
+			C.alarmSel = (C.alarmSel + 1) % len(C.alarms)
+		}
+		return C, nil
+	case "n":
+		C.alarms = append(C.alarms, NewAlarm(C.ct, C.defaultTone))
+		C.alarmSel = len(C.alarms) - 1
+		C.editing, C.field = true, fieldHour
+		return C, C.saveAlarms()
+	}
+	if len(C.alarms) == 0 {
+		return C, nil
+	}
+
+	a := &C.alarms[C.alarmSel]
+	switch key {
+	case "enter", "e":
+		C.editing, C.field = true, fieldHour
+		return C, nil
+	case " ":
+		a.Enabled = !a.Enabled
+		a.Created, a.Snooze = C.ct, time.Time{}
+	case "d", "delete":
+		C.alarms = append(C.alarms[:C.alarmSel:C.alarmSel], C.alarms[C.alarmSel+1:]...)
+		C.alarmSel = max(min(C.alarmSel, len(C.alarms)-1), 0)
+	default:
+		return C, nil
+	}
+	return C, C.saveAlarms()
+}
+
+// handleAlarmEditKey edits the selected alarm in place. ←/→ or tab pick a field,
+// ↑/↓ change it, typing edits the label, enter or esc finishes.
+func (C ClockModel) handleAlarmEditKey(key string) (tea.Model, tea.Cmd) {
+	a := &C.alarms[C.alarmSel]
+	switch key {
+	case "enter", "esc":
+		C.editing = false
+		// a changed schedule counts from now, not from when it last rang
+		a.Created, a.Snooze, a.Enabled = C.ct, time.Time{}, true
+		return C, C.saveAlarms()
+	case "left":
+		C.field = (C.field - 1 + fieldCount) % fieldCount
+	case "right", "tab":
+		C.field = (C.field + 1) % fieldCount
+	case "up", "+":
+		a.adjust(C.field, 1)
+	case "down", "-":
+		a.adjust(C.field, -1)
+	default:
+		if C.field == fieldLabel {
+			a.typeLabel(key)
+		}
+	}
+	return C, nil
+}
+
+func (C ClockModel) alarmView() (string, string) {
+	t := theme.Current()
+
+	// big digits show the selected alarm
+	var left string
+	if len(C.alarms) == 0 {
+		left = t.Style(theme.Muted).Bold(true).Render(C.renderBig("--:--"))
+	} else {
+		a := C.alarms[C.alarmSel]
+		role := theme.Muted
+		if a.Enabled {
+			role = theme.Accent
+		}
+		left = t.Style(role).Bold(true).Render(C.renderBig(fmt.Sprintf("%02d:%02d", a.Hour, a.Minute)))
+	}
+
+	if len(C.alarms) == 0 {
+		return left, t.Style(theme.Muted).Render("no alarms · n new")
+	}
+
+	var lines []string
+	for i, a := range C.alarms {
+		marker := "  "
+		if i == C.alarmSel {
+			marker = "> "
+		}
+		state := t.Style(theme.Muted).Render("off")
+		if a.Enabled {
+			if due, err := a.Due(); err == nil {
+				state = t.Style(theme.GaugeLow).Render(due.Format("Mon 15:04"))
+			} else {
+				state = t.Style(theme.Alert).Render("invalid")
+			}
+		}
+		tone := " "
+		if a.Tone {
+			tone = "♪"
+		}
+		lines = append(lines, fmt.Sprintf("%s%-12s %s %s %s", marker, a.Label, t.Style(theme.Text).Render(a.Schedule()), tone, state))
+	}
+
+	if C.editing {
+		a := C.alarms[C.alarmSel]
+		var fields []string
+		for f := range fieldCount {
+			v := ""
+			switch f {
+			case fieldHour:
+				v = fmt.Sprintf("%02d", a.Hour)
+			case fieldMinute:
+				v = fmt.Sprintf("%02d", a.Minute)
+			case fieldRepeat:
+				v = a.Repeat
+			case fieldTone:
+				v = fmt.Sprintf("%v", a.Tone)
+			case fieldLabel:
+				v = a.Label + "▏"
+			}
+			style := t.Style(theme.Muted)
+			if f == C.field {
+				style = t.Style(theme.Accent).Reverse(true)
+			}
+			fields = append(fields, style.Render(f.String()+" "+v))
+		}
+		lines = append(lines, "", lipgloss.JoinHorizontal(lipgloss.Top, joinSpaced(fields)...))
+	}
+	return left, lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+func joinSpaced(parts []string) []string {
+	out := make([]string, 0, len(parts)*2)
+	for i, p := range parts {
+		if i > 0 {
+			out = append(out, " ")
+		}
+		out = append(out, p)
+	}
+	return out
+}
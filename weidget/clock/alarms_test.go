@@ -0,0 +1,73 @@
+package clock
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/antiloger/termctlr/config"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestAlarmEditorCapturesKeys(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	var m tea.Model = NewClockWidget(config.ClockConfig{})
+	key := func(k string) {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		if k == "tab" {
+			msg = tea.KeyMsg{Type: tea.KeyTab}
+		}
+		m, _ = m.Update(msg)
+	}
+	C := m.(ClockModel)
+	C.mode = AlarmMode
+	m = C
+	if C.CapturesKeys() {
+		t.Fatal("the clock captures keys without an open editor")
+	}
+
+	key("n")
+	if !m.(ClockModel).CapturesKeys() {
+		t.Fatal("the alarm editor does not capture keys, so tab never reaches it")
+	}
+	key("tab")
+	key("tab")
+	if f := m.(ClockModel).field; f != fieldRepeat {
+		t.Errorf("tab twice moved to %v, want repeat", f)
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.(ClockModel).CapturesKeys() {
+		t.Error("the clock still captures keys after enter closed the editor")
+	}
+}
+
+func TestStateAndAlarmErrorsKeptApart(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", dir)
+	os.MkdirAll(filepath.Join(dir, "termctrl"), 0o755)
+	os.WriteFile(filepath.Join(dir, "termctrl", "clock.json"), []byte("{"), 0o644)
+	os.WriteFile(filepath.Join(dir, "termctrl", "alarms.json"), []byte(`{"alarms": 3}`), 0o644)
+
+	C := NewClockWidget(config.ClockConfig{})
+	if C.stateErr == nil || C.alarmErr == nil {
+		t.Fatalf("state error %v, alarm error %v; want both", C.stateErr, C.alarmErr)
+	}
+	view := ansi.Strip(C.View())
+	if !strings.Contains(view, "state: ") || !strings.Contains(view, "alarms: ") {
+		t.Errorf("view lacks an error:\n%s", view)
+	}
+
+	// a timer save going well leaves the alarm error
+	next, _ := C.Update(stateSavedMsg{path: C.statePath})
+	C = next.(ClockModel)
+	if C.stateErr != nil || C.alarmErr == nil {
+		t.Errorf("after saving timers: state error %v, alarm error %v", C.stateErr, C.alarmErr)
+	}
+	next, _ = C.Update(stateSavedMsg{path: C.alarmPath})
+	if C = next.(ClockModel); C.alarmErr != nil {
+		t.Errorf("alarm error %v after saving alarms", C.alarmErr)
+	}
+}
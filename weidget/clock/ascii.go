@@ -70,24 +70,6 @@ var asciiColon = `
  ╹ 
   `
 
-var TestCal = `
- S M T W T F S
-              
-       ◯ ◯ ◯ ◯
- ◯ ◯ ◯ ◯ ¤ ◯ ◯
- ◯ ◯ ◯ ◯ ◯ ◯ ◯
- ◯ ◯ ◯        
-	`
-
-var TestCal2 = `
-S  M  T  W  T  F  S
-                   
-         ◯  ◯  ◯  ◯
-◯  ◯  ◯  ◯ 17  ◯  ◯
-◯  ◯  ◯  ◯  ◯  ◯  ◯
-◯  ◯  ◯            
-	`
-
 // ┏━┓┏━┓   ╻ ╻┏━┓   ┏━┓╻ ╻
 // ┃┃┃┗━┫ ╹ ┗━┫┗━┫ ╹ ╺━┫┗━┫
 // ┗━┛┗━┛ ╹   ╹┗━┛ ╹ ┗━┛  ╹
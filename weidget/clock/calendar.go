@@ -0,0 +1,143 @@
+package clock
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antiloger/termctlr/theme"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Calendar is a month view with a movable cursor (the selected day).
+type Calendar struct {
+	Cursor      time.Time    // selected day; its month is the one shown
+	WeekStart   time.Weekday // time.Monday or time.Sunday
+	WeekNumbers bool         // show ISO week numbers in the first column
+	Events      []Event
+}
+
+func NewCalendar(now time.Time, weekStart time.Weekday, weekNumbers bool) Calendar {
+	return Calendar{
+		Cursor:      dayOf(now),
+		WeekStart:   weekStart,
+		WeekNumbers: weekNumbers,
+	}
+}
+
+// Move shifts the cursor by days, MoveMonth by whole months (clamping the
+// day so Jan 31 + 1 month is Feb 28/29, not Mar 3).
+func (c *Calendar) Move(days int) {
+	c.Cursor = c.Cursor.AddDate(0, 0, days)
+}
+
+func (c *Calendar) MoveMonth(months int) {
+	y, m, d := c.Cursor.Date()
+	first := time.Date(y, m+time.Month(months), 1, 0, 0, 0, 0, c.Cursor.Location())
+	d = min(d, daysIn(first))
+	c.Cursor = time.Date(first.Year(), first.Month(), d, 0, 0, 0, 0, c.Cursor.Location())
+}
+
+func (c Calendar) View(today time.Time) string {
+	t := theme.Current()
+	muted := t.Style(theme.Muted)
+	todayStyle := t.Style(theme.Accent).Bold(true).Reverse(true)
+	cursorStyle := t.Style(theme.Text).Bold(true).Underline(true)
+	eventStyle := t.Style(theme.GaugeMed)
+
+	first := time.Date(c.Cursor.Year(), c.Cursor.Month(), 1, 0, 0, 0, 0, c.Cursor.Location())
+	lead := (int(first.Weekday()) - int(c.WeekStart) + 7) % 7
+	start := first.AddDate(0, 0, -lead)
+
+	var lines []string
+
+	// weekday header
+	var head strings.Builder
+	if c.WeekNumbers {
+		head.WriteString("   ")
+	}
+	for i := range 7 {
+		if i > 0 {
+			head.WriteString(" ")
+		}
+		head.WriteString(((c.WeekStart + time.Weekday(i)) % 7).String()[:2])
+	}
+	lines = append(lines, muted.Render(head.String()))
+
+	for week := start; week.Before(first.AddDate(0, 1, 0)); week = week.AddDate(0, 0, 7) {
+		var row strings.Builder
+		if c.WeekNumbers {
+			// ISO weeks start on Monday; look at the row's Monday either way
+			monday := week.AddDate(0, 0, (int(time.Monday)-int(week.Weekday())+7)%7)
+			_, wk := monday.ISOWeek()
+			row.WriteString(muted.Render(fmt.Sprintf("%2d ", wk)))
+		}
+		for i := range 7 {
+			if i > 0 {
+				row.WriteString(" ")
+			}
+			day := week.AddDate(0, 0, i)
+			if day.Month() != first.Month() {
+				row.WriteString("  ")
+				continue
+			}
+			cell := fmt.Sprintf("%2d", day.Day())
+			switch {
+			case sameDay(day, today):
+				cell = todayStyle.Render(cell)
+			case sameDay(day, c.Cursor):
+				cell = cursorStyle.Render(cell)
+			case len(EventsOn(c.Events, day)) > 0:
+				cell = eventStyle.Render(cell)
+			default:
+				cell = t.Style(theme.Text).Render(cell)
+			}
+			row.WriteString(cell)
+		}
+		lines = append(lines, row.String())
+	}
+
+	title := t.Style(theme.Accent).Bold(true).Render(first.Format("January 2006"))
+	grid := strings.Join(lines, "\n")
+	return lipgloss.JoinVertical(lipgloss.Center, title, grid)
+}
+
+// Agenda lists the events on the cursor day, at most limit entries.
+func (c Calendar) Agenda(limit int) string {
+	t := theme.Current()
+	events := EventsOn(c.Events, c.Cursor)
+
+	header := t.Style(theme.Muted).Render(c.Cursor.Format("Mon 02 Jan"))
+	if len(events) == 0 {
+		return header + "\n" + t.Style(theme.Muted).Render("no events")
+	}
+
+	lines := []string{header}
+	for i, e := range events {
+		if i == limit {
+			lines = append(lines, t.Style(theme.Muted).Render(fmt.Sprintf("+%d more", len(events)-limit)))
+			break
+		}
+		when := "all-day"
+		if !e.AllDay {
+			when = e.Start.Local().Format("15:04")
+		}
+		lines = append(lines, t.Style(theme.Accent).Render(fmt.Sprintf("%-7s", when))+" "+t.Style(theme.Text).Render(e.Summary))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func dayOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+func daysIn(month time.Time) int {
+	return time.Date(month.Year(), month.Month()+1, 0, 0, 0, 0, 0, month.Location()).Day()
+}
@@ -0,0 +1,98 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+// bits sets the given values.
+func bits(vs ...int) uint64 {
+	var b uint64
+	for _, v := range vs {
+		b |= 1 << v
+	}
+	return b
+}
+
+func TestCronField(t *testing.T) {
+	for _, c := range []struct {
+		field  string
+		lo, hi int
+		want   uint64
+	}{
+		{"*", 0, 6, bits(0, 1, 2, 3, 4, 5, 6)},
+		{"5", 0, 59, bits(5)},
+		{"1,3,5", 0, 7, bits(1, 3, 5)},
+		{"1-5", 0, 7, bits(1, 2, 3, 4, 5)},
+		{"*/15", 0, 59, bits(0, 15, 30, 45)},
+		{"10-30/10", 0, 59, bits(10, 20, 30)},
+		{"1-12/5", 1, 12, bits(1, 6, 11)},
+		{"0,30-31,*/20", 0, 59, bits(0, 20, 30, 31, 40)},
+	} {
+		got, err := cronField(c.field, c.lo, c.hi)
+		if err != nil || got != c.want {
+			t.Errorf("cronField(%q) = %b, %v; want %b", c.field, got, err, c.want)
+		}
+	}
+	for _, bad := range []string{"", "x", "60", "5-1", "*/0", "*/x", "1-x", "-1", "1-60", "1,,2"} {
+		if _, err := cronField(bad, 0, 59); err == nil {
+			t.Errorf("cronField(%q) accepted", bad)
+		}
+	}
+}
+
+func TestParseCron(t *testing.T) {
+	c, err := parseCron("30 7 * * 7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.dow != bits(0, 7) {
+		t.Errorf("day of week 7 = %b, want Sunday as 0 and 7", c.dow)
+	}
+	for _, bad := range []string{"* * * *", "* * * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8"} {
+		if _, err := parseCron(bad); err == nil {
+			t.Errorf("parseCron(%q) accepted", bad)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// Friday 14 June 2024, 10:17:30
+	from := time.Date(2024, 6, 14, 10, 17, 30, 0, time.UTC)
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	for _, c := range []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", at(6, 14, 10, 18)},
+		{"17 10 * * *", at(6, 15, 10, 17)}, // strictly after: tomorrow
+		{"*/15 * * * *", at(6, 14, 10, 30)},
+		{"0 9-17/4 * * *", at(6, 14, 13, 0)},
+		{"30 7 * * 1-5", at(6, 17, 7, 30)}, // Monday, skipping the weekend
+		{"0 8 * * 0", at(6, 16, 8, 0)},     // Sunday
+		{"0 8 * * 7", at(6, 16, 8, 0)},     // Sunday too
+		{"0 0 1 * *", at(7, 1, 0, 0)},
+		{"0 12 1,15 * *", at(6, 15, 12, 0)},
+		{"0 0 * 12 *", at(12, 1, 0, 0)},
+		// both day fields restricted: either matches
+		{"0 6 20 * 1", at(6, 17, 6, 0)},
+		{"0 6 15 * 3", at(6, 15, 6, 0)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	} {
+		spec, err := parseCron(c.expr)
+		if err != nil {
+			t.Fatalf("%s: %v", c.expr, err)
+		}
+		got, ok := spec.Next(from)
+		if !ok || !got.Equal(c.want) {
+			t.Errorf("%s: next = %v (%v), want %v", c.expr, got, ok, c.want)
+		}
+	}
+
+	never, _ := parseCron("0 0 31 2 *")
+	if got, ok := never.Next(from); ok {
+		t.Errorf("31 February fires at %v", got)
+	}
+}
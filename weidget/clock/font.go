@@ -0,0 +1,300 @@
+package clock
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Font maps characters to equally tall blocks of lines.
+type Font struct {
+	Name     string
+	Height   int
+	Glyphs   map[rune][]string
+	Scalable bool // pixel fonts can be scaled up by repeating cells
+}
+
+// BlankColon in a text renders as a colon-wide gap (blinking colons).
+const BlankColon = '\x00'
+
+// Render draws text in the font. Unknown characters render as blanks the
+// width of a digit. scale > 1 enlarges scalable fonts.
+func (f *Font) Render(text string, scale int) string {
+	if scale < 1 || !f.Scalable {
+		scale = 1
+	}
+	rows := make([]strings.Builder, f.Height*scale)
+	for _, r := range text {
+		g := f.glyph(r)
+		for y, line := range g {
+			line = scaleLine(line, scale)
+			for k := range scale {
+				rows[y*scale+k].WriteString(line)
+			}
+		}
+	}
+	lines := make([]string, len(rows))
+	for i := range rows {
+		lines[i] = rows[i].String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Size returns the width and height of text rendered at scale.
+func (f *Font) Size(text string, scale int) (int, int) {
+	if scale < 1 || !f.Scalable {
+		scale = 1
+	}
+	w := 0
+	for _, r := range text {
+		w += ansi.StringWidth(f.glyph(r)[0])
+	}
+	return w * scale, f.Height * scale
+}
+
+func (f *Font) glyph(r rune) []string {
+	if r == BlankColon {
+		return f.Blanked(':')
+	}
+	if g, ok := f.Glyphs[r]; ok {
+		return g
+	}
+	return f.blank()
+}
+
+// blank is a space glyph as wide as "0", used for blinking colons and
+// characters the font lacks.
+func (f *Font) blank() []string {
+	w := 1
+	if g, ok := f.Glyphs['0']; ok {
+		w = ansi.StringWidth(g[0])
+	}
+	lines := make([]string, f.Height)
+	for i := range lines {
+		lines[i] = strings.Repeat(" ", w)
+	}
+	return lines
+}
+
+// Blanked returns the glyph for r replaced by spaces of the same width.
+func (f *Font) Blanked(r rune) []string {
+	g, ok := f.Glyphs[r]
+	if !ok {
+		return f.blank()
+	}
+	out := make([]string, len(g))
+	for i, line := range g {
+		out[i] = strings.Repeat(" ", ansi.StringWidth(line))
+	}
+	return out
+}
+
+func scaleLine(line string, scale int) string {
+	if scale == 1 {
+		return line
+	}
+	var b strings.Builder
+	for _, r := range line {
+		b.WriteString(strings.Repeat(string(r), scale))
+	}
+	return b.String()
+}
+
+// ── Built-in fonts ────────────────────────────────────────────────────────────
+
+var builtinFonts = map[string]func() *Font{
+	"box":     boxFont,
+	"block":   blockFont,
+	"7seg":    sevenSegFont,
+	"braille": brailleFont,
+}
+
+// FontNames lists the built-in fonts in cycling order.
+func FontNames() []string {
+	return []string{"box", "block", "7seg", "braille"}
+}
+
+// LoadFont returns a built-in font by name, or reads a FIGlet font when
+// name is a path to a .flf file.
+func LoadFont(name string) (*Font, error) {
+	if f, ok := builtinFonts[name]; ok {
+		return f(), nil
+	}
+	if strings.HasSuffix(name, ".flf") {
+		return LoadFIGlet(name)
+	}
+	return nil, fmt.Errorf("unknown font %q", name)
+}
+
+// boxFont is the original box-drawing font (asciiDigits / asciiColon).
+func boxFont() *Font {
+	f := &Font{Name: "box", Height: 3, Glyphs: map[rune][]string{}}
+	trim := func(art string) []string {
+		lines := strings.Split(strings.TrimPrefix(art, "\n"), "\n")
+		return lines[:3]
+	}
+	for i, d := range asciiDigits {
+		f.Glyphs[rune('0'+i)] = trim(d)
+	}
+	f.Glyphs[':'] = trim(asciiColon)
+	return f
+}
+
+// pixel bitmaps shared by the block and braille fonts, 3x5 each
+var pixelDigits = map[rune][]string{
+	'0': {"###", "# #", "# #", "# #", "###"},
+	'1': {"## ", " # ", " # ", " # ", "###"},
+	'2': {"###", "  #", "###", "#  ", "###"},
+	'3': {"###", "  #", "###", "  #", "###"},
+	'4': {"# #", "# #", "###", "  #", "  #"},
+	'5': {"###", "#  ", "###", "  #", "###"},
+	'6': {"###", "#  ", "###", "# #", "###"},
+	'7': {"###", "  #", "  #", "  #", "  #"},
+	'8': {"###", "# #", "###", "# #", "###"},
+	'9': {"###", "# #", "###", "  #", "###"},
+	':': {" ", "#", " ", "#", " "},
+}
+
+func blockFont() *Font {
+	f := &Font{Name: "block", Height: 5, Glyphs: map[rune][]string{}, Scalable: true}
+	for r, bitmap := range pixelDigits {
+		g := make([]string, len(bitmap))
+		for i, row := range bitmap {
+			g[i] = strings.NewReplacer("#", "█").Replace(row) + " "
+		}
+		f.Glyphs[r] = g
+	}
+	return f
+}
+
+func sevenSegFont() *Font {
+	return &Font{Name: "7seg", Height: 3, Glyphs: map[rune][]string{
+		'0': {" _ ", "| |", "|_|"},
+		'1': {"   ", "  |", "  |"},
+		'2': {" _ ", " _|", "|_ "},
+		'3': {" _ ", " _|", " _|"},
+		'4': {"   ", "|_|", "  |"},
+		'5': {" _ ", "|_ ", " _|"},
+		'6': {" _ ", "|_ ", "|_|"},
+		'7': {" _ ", "  |", "  |"},
+		'8': {" _ ", "|_|", "|_|"},
+		'9': {" _ ", "|_|", " _|"},
+		':': {" ", ".", "."},
+	}}
+}
+
+// brailleFont packs the 3x5 pixel digits into 2x2 braille cells.
+func brailleFont() *Font {
+	f := &Font{Name: "braille", Height: 2, Glyphs: map[rune][]string{}}
+	for r, bitmap := range pixelDigits {
+		f.Glyphs[r] = toBraille(bitmap)
+	}
+	return f
+}
+
+func toBraille(bitmap []string) []string {
+	w := 0
+	for _, row := range bitmap {
+		w = max(w, len(row))
+	}
+	// a 3 pixel digit gets a 4th, empty column of dots as spacing
+	cols := (w + 1) / 2
+	rows := (len(bitmap) + 3) / 4
+	out := make([]string, rows)
+	for cy := range rows {
+		var b strings.Builder
+		for cx := range cols {
+			var dots rune
+			for y := range 4 {
+				for x := range 2 {
+					py, px := cy*4+y, cx*2+x
+					if py < len(bitmap) && px < len(bitmap[py]) && bitmap[py][px] == '#' {
+						dots |= brailleDots[y][x]
+					}
+				}
+			}
+			b.WriteRune(0x2800 + dots)
+		}
+		out[cy] = b.String()
+	}
+	return out
+}
+
+// braille dot bits indexed by [y][x] inside a 2x4 cell
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// ── FIGlet ────────────────────────────────────────────────────────────────────
+
+// LoadFIGlet reads a FIGlet (.flf) font. Only the required ASCII range
+// (32–126) is loaded; smushing rules are ignored and glyphs are joined at
+// full width, which is what the digits need.
+func LoadFIGlet(path string) (*Font, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sc := bufio.NewScanner(file)
+	if !sc.Scan() {
+		return nil, fmt.Errorf("%s: empty font", path)
+	}
+	header := sc.Text()
+	if !strings.HasPrefix(header, "flf2a") || len(header) < 6 {
+		return nil, fmt.Errorf("%s: not a FIGlet font", path)
+	}
+	hardblank := string(header[5])
+	fields := strings.Fields(header[6:])
+	if len(fields) < 5 {
+		return nil, fmt.Errorf("%s: bad header", path)
+	}
+	height, err1 := strconv.Atoi(fields[0])
+	comments, err2 := strconv.Atoi(fields[4])
+	if err1 != nil || err2 != nil || height < 1 {
+		return nil, fmt.Errorf("%s: bad header", path)
+	}
+	for range comments {
+		sc.Scan()
+	}
+
+	f := &Font{Name: path, Height: height, Glyphs: map[rune][]string{}}
+	for code := rune(32); code <= 126; code++ {
+		g := make([]string, height)
+		for i := range height {
+			if !sc.Scan() {
+				return nil, fmt.Errorf("%s: truncated at %q", path, code)
+			}
+			line := sc.Text()
+			if line != "" {
+				// every line ends with an endmark and a glyph's last
+				// line with two; the same character may be drawn
+				// before them, so strip exactly those
+				end := line[len(line)-1:]
+				line = strings.TrimSuffix(line, end)
+				if i == height-1 {
+					line = strings.TrimSuffix(line, end)
+				}
+			}
+			g[i] = strings.ReplaceAll(line, hardblank, " ")
+		}
+		// glyph lines may differ in width; pad to the widest
+		w := 0
+		for _, l := range g {
+			w = max(w, ansi.StringWidth(l))
+		}
+		for i, l := range g {
+			g[i] = l + strings.Repeat(" ", w-ansi.StringWidth(l))
+		}
+		f.Glyphs[code] = g
+	}
+	return f, sc.Err()
+}
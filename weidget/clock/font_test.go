@@ -0,0 +1,39 @@
+package clock
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadFIGletKeepsEndmarkInGlyphs(t *testing.T) {
+	// a two line font where each glyph is its character doubled, so the
+	// glyph for @ ends in the same character as the endmarks
+	var b strings.Builder
+	b.WriteString("flf2a$ 2 1 4 0 1\nthe one comment line\n")
+	for c := rune(32); c <= 126; c++ {
+		s := string(c)
+		b.WriteString(s + s + "$@\n")
+		b.WriteString(s + "$" + s + "@@\n")
+	}
+	path := filepath.Join(t.TempDir(), "test.flf")
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := LoadFIGlet(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for c, want := range map[rune][]string{
+		'@': {"@@ ", "@ @"},
+		'A': {"AA ", "A A"},
+		' ': {"   ", "   "},
+	} {
+		got := f.Glyphs[c]
+		if len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+			t.Errorf("glyph %q = %q, want %q", c, got, want)
+		}
+	}
+}
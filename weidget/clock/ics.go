@@ -0,0 +1,382 @@
+package clock
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a VEVENT from an .ics file. Recurring events keep their rule and
+// are expanded per day by EventsOn.
+type Event struct {
+	Summary  string
+	Location string
+	Start    time.Time
+	End      time.Time
+	AllDay   bool
+	rule     *rrule
+	exdates  []time.Time // occurrences removed from the rule
+}
+
+// rrule is the subset of RFC 5545 RRULE that calendars commonly export:
+// FREQ, INTERVAL, COUNT, UNTIL and BYDAY (weekly).
+type rrule struct {
+	freq     string
+	interval int
+	count    int
+	until    time.Time
+	byDay    []time.Weekday
+}
+
+// LoadICS reads events from .ics files. Directories are scanned for *.ics.
+// Unreadable files are skipped and reported in the returned error.
+func LoadICS(paths ...string) ([]Event, error) {
+	var events []Event
+	var errs []string
+	for _, p := range paths {
+		files := []string{p}
+		if info, err := os.Stat(p); err == nil && info.IsDir() {
+			files, _ = filepath.Glob(filepath.Join(p, "*.ics"))
+		}
+		for _, f := range files {
+			evs, err := loadICSFile(f)
+			if err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			events = append(events, evs...)
+		}
+	}
+	if len(errs) > 0 {
+		return events, fmt.Errorf("ics: %s", strings.Join(errs, "; "))
+	}
+	return events, nil
+}
+
+func loadICSFile(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	evs, err := ParseICS(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return evs, nil
+}
+
+// ParseICS extracts the VEVENTs of an iCalendar stream.
+func ParseICS(r io.Reader) ([]Event, error) {
+	var (
+		events []Event
+		cur    *Event
+		skip   bool // cancelled, or no usable start
+	)
+
+	lines, err := unfold(r)
+	for _, line := range lines {
+		name, params, value := splitProperty(line)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			cur, skip = &Event{}, false
+			continue
+		case name == "END" && value == "VEVENT":
+			if cur != nil && !skip && !cur.Start.IsZero() {
+				if cur.End.IsZero() {
+					cur.End = cur.Start
+					if cur.AllDay {
+						cur.End = cur.Start.AddDate(0, 0, 1)
+					}
+				}
+				events = append(events, *cur)
+			}
+			cur = nil
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+
+		switch name {
+		case "SUMMARY":
+			cur.Summary = unescape(value)
+		case "LOCATION":
+			cur.Location = unescape(value)
+		case "STATUS":
+			skip = skip || value == "CANCELLED"
+		case "DTSTART":
+			// an event without a usable start is dropped, not the file
+			t, allDay, err := parseICSTime(value, params)
+			skip = skip || err != nil
+			cur.Start, cur.AllDay = t, allDay
+		case "DTEND":
+			if t, _, err := parseICSTime(value, params); err == nil {
+				cur.End = t
+			}
+		case "RRULE":
+			// rules we can't expand (BYDAY=2MO, BYSETPOS, …) leave the
+			// first occurrence, like a calendar without recurrence support
+			if rule, err := parseRRule(value); err == nil {
+				cur.rule = rule
+			}
+		case "EXDATE":
+			for _, v := range strings.Split(value, ",") {
+				if t, _, err := parseICSTime(v, params); err == nil {
+					cur.exdates = append(cur.exdates, t)
+				}
+			}
+		}
+	}
+	return events, err
+}
+
+// unfold joins continuation lines (RFC 5545 3.1: CRLF followed by a space
+// or tab continues the previous line).
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// splitProperty splits `NAME;P1=a;P2=b:value`.
+func splitProperty(line string) (name string, params map[string]string, value string) {
+	inQuote := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuote = !inQuote
+		}
+		if r == ':' && !inQuote {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return "", nil, ""
+	}
+	head, value := line[:colon], line[colon+1:]
+	parts := strings.Split(head, ";")
+	params = make(map[string]string)
+	for _, p := range parts[1:] {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, value
+}
+
+func unescape(s string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
+
+func parseICSTime(value string, params map[string]string) (time.Time, bool, error) {
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, time.Local)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	loc := time.Local
+	if tz := params["TZID"]; tz != "" {
+		if l, err := time.LoadLocation(tz); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+var icsWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+func parseRRule(value string) (*rrule, error) {
+	r := &rrule{interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		k, v, _ := strings.Cut(part, "=")
+		switch k {
+		case "FREQ":
+			r.freq = v
+		case "INTERVAL":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("rrule: bad interval %q", v)
+			}
+			r.interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("rrule: bad count %q", v)
+			}
+			r.count = n
+		case "UNTIL":
+			t, _, err := parseICSTime(v, nil)
+			if err != nil {
+				return nil, fmt.Errorf("rrule: bad until %q", v)
+			}
+			r.until = t
+		case "BYDAY":
+			for _, d := range strings.Split(v, ",") {
+				// positional forms like 2MO are monthly rules we don't expand
+				wd, ok := icsWeekdays[d]
+				if !ok {
+					return nil, fmt.Errorf("rrule: unsupported BYDAY %q", d)
+				}
+				r.byDay = append(r.byDay, wd)
+			}
+		default:
+			// BYMONTHDAY, BYSETPOS and the like would change the days;
+			// ignoring them would show the event on the wrong ones
+			if strings.HasPrefix(k, "BY") {
+				return nil, fmt.Errorf("rrule: unsupported %s", k)
+			}
+		}
+	}
+	switch r.freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return nil, fmt.Errorf("rrule: unsupported FREQ %q", r.freq)
+	}
+	if len(r.byDay) > 0 && r.freq != "WEEKLY" {
+		// only weekly rules are expanded by weekday
+		return nil, fmt.Errorf("rrule: unsupported BYDAY with FREQ=%s", r.freq)
+	}
+	return r, nil
+}
+
+// EventsOn returns the events happening on day, recurring ones included,
+// all-day events first and the rest by start time. Recurring events come
+// back with Start/End moved to that day's occurrence.
+func EventsOn(events []Event, day time.Time) []Event {
+	var out []Event
+	for _, e := range events {
+		if occ, ok := e.occurrenceOn(day); ok {
+			out = append(out, occ)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].AllDay != out[j].AllDay {
+			return out[i].AllDay
+		}
+		return out[i].Start.Before(out[j].Start)
+	})
+	return out
+}
+
+func (e Event) occurrenceOn(day time.Time) (Event, bool) {
+	start := e.Start.In(day.Location())
+	if e.rule == nil {
+		end := e.End.In(day.Location())
+		first, last := dayOf(start), dayOf(end)
+		// DTEND is exclusive; an event ending at midnight doesn't touch that day
+		if end.Equal(last) && last.After(first) {
+			last = last.AddDate(0, 0, -1)
+		}
+		d := dayOf(day)
+		return e, !d.Before(first) && !d.After(last)
+	}
+
+	if !e.rule.matches(start, day) || e.excluded(day) {
+		return e, false
+	}
+	occ := e
+	y, m, d := day.Date()
+	occ.Start = time.Date(y, m, d, start.Hour(), start.Minute(), start.Second(), 0, day.Location())
+	occ.End = occ.Start.Add(e.End.Sub(e.Start))
+	return occ, true
+}
+
+// excluded reports whether an EXDATE removes the occurrence on day.
+func (e Event) excluded(day time.Time) bool {
+	d := dayOf(day)
+	for _, x := range e.exdates {
+		if dayOf(x.In(day.Location())).Equal(d) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether day is an occurrence of a rule anchored at start.
+func (r *rrule) matches(start, day time.Time) bool {
+	diff := daysBetween(start, day)
+	if diff < 0 {
+		return false
+	}
+	if !r.until.IsZero() && dayOf(day).After(r.until) {
+		return false
+	}
+	if !r.candidate(start, day, diff) {
+		return false
+	}
+	if r.count == 0 {
+		return true
+	}
+	// COUNT: walk the candidates from the start; bounded by count
+	n := 0
+	for d := 0; d <= diff; d++ {
+		if r.candidate(start, start.AddDate(0, 0, d), d) {
+			n++
+			if n > r.count {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (r *rrule) candidate(start, day time.Time, diff int) bool {
+	switch r.freq {
+	case "DAILY":
+		return diff%r.interval == 0
+	case "WEEKLY":
+		days := r.byDay
+		if len(days) == 0 {
+			days = []time.Weekday{start.Weekday()}
+		}
+		found := false
+		for _, wd := range days {
+			found = found || wd == day.Weekday()
+		}
+		weeks := daysBetween(mondayOf(start), mondayOf(day)) / 7
+		return found && weeks%r.interval == 0
+	case "MONTHLY":
+		months := (day.Year()-start.Year())*12 + int(day.Month()-start.Month())
+		return day.Day() == start.Day() && months%r.interval == 0
+	case "YEARLY":
+		return day.Month() == start.Month() && day.Day() == start.Day() && (day.Year()-start.Year())%r.interval == 0
+	}
+	return false
+}
+
+// daysBetween counts calendar days from a to b, ignoring DST shifts.
+func daysBetween(a, b time.Time) int {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	da := time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)
+	db := time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC)
+	return int(db.Sub(da).Hours() / 24)
+}
+
+func mondayOf(t time.Time) time.Time {
+	return dayOf(t).AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+}
@@ -0,0 +1,150 @@
+package clock
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testCalendar = `BEGIN:VCALENDAR
+BEGIN:VEVENT
+SUMMARY:Standup
+DTSTART:20240603T090000
+DTEND:20240603T091500
+RRULE:FREQ=WEEKLY;BYDAY=MO,WE
+EXDATE:20240610T090000,20240612T090000
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Board meeting
+DTSTART:20240610T140000
+DTEND:20240610T150000
+RRULE:FREQ=MONTHLY;BYDAY=2MO
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Payday
+DTSTART;VALUE=DATE:20240628
+RRULE:FREQ=MONTHLY;BYDAY=-1FR
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Garbled
+DTSTART:not a time
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Holiday
+DTSTART;VALUE=DATE:20240701
+RRULE:FREQ=DAILY;COUNT=5
+EXDATE;VALUE=DATE:20240703
+END:VEVENT
+END:VCALENDAR
+`
+
+func summaries(events []Event) string {
+	var s []string
+	for _, e := range events {
+		s = append(s, e.Summary)
+	}
+	return strings.Join(s, ",")
+}
+
+func TestLoadICSUnsupportedRule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "work.ics")
+	if err := os.WriteFile(path, []byte(testCalendar), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	events, err := LoadICS(path)
+	if err != nil {
+		t.Fatalf("the file failed: %v", err)
+	}
+	if got := summaries(events); got != "Standup,Board meeting,Payday,Holiday" {
+		t.Fatalf("events = %s", got)
+	}
+
+	day := func(d int) time.Time { return time.Date(2024, 6, d, 12, 0, 0, 0, time.Local) }
+	tests := []struct {
+		day  time.Time
+		want string
+	}{
+		{day(10), "Board meeting"}, // standup excluded; the positional rule shows its first instance
+		{day(12), ""},              // excluded
+		{day(17), "Standup"},       // the weekly rule still runs
+		{day(19), "Standup"},
+		{day(28), "Payday"},
+		{time.Date(2024, 7, 8, 12, 0, 0, 0, time.Local), "Standup"}, // no second board meeting
+		{time.Date(2024, 7, 26, 12, 0, 0, 0, time.Local), ""},       // nor payday
+		{time.Date(2024, 7, 2, 12, 0, 0, 0, time.Local), "Holiday"},
+		{time.Date(2024, 7, 3, 12, 0, 0, 0, time.Local), "Standup"}, // all-day EXDATE
+		{time.Date(2024, 7, 5, 12, 0, 0, 0, time.Local), "Holiday"},
+		{time.Date(2024, 7, 6, 12, 0, 0, 0, time.Local), ""}, // COUNT counts the excluded day
+	}
+	for _, tt := range tests {
+		if got := summaries(EventsOn(events, tt.day)); got != tt.want {
+			t.Errorf("%s: %q, want %q", tt.day.Format("Mon Jan 2"), got, tt.want)
+		}
+	}
+}
+
+func TestParseRRule(t *testing.T) {
+	for _, rule := range []string{
+		"FREQ=DAILY",
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;WKST=MO",
+		"FREQ=MONTHLY;COUNT=12",
+		"FREQ=YEARLY;UNTIL=20301231T000000Z",
+	} {
+		if _, err := parseRRule(rule); err != nil {
+			t.Errorf("%s: %v", rule, err)
+		}
+	}
+	for _, rule := range []string{
+		"FREQ=HOURLY",
+		"FREQ=MONTHLY;BYDAY=MO", // would be taken for the start's day of the month
+		"FREQ=DAILY;BYDAY=MO,TU",
+		"FREQ=YEARLY;BYDAY=SU",
+		"FREQ=MONTHLY;BYDAY=2MO",
+		"FREQ=MONTHLY;BYMONTHDAY=-1",
+		"FREQ=YEARLY;BYMONTH=3",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=-2",
+		"FREQ=DAILY;INTERVAL=0",
+	} {
+		if _, err := parseRRule(rule); err == nil {
+			t.Errorf("%s accepted", rule)
+		}
+	}
+}
+
+func TestMonthlyByDayShowsFirstOnly(t *testing.T) {
+	events, err := ParseICS(strings.NewReader(`BEGIN:VCALENDAR
+BEGIN:VEVENT
+SUMMARY:Mondays
+DTSTART:20240603T090000
+RRULE:FREQ=MONTHLY;BYDAY=MO
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Never
+DTSTART:20240604T090000
+RRULE:FREQ=DAILY;COUNT=0
+END:VEVENT
+END:VCALENDAR
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		day  int
+		want string
+	}{
+		{3, "Mondays"},
+		{4, "Never"},
+		{5, ""},
+		{10, ""}, // not expanded, rather than guessed
+	} {
+		if got := summaries(EventsOn(events, time.Date(2024, 6, c.day, 12, 0, 0, 0, time.Local))); got != c.want {
+			t.Errorf("June %d: %q, want %q", c.day, got, c.want)
+		}
+	}
+	if got := summaries(EventsOn(events, time.Date(2024, 7, 3, 12, 0, 0, 0, time.Local))); got != "" {
+		t.Errorf("July 3: %q; the start's day of the month is not a Monday rule", got)
+	}
+}
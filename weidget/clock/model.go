@@ -2,115 +2,359 @@ package clock
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 	"time"
 	"unicode/utf8"
 
+	"github.com/antiloger/termctlr/config"
+	"github.com/antiloger/termctlr/message"
+	"github.com/antiloger/termctlr/theme"
 	"github.com/antiloger/termctlr/types"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
 type ClockModel struct {
-	w    types.Position
-	size types.Position
-	ct   time.Time
+	w         types.Position
+	size      types.Position
+	ct        time.Time
+	mode      Mode
+	cal       Calendar
+	calendars []string // .ics sources
+	calErr    error
+	zones     []Zone // zones[0] is local time
+	zone      int    // zone shown in the big digits
+	zoneErr   error
+
+	stopwatch Stopwatch
+	timers    []Countdown
+	selected  int // selected countdown
+	pomodoro  Pomodoro
+	statePath string
+	stateErr  error
+
+	alarms      []Alarm
+	alarmPath   string
+	alarmErr    error // reading or writing alarmPath
+	alarmSel    int
+	editing     bool // editing the selected alarm
+	field       alarmField
+	ringing     int // index of the ringing alarm, -1 when quiet
+	snooze      time.Duration
+	defaultTone bool
+
+	fonts   []*Font // configured font first, then the built-ins
+	font    int
+	hour12  bool
+	seconds bool
+	blink   bool
+	fontErr error
 }
 
-func NewClockWidget() ClockModel {
-	return ClockModel{
-		ct: time.Now(),
+// eventsLoadedMsg carries the result of reading the .ics calendars.
+type eventsLoadedMsg struct {
+	events []Event
+	err    error
+}
+
+func NewClockWidget(cfg config.ClockConfig) ClockModel {
+	now := time.Now()
+	weekStart := time.Monday
+	if cfg.WeekStart == "sunday" {
+		weekStart = time.Sunday
+	}
+	calendars := make([]string, len(cfg.Calendars))
+	for i, p := range cfg.Calendars {
+		calendars[i] = config.ExpandPath(p)
 	}
+	zones, zoneErr := LoadZones(cfg.Zones)
+
+	C := ClockModel{
+		ct:        now,
+		cal:       NewCalendar(now, weekStart, cfg.WeekNumbers),
+		calendars: calendars,
+		zones:     append([]Zone{LocalZone()}, zones...),
+		zoneErr:   zoneErr,
+		statePath: filepath.Join(config.StateDir(), "clock.json"),
+	}
+
+	for _, tc := range cfg.Timers {
+		C.timers = append(C.timers, NewCountdown(tc.Name, parseDuration(tc.Duration, 5*time.Minute)))
+	}
+	p := cfg.Pomodoro
+	C.pomodoro = NewPomodoro(
+		parseDuration(p.Work, 25*time.Minute),
+		parseDuration(p.Short, 5*time.Minute),
+		parseDuration(p.Long, 15*time.Minute),
+		p.LongEvery,
+	)
+
+	C.hour12, C.seconds, C.blink = cfg.Hour12, !cfg.HideSeconds, cfg.BlinkColon
+	C.fonts, C.fontErr = loadFonts(cfg.Font)
+
+	st, err := loadTimerState(C.statePath)
+	C.restore(st)
+	C.stateErr = err
+
+	C.alarmPath = filepath.Join(config.StateDir(), "alarms.json")
+	C.ringing = -1
+	C.snooze = parseDuration(cfg.Snooze, 9*time.Minute)
+	C.defaultTone = cfg.AlarmTone
+	var af alarmFile
+	C.alarmErr = loadJSON(C.alarmPath, &af)
+	C.alarms = af.Alarms
+	return C
+}
+
+// loadFonts puts the configured font first in the "f" cycle. A broken
+// font file falls back to the built-ins and is reported.
+func loadFonts(name string) ([]*Font, error) {
+	var fonts []*Font
+	var err error
+	if name != "" {
+		var f *Font
+		f, err = LoadFont(config.ExpandPath(name))
+		if err == nil {
+			fonts = append(fonts, f)
+		}
+	}
+	for _, n := range FontNames() {
+		if len(fonts) > 0 && fonts[0].Name == n {
+			continue
+		}
+		fonts = append(fonts, builtinFonts[n]())
+	}
+	return fonts, err
+}
+
+func parseDuration(s string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return def
+	}
+	return d
 }
 
 func (C ClockModel) Init() tea.Cmd {
-	return nil
+	return loadEvents(C.calendars)
+}
+
+func loadEvents(paths []string) tea.Cmd {
+	if len(paths) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		events, err := LoadICS(paths...)
+		return eventsLoadedMsg{events: events, err: err}
+	}
 }
 
 func (C ClockModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
-	case tea.WindowSizeMsg:
+	case types.SizeMsg:
 		C.w.X = msg.Width
 		C.w.Y = msg.Height
 	case types.TickMsg:
 		C.ct = time.Time(msg)
-		return C, nil // Keep ticking
+		return C, tea.Batch(C.expireTimers(), C.checkAlarms())
+	case eventsLoadedMsg:
+		C.cal.Events = msg.events
+		C.calErr = msg.err
+	case stateSavedMsg:
+		if msg.path == C.alarmPath {
+			C.alarmErr = msg.err
+		} else {
+			C.stateErr = msg.err
+		}
 	case tea.KeyMsg:
-		if msg.String() == "q" || msg.String() == "ctrl+c" {
+		// a ringing alarm and alarm editing (label typing) get keys first
+		if C.ringing >= 0 {
+			return C.handleRingingKey(msg.String())
+		}
+		if C.mode == AlarmMode && C.editing {
+			return C.handleAlarmEditKey(msg.String())
+		}
+		switch msg.String() {
+		case "q", "ctrl+c":
 			return C, tea.Quit
+		case "m":
+			C.mode = (C.mode + 1) % modeCount
+			return C, nil
+		case "f":
+			C.font = (C.font + 1) % len(C.fonts)
+			return C, nil
 		}
+		return C.handleModeKey(msg.String())
 	}
 	return C, nil
 }
 
+// expireTimers completes every countdown whose deadline passed and
+// announces it. A finished pomodoro phase moves on to the next one.
+func (C *ClockModel) expireTimers() tea.Cmd {
+	var cmds []tea.Cmd
+	for i := range C.timers {
+		if C.timers[i].Expire(C.ct) {
+			cmds = append(cmds, timerDone(C.timers[i].Name))
+		}
+	}
+	if C.pomodoro.Timer.Expire(C.ct) {
+		cmds = append(cmds, timerDone("pomodoro: "+C.pomodoro.Phase.String()))
+		C.pomodoro.Next()
+	}
+	if len(cmds) == 0 {
+		return nil
+	}
+	return tea.Batch(append(cmds, saveTimerState(C.statePath, C.snapshot()))...)
+}
+
+func timerDone(name string) tea.Cmd {
+	return func() tea.Msg {
+		return message.TimerDoneMsg{Name: name}
+	}
+}
+
 func (C ClockModel) SetPosition(x, y int) {
 	C.w.X = x
 	C.w.Y = y
 }
 
 func (C ClockModel) View() string {
-	// Get current time
-	hour := C.ct.Hour()
-	minute := C.ct.Minute()
-	second := C.ct.Second()
-	// day := C.ct.Day()
-	// year := C.ct.Year()
-	// month := C.ct.Month().String()[:3] // Short month name
-	// dayOfWeek := C.ct.Weekday().String()[:3]
-	timezone, _ := C.ct.Zone()
-
-	// Convert to digits
-	h1 := hour / 10
-	h2 := hour % 10
-	m1 := minute / 10
-	m2 := minute % 10
-	s1 := second / 10
-	s2 := second % 10
-
-	// Build the display: HH:MM:SS
-	// display := joinHorizontal(
-	// 	asciiDigits[h1],
-	// 	asciiDigits[h2],
-	// 	asciiColon,
-	// 	asciiDigits[m1],
-	// 	asciiDigits[m2],
-	// 	asciiColon,
-	// 	asciiDigits[s1],
-	// 	asciiDigits[s2],
-	// )
-
-	display := lipgloss.JoinHorizontal(lipgloss.Center,
-		asciiDigits[h1],
-		asciiDigits[h2],
-		asciiColon,
-		asciiDigits[m1],
-		asciiDigits[m2],
-		asciiColon,
-		asciiDigits[s1],
-		asciiDigits[s2],
-	)
+	t := theme.Current()
 
-	display = lipgloss.JoinHorizontal(lipgloss.Center, display, "      ", TestCal2)
+	var left, right string
+	switch C.mode {
+	case StopwatchMode:
+		left, right = C.stopwatchView()
+	case TimerMode:
+		left, right = C.timerView()
+	case PomodoroMode:
+		left, right = C.pomodoroView()
+	case AlarmMode:
+		left, right = C.alarmView()
+	default:
+		left, right = C.clockView()
+	}
 
-	style := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("ff")).
-		Bold(true)
+	left += "\n" + t.Style(theme.Muted).Render(C.mode.Help())
+	if C.stateErr != nil {
+		left += "\n" + t.Style(theme.Alert).Render("state: "+C.stateErr.Error())
+	}
+	if C.alarmErr != nil {
+		left += "\n" + t.Style(theme.Alert).Render("alarms: "+C.alarmErr.Error())
+	}
+	if C.fontErr != nil {
+		left += "\n" + t.Style(theme.Alert).Render("font: "+C.fontErr.Error())
+	}
 
-	timeStyle := lipgloss.NewStyle()
-	// datestlyle := lipgloss.NewStyle().
-	// 	Bold(true).
-	// 	Italic(true)
-	// dateStr := fmt.Sprintf(" %s %02d, %d || %s ", month, day, year, dayOfWeek)
-	bottomInfo := fmt.Sprintf("TZ: %s", timezone)
 	return lipgloss.Place(
 		C.w.X,
 		C.w.Y,
 		lipgloss.Center, // horizontal center
 		lipgloss.Center, // vertical center
-		style.Render(timeStyle.Render(display)+"\n"+bottomInfo),
+		lipgloss.JoinHorizontal(lipgloss.Center, left, "      ", right),
 	)
 }
 
+func (C ClockModel) clockView() (string, string) {
+	// Get current time in the zone picked for the big digits
+	zone := C.zones[C.zone]
+	ct := C.ct.In(zone.Loc)
+	timezone, _ := ct.Zone()
+
+	t := theme.Current()
+
+	style := t.Style(theme.Accent).
+		Bold(true)
+
+	// dateStr := fmt.Sprintf(" %s %02d, %d || %s ", month, day, year, dayOfWeek)
+	tz := fmt.Sprintf("TZ: %s", timezone)
+	if C.zone != 0 {
+		tz += " · " + zone.Label
+	}
+	bottomInfo := t.Style(theme.Muted).Render(tz)
+	clock := style.Render(C.clockDigits(ct)) + "\n" + bottomInfo
+	if len(C.zones) > 1 {
+		clock += "\n\n" + ZoneList(C.zones, C.zone, C.ct)
+	}
+	if C.zoneErr != nil {
+		clock += "\n" + t.Style(theme.Alert).Render(C.zoneErr.Error())
+	}
+
+	calendar := lipgloss.JoinVertical(lipgloss.Left, C.cal.View(C.ct), "", C.cal.Agenda(4))
+	if C.calErr != nil {
+		calendar += "\n" + t.Style(theme.Alert).Render(C.calErr.Error())
+	}
+	return clock, calendar
+}
+
+// clockDigits renders the time of day in the current font, honouring the
+// 12/24h, seconds and blinking colon settings.
+func (C ClockModel) clockDigits(ct time.Time) string {
+	colon := ":"
+	if C.blink && ct.Second()%2 == 1 {
+		colon = string(BlankColon)
+	}
+
+	hour, suffix := ct.Hour(), ""
+	if C.hour12 {
+		suffix = " AM"
+		if hour >= 12 {
+			suffix = " PM"
+		}
+		hour = (hour+11)%12 + 1
+	}
+
+	text := fmt.Sprintf("%02d%s%02d", hour, colon, ct.Minute())
+	if C.hour12 {
+		text = fmt.Sprintf("%d%s%02d", hour, colon, ct.Minute())
+	}
+	if C.seconds {
+		text += fmt.Sprintf("%s%02d", colon, ct.Second())
+	}
+
+	big := C.renderBig(text)
+	if suffix == "" {
+		return big
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Bottom, big, suffix)
+}
+
+// renderBig draws text in the selected font, sized to the widget. With an
+// allotted size, pixel fonts grow to fill it and fonts that don't fit give
+// way to smaller built-ins. Without one (natural layout) the font is used
+// as is.
+func (C ClockModel) renderBig(text string) string {
+	f := C.fonts[C.font]
+	if C.w.X <= 0 || C.w.Y <= 0 {
+		return f.Render(text, 1)
+	}
+
+	// leave room for the calendar/side panel and the lines under the digits
+	budgetW, budgetH := C.w.X*55/100, C.w.Y-6
+
+	scale := 1
+	for f.Scalable {
+		w, h := f.Size(text, scale+1)
+		if w > budgetW || h > budgetH {
+			break
+		}
+		scale++
+	}
+	if w, h := f.Size(text, scale); w <= budgetW && h <= budgetH {
+		return f.Render(text, scale)
+	}
+
+	for _, name := range []string{"box", "7seg", "braille"} {
+		fb := builtinFonts[name]()
+		if w, h := fb.Size(text, 1); w <= budgetW && h <= budgetH {
+			return fb.Render(text, 1)
+		}
+	}
+	return builtinFonts["braille"]().Render(text, 1)
+}
+
 func joinHorizontal(arts ...string) string {
 	// Split each art into lines
 	var allLines [][]string
@@ -154,3 +398,7 @@ func joinHorizontal(arts ...string) string {
 	}
 	return result.String()
 }
+
+func (C ClockModel) Name() string {
+	return "clock"
+}
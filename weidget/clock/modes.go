@@ -0,0 +1,215 @@
+package clock
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antiloger/termctlr/theme"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Mode is what the big digits show. "m" cycles through them.
+type Mode int
+
+const (
+	ClockMode Mode = iota
+	StopwatchMode
+	TimerMode
+	PomodoroMode
+	AlarmMode
+	modeCount
+)
+
+func (m Mode) String() string {
+	return [...]string{"clock", "stopwatch", "timers", "pomodoro", "alarms"}[m]
+}
+
+// Help is the key hint line shown under the digits.
+func (m Mode) Help() string {
+	switch m {
+	case StopwatchMode:
+		return "stopwatch · space start/stop · l lap · x reset · m mode"
+	case TimerMode:
+		return "timers · ↑↓ select · space start/stop · +/- 1m · x reset · m mode"
+	case PomodoroMode:
+		return "pomodoro · space start/stop · s skip · x reset · m mode"
+	case AlarmMode:
+		return "alarms · ↑↓ select · n new · e edit · space on/off · d delete · m mode"
+	}
+	return "clock · ←↑↓→ day · [ ] month · t today · z zone · f font · m mode"
+}
+
+func (C ClockModel) handleModeKey(key string) (tea.Model, tea.Cmd) {
+	now := time.Now()
+	switch C.mode {
+	case ClockMode:
+		switch key {
+		case "left", "h":
+			C.cal.Move(-1)
+		case "right", "l":
+			C.cal.Move(1)
+		case "up", "k":
+			C.cal.Move(-7)
+		case "down", "j":
+			C.cal.Move(7)
+		case "[", "pgup":
+			C.cal.MoveMonth(-1)
+		case "]", "pgdown":
+			C.cal.MoveMonth(1)
+		case "t":
+			C.cal.Cursor = dayOf(C.ct)
+		case "r":
+			return C, loadEvents(C.calendars)
+		case "z":
+			C.zone = (C.zone + 1) % len(C.zones)
+		}
+		return C, nil
+
+	case StopwatchMode:
+		switch key {
+		case " ":
+			C.stopwatch.Toggle(now)
+		case "l":
+			C.stopwatch.Lap(now)
+		case "x":
+			C.stopwatch.Reset()
+		default:
+			return C, nil
+		}
+
+	case TimerMode:
+		if len(C.timers) == 0 {
+			return C, nil
+		}
+		timer := &C.timers[C.selected]
+		switch key {
+		case "up", "k":
+			C.selected = (C.selected - 1 + len(C.timers)) % len(C.timers)
+			return C, nil
+		case "down", "j":
+			C.selected = (C.selected + 1) % len(C.timers)
+			return C, nil
+		case " ":
+			timer.Toggle(now)
+		case "+", "=":
+			timer.Adjust(time.Minute)
+		case "-":
+			timer.Adjust(-time.Minute)
+		case "x":
+			timer.Reset()
+		default:
+			return C, nil
+		}
+
+	case AlarmMode:
+		return C.handleAlarmKey(key)
+
+	case PomodoroMode:
+		switch key {
+		case " ":
+			C.pomodoro.Timer.Toggle(now)
+		case "s":
+			C.pomodoro.Next()
+		case "x":
+			C.pomodoro.Reset()
+		default:
+			return C, nil
+		}
+	}
+
+	// every handled timer key changes persisted state
+	return C, saveTimerState(C.statePath, C.snapshot())
+}
+
+func (C ClockModel) digits(d time.Duration, running bool) string {
+	t := theme.Current()
+	h, m, s := clockDuration(d)
+	role := theme.Accent
+	if !running {
+		role = theme.Muted
+	}
+	return t.Style(role).Bold(true).Render(C.renderBig(fmt.Sprintf("%02d:%02d:%02d", h, m, s)))
+}
+
+func (C ClockModel) stopwatchView() (string, string) {
+	t := theme.Current()
+	total := C.stopwatch.Total(C.ct)
+	left := C.digits(total, C.stopwatch.Running)
+
+	laps := C.stopwatch.Laps
+	if len(laps) == 0 {
+		return left, t.Style(theme.Muted).Render("no laps")
+	}
+	var lines []string
+	first := max(len(laps)-8, 0)
+	for i := len(laps) - 1; i >= first; i-- {
+		split := laps[i]
+		if i > 0 {
+			split -= laps[i-1]
+		}
+		lines = append(lines, t.Style(theme.Muted).Render(fmt.Sprintf("lap %-3d", i+1))+
+			t.Style(theme.Text).Render(fmt.Sprintf(" %s  +%s", formatSpan(laps[i]), formatSpan(split))))
+	}
+	return left, strings.Join(lines, "\n")
+}
+
+func (C ClockModel) timerView() (string, string) {
+	t := theme.Current()
+	if len(C.timers) == 0 {
+		return C.digits(0, false), t.Style(theme.Muted).Render("no timers configured")
+	}
+	sel := C.timers[C.selected]
+	left := C.digits(sel.Left(C.ct), sel.Running)
+
+	var lines []string
+	for i, c := range C.timers {
+		marker := "  "
+		if i == C.selected {
+			marker = "> "
+		}
+		state, role := "paused", theme.Muted
+		switch {
+		case c.Done:
+			state, role = "done", theme.Alert
+		case c.Running:
+			state, role = "running", theme.GaugeLow
+		}
+		lines = append(lines, fmt.Sprintf("%s%-10s %s %s", marker, c.Name,
+			t.Style(theme.Text).Render(formatSpan(c.Left(C.ct))), t.Style(role).Render(state)))
+	}
+	return left, strings.Join(lines, "\n")
+}
+
+func (C ClockModel) pomodoroView() (string, string) {
+	t := theme.Current()
+	p := C.pomodoro
+	left := C.digits(p.Timer.Left(C.ct), p.Timer.Running)
+
+	// one dot per work session in the current long-break cycle
+	done := p.Completed % p.LongEvery
+	if p.Phase == LongBreak {
+		done = p.LongEvery
+	}
+	dots := strings.Repeat("●", done) + strings.Repeat("○", p.LongEvery-done)
+
+	phaseRole := theme.Accent
+	if p.Phase != Work {
+		phaseRole = theme.GaugeLow
+	}
+	right := strings.Join([]string{
+		t.Style(phaseRole).Bold(true).Render(strings.ToUpper(p.Phase.String())),
+		t.Style(theme.Text).Render(dots),
+		t.Style(theme.Muted).Render(fmt.Sprintf("%d sessions done", p.Completed)),
+	}, "\n")
+	return left, right
+}
+
+// formatSpan renders a duration as H:MM:SS or MM:SS.
+func formatSpan(d time.Duration) string {
+	h, m, s := clockDuration(d)
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d", m, s)
+}
@@ -0,0 +1,89 @@
+package clock
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/antiloger/termctlr/config"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// timerState is what survives a restart: everything time-keeping, but not
+// the configuration (durations come from config on every start).
+type timerState struct {
+	Stopwatch Stopwatch   `json:"stopwatch"`
+	Timers    []Countdown `json:"timers"`
+	Pomodoro  Pomodoro    `json:"pomodoro"`
+}
+
+// stateSavedMsg reports a state file written, timers or alarms.
+type stateSavedMsg struct {
+	path string
+	err  error
+}
+
+func loadTimerState(path string) (timerState, error) {
+	var st timerState
+	err := loadJSON(path, &st)
+	return st, err
+}
+
+func saveTimerState(path string, st timerState) tea.Cmd {
+	return saveJSON(path, st)
+}
+
+// loadJSON reads a state file; a missing file leaves v untouched.
+func loadJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// saveJSON writes a state file off the UI goroutine. The file is
+// replaced atomically so a crash never leaves half a file behind.
+func saveJSON(path string, v any) tea.Cmd {
+	return func() tea.Msg {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return stateSavedMsg{path, err}
+		}
+		return stateSavedMsg{path, config.WriteState(path, data)}
+	}
+}
+
+// restore applies saved state on top of freshly configured timers.
+// Countdowns are matched by name so removing one from config drops it.
+func (C *ClockModel) restore(st timerState) {
+	C.stopwatch = st.Stopwatch
+	for _, saved := range st.Timers {
+		for i := range C.timers {
+			if C.timers[i].Name == saved.Name {
+				C.timers[i].resume(saved)
+			}
+		}
+	}
+	if st.Pomodoro.Timer.Duration > 0 {
+		C.pomodoro.start(st.Pomodoro.Phase)
+		C.pomodoro.Completed = st.Pomodoro.Completed
+		C.pomodoro.Timer.resume(st.Pomodoro.Timer)
+	}
+}
+
+func (C ClockModel) snapshot() timerState {
+	// copy the slices: the snapshot is marshalled on another goroutine
+	sw := C.stopwatch
+	sw.Laps = append([]time.Duration(nil), sw.Laps...)
+	return timerState{
+		Stopwatch: sw,
+		Timers:    append([]Countdown(nil), C.timers...),
+		Pomodoro:  C.pomodoro,
+	}
+}
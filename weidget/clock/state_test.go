@@ -0,0 +1,58 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRestoreKeepsConfiguredDurations(t *testing.T) {
+	now := time.Date(2024, 6, 14, 12, 0, 0, 0, time.UTC)
+	C := ClockModel{
+		timers: []Countdown{
+			NewCountdown("tea", 5*time.Minute), // was 3m when saved
+			NewCountdown("pasta", 12*time.Minute),
+			NewCountdown("eggs", 4*time.Minute),
+			NewCountdown("bread", 40*time.Minute),
+			NewCountdown("new", time.Minute),
+		},
+		pomodoro: NewPomodoro(50*time.Minute, 10*time.Minute, 30*time.Minute, 4),
+	}
+	saved := timerState{
+		Timers: []Countdown{
+			{Name: "tea", Duration: 3 * time.Minute, Remaining: 3 * time.Minute},
+			{Name: "pasta", Duration: 10 * time.Minute, Deadline: now.Add(4 * time.Minute), Running: true},
+			{Name: "eggs", Duration: 6 * time.Minute, Remaining: 5 * time.Minute},
+			{Name: "bread", Duration: 30 * time.Minute, Done: true},
+			{Name: "gone", Duration: time.Hour, Running: true},
+		},
+		Pomodoro: Pomodoro{Phase: ShortBreak, Completed: 3,
+			Timer: Countdown{Name: "short break", Duration: 5 * time.Minute, Remaining: 2 * time.Minute}},
+	}
+	C.restore(saved)
+
+	for _, want := range []Countdown{
+		{Name: "tea", Duration: 5 * time.Minute, Remaining: 5 * time.Minute},                           // unstarted: as configured
+		{Name: "pasta", Duration: 12 * time.Minute, Deadline: now.Add(4 * time.Minute), Running: true}, // still running
+		{Name: "eggs", Duration: 4 * time.Minute, Remaining: 4 * time.Minute},                          // paused, within the new length
+		{Name: "bread", Duration: 40 * time.Minute, Done: true},                                        // done
+		{Name: "new", Duration: time.Minute, Remaining: time.Minute},                                   // not saved
+	} {
+		var got Countdown
+		for _, c := range C.timers {
+			if c.Name == want.Name {
+				got = c
+			}
+		}
+		if got != want {
+			t.Errorf("%s = %+v, want %+v", want.Name, got, want)
+		}
+	}
+	if len(C.timers) != 5 {
+		t.Errorf("%d timers; a timer removed from config came back", len(C.timers))
+	}
+
+	p := C.pomodoro
+	if p.Phase != ShortBreak || p.Completed != 3 || p.Timer.Duration != 10*time.Minute || p.Timer.Remaining != 2*time.Minute {
+		t.Errorf("pomodoro = %+v", p)
+	}
+}
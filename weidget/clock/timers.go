@@ -0,0 +1,183 @@
+package clock
+
+import (
+	"time"
+)
+
+// Stopwatch counts up; Elapsed holds time accumulated before the current run.
+type Stopwatch struct {
+	Running bool            `json:"running"`
+	Start   time.Time       `json:"start"`
+	Elapsed time.Duration   `json:"elapsed"`
+	Laps    []time.Duration `json:"laps"` // totals at each lap, oldest first
+}
+
+func (s Stopwatch) Total(now time.Time) time.Duration {
+	if s.Running {
+		return s.Elapsed + now.Sub(s.Start)
+	}
+	return s.Elapsed
+}
+
+func (s *Stopwatch) Toggle(now time.Time) {
+	if s.Running {
+		s.Elapsed += now.Sub(s.Start)
+	} else {
+		s.Start = now
+	}
+	s.Running = !s.Running
+}
+
+func (s *Stopwatch) Lap(now time.Time) {
+	if s.Running {
+		s.Laps = append(s.Laps, s.Total(now))
+	}
+}
+
+func (s *Stopwatch) Reset() {
+	*s = Stopwatch{}
+}
+
+// Countdown is a named timer. While running it is anchored to a deadline,
+// so it keeps its place across restarts; paused it stores what's left.
+type Countdown struct {
+	Name      string        `json:"name"`
+	Duration  time.Duration `json:"duration"`
+	Remaining time.Duration `json:"remaining"` // valid while paused
+	Deadline  time.Time     `json:"deadline"`  // valid while running
+	Running   bool          `json:"running"`
+	Done      bool          `json:"done"`
+}
+
+func NewCountdown(name string, d time.Duration) Countdown {
+	return Countdown{Name: name, Duration: d, Remaining: d}
+}
+
+func (c Countdown) Left(now time.Time) time.Duration {
+	if c.Running {
+		return max(c.Deadline.Sub(now), 0)
+	}
+	return c.Remaining
+}
+
+func (c *Countdown) Toggle(now time.Time) {
+	if c.Done {
+		c.Reset()
+	}
+	if c.Running {
+		c.Remaining = c.Left(now)
+	} else {
+		c.Deadline = now.Add(c.Remaining)
+	}
+	c.Running = !c.Running
+}
+
+func (c *Countdown) Reset() {
+	c.Running, c.Done = false, false
+	c.Remaining = c.Duration
+}
+
+// Adjust changes the length of a stopped timer, never below a minute.
+func (c *Countdown) Adjust(d time.Duration) {
+	if c.Running {
+		return
+	}
+	c.Duration = max(c.Duration+d, time.Minute)
+	c.Remaining = c.Duration
+	c.Done = false
+}
+
+// Expire marks the timer done once its deadline passed. It reports true
+// only on the tick the timer completes.
+func (c *Countdown) Expire(now time.Time) bool {
+	if !c.Running || now.Before(c.Deadline) {
+		return false
+	}
+	c.Running, c.Done = false, true
+	c.Remaining = 0
+	return true
+}
+
+// resume takes over where saved was: running, paused part-way or done.
+// The length stays the configured one; a saved timer that had not been
+// started is left as configured.
+func (c *Countdown) resume(saved Countdown) {
+	if !saved.Running && !saved.Done && saved.Remaining == saved.Duration {
+		return
+	}
+	c.Running, c.Done, c.Deadline = saved.Running, saved.Done, saved.Deadline
+	c.Remaining = min(saved.Remaining, c.Duration)
+}
+
+type Phase int
+
+const (
+	Work Phase = iota
+	ShortBreak
+	LongBreak
+)
+
+func (p Phase) String() string {
+	switch p {
+	case ShortBreak:
+		return "short break"
+	case LongBreak:
+		return "long break"
+	}
+	return "work"
+}
+
+// Pomodoro cycles work sessions and breaks, a long break after every
+// LongEvery work sessions.
+type Pomodoro struct {
+	Phase     Phase         `json:"phase"`
+	Completed int           `json:"completed"` // work sessions finished
+	Timer     Countdown     `json:"timer"`
+	Work      time.Duration `json:"-"`
+	Short     time.Duration `json:"-"`
+	Long      time.Duration `json:"-"`
+	LongEvery int           `json:"-"`
+}
+
+func NewPomodoro(work, short, long time.Duration, longEvery int) Pomodoro {
+	p := Pomodoro{Work: work, Short: short, Long: long, LongEvery: max(longEvery, 1)}
+	p.start(Work)
+	return p
+}
+
+func (p *Pomodoro) start(phase Phase) {
+	p.Phase = phase
+	d := p.Work
+	switch phase {
+	case ShortBreak:
+		d = p.Short
+	case LongBreak:
+		d = p.Long
+	}
+	p.Timer = NewCountdown(phase.String(), d)
+}
+
+// Next moves to the following phase, paused.
+func (p *Pomodoro) Next() {
+	if p.Phase != Work {
+		p.start(Work)
+		return
+	}
+	p.Completed++
+	if p.Completed%p.LongEvery == 0 {
+		p.start(LongBreak)
+		return
+	}
+	p.start(ShortBreak)
+}
+
+func (p *Pomodoro) Reset() {
+	p.Completed = 0
+	p.start(Work)
+}
+
+// clockDuration splits d into hours, minutes and seconds for the digits.
+func clockDuration(d time.Duration) (h, m, s int) {
+	d = d.Round(time.Second)
+	return int(d / time.Hour), int(d % time.Hour / time.Minute), int(d % time.Minute / time.Second)
+}
@@ -0,0 +1,115 @@
+package clock
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antiloger/termctlr/config"
+	"github.com/antiloger/termctlr/theme"
+)
+
+// Zone is one entry of the world clock.
+type Zone struct {
+	Label     string
+	Loc       *time.Location
+	WorkStart int // hour
+	WorkEnd   int // hour, exclusive
+}
+
+// LocalZone is the machine's own zone; it is always first in the cycle.
+func LocalZone() Zone {
+	return Zone{Label: "Local", Loc: time.Local, WorkStart: 9, WorkEnd: 17}
+}
+
+// LoadZones resolves configured IANA names. Unknown zones are skipped and
+// reported together.
+func LoadZones(cfgs []config.ZoneConfig) ([]Zone, error) {
+	var zones []Zone
+	var bad []string
+	for _, c := range cfgs {
+		loc, err := time.LoadLocation(c.Name)
+		if err != nil {
+			bad = append(bad, c.Name)
+			continue
+		}
+		label := c.Label
+		if label == "" {
+			label = c.Name[strings.LastIndex(c.Name, "/")+1:]
+			label = strings.ReplaceAll(label, "_", " ")
+		}
+		z := Zone{Label: label, Loc: loc, WorkStart: c.WorkStart, WorkEnd: c.WorkEnd}
+		if z.WorkStart == 0 && z.WorkEnd == 0 {
+			z.WorkStart, z.WorkEnd = 9, 17
+		}
+		zones = append(zones, z)
+	}
+	if len(bad) > 0 {
+		return zones, fmt.Errorf("unknown time zones: %s", strings.Join(bad, ", "))
+	}
+	return zones, nil
+}
+
+// Working reports whether t falls into the zone's working hours.
+// Ranges that wrap midnight (e.g. 22–6) are supported.
+func (z Zone) Working(t time.Time) bool {
+	h := t.In(z.Loc).Hour()
+	if z.WorkStart <= z.WorkEnd {
+		return h >= z.WorkStart && h < z.WorkEnd
+	}
+	return h >= z.WorkStart || h < z.WorkEnd
+}
+
+// ZoneList renders the compact list of every zone except the one shown in
+// the big digits (ref), with the offset and day change relative to it.
+func ZoneList(zones []Zone, shown int, now time.Time) string {
+	t := theme.Current()
+	ref := now.In(zones[shown].Loc)
+	_, refOff := ref.Zone()
+
+	labelW := 0
+	for _, z := range zones {
+		labelW = max(labelW, len([]rune(z.Label)))
+	}
+
+	var lines []string
+	for i, z := range zones {
+		if i == shown {
+			continue
+		}
+		zt := now.In(z.Loc)
+		_, off := zt.Zone()
+
+		day := "   "
+		switch d := daysBetween(ref, zt); {
+		case d > 0:
+			day = fmt.Sprintf("+%dd", d)
+		case d < 0:
+			day = fmt.Sprintf("-%dd", -d)
+		}
+
+		style := t.Style(theme.Muted)
+		if z.Working(now) {
+			style = t.Style(theme.GaugeLow)
+		}
+		line := fmt.Sprintf("%-*s %s %6s %s", labelW, z.Label, zt.Format("15:04"), formatOffset(off-refOff), day)
+		lines = append(lines, style.Render(line))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatOffset renders a difference in seconds as +9h, -5:30h, ±0h.
+func formatOffset(sec int) string {
+	sign := "+"
+	if sec < 0 {
+		sign, sec = "-", -sec
+	}
+	if sec == 0 {
+		return "±0h"
+	}
+	h, m := sec/3600, sec%3600/60
+	if m == 0 {
+		return fmt.Sprintf("%s%dh", sign, h)
+	}
+	return fmt.Sprintf("%s%d:%02dh", sign, h, m)
+}
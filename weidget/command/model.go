@@ -0,0 +1,181 @@
+package command
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/antiloger/termctlr/config"
+	"github.com/antiloger/termctlr/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// lastID numbers the widgets, so each knows its own messages: the screen
+// hands every message to every widget.
+var lastID atomic.Int64
+
+// Model is the command widget. Runs happen off the UI goroutine; Update
+// only starts, stops and reads them.
+type Model struct {
+	id       int64
+	name     string
+	command  string
+	interval time.Duration // between runs, or before restarting a followed command
+	timeout  time.Duration // 0 in follow mode
+	follow   bool
+	color    bool
+	maxLines int
+
+	cur     *proc // the running or last run, nil before the first
+	shown   *proc // the run on screen: the last finished one between runs
+	nextAt  time.Time
+	stopped bool // stopped by hand; no more runs until asked
+	now     time.Time
+
+	width, height int
+	pos           types.Position
+	offset        int  // first output line shown
+	pinned        bool // stay at the end of the output
+}
+
+func NewModel(cfg config.CommandConfig) Model {
+	name := cfg.Name
+	if name == "" {
+		name = "command"
+	}
+	interval := parseDuration(cfg.Interval, 10*time.Second)
+	timeout := parseDuration(cfg.Timeout, 30*time.Second)
+	if cfg.Follow {
+		timeout = 0
+	}
+	maxLines := cfg.MaxLines
+	if maxLines <= 0 {
+		maxLines = 500
+	}
+	return Model{
+		id:       lastID.Add(1),
+		name:     name,
+		command:  cfg.Command,
+		interval: interval,
+		timeout:  timeout,
+		follow:   cfg.Follow,
+		color:    !cfg.NoColor,
+		maxLines: maxLines,
+		now:      time.Now(),
+		width:    60,
+		height:   10,
+		pinned:   cfg.Follow, // followed output reads like a log
+	}
+}
+
+func parseDuration(s string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return def
+	}
+	return d
+}
+
+// runMsg starts a run of widget id from Update, where the model can
+// keep it.
+type runMsg struct{ id int64 }
+
+func (m Model) Init() tea.Cmd {
+	if m.command == "" {
+		return nil
+	}
+	id := m.id
+	return func() tea.Msg { return runMsg{id: id} }
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case types.SizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+	case types.TickMsg:
+		m.now = time.Time(msg)
+		if m.command == "" || m.stopped || m.running() || m.now.Before(m.nextAt) {
+			return m, nil
+		}
+		return m.run()
+	case runMsg:
+		if msg.id != m.id {
+			return m, nil // another command's first run
+		}
+		return m.run()
+	case outputMsg:
+		if msg.proc != m.cur {
+			return m, nil // a run that has been replaced
+		}
+		s := m.cur.snapshot()
+		if s.running() {
+			return m, waitOutput(m.cur)
+		}
+		m.shown = m.cur
+		m.nextAt = s.ended.Add(m.interval)
+		return m, nil
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m Model) handleKey(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "r":
+		if m.command != "" {
+			return m.run()
+		}
+	case "x":
+		if m.running() {
+			m.cur.stop()
+			m.stopped = true
+		}
+	case "up", "k":
+		m.scrollTo(m.top() - 1)
+	case "down", "j":
+		m.scrollTo(m.top() + 1)
+	case "pgup":
+		m.scrollTo(m.top() - m.bodyHeight())
+	case "pgdown":
+		m.scrollTo(m.top() + m.bodyHeight())
+	case "g", "home":
+		m.scrollTo(0)
+	case "G", "end":
+		m.pinned = true
+	}
+	return m, nil
+}
+
+// run starts the command, stopping a run still going.
+func (m Model) run() (Model, tea.Cmd) {
+	if m.running() {
+		m.cur.stop()
+	}
+	m.stopped = false
+	m.cur = start(m.command, m.timeout, m.maxLines, m.color)
+	if m.follow || m.shown == nil {
+		m.shown = m.cur // interval runs replace the output once done
+	}
+	return m, waitOutput(m.cur)
+}
+
+func (m Model) running() bool {
+	return m.cur != nil && m.cur.snapshot().running()
+}
+
+// Close stops a run still going when the program exits.
+func (m Model) Close() {
+	if m.cur != nil {
+		m.cur.stopWait()
+	}
+}
+
+func (m Model) SetPosition(x, y int) {
+	m.pos.X = x
+	m.pos.Y = y
+}
+
+func (m Model) Name() string {
+	return m.name
+}
@@ -0,0 +1,36 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/antiloger/termctlr/config"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFirstRunStartsOnlyItsCommand(t *testing.T) {
+	a := NewModel(config.CommandConfig{Command: "true"})
+	b := NewModel(config.CommandConfig{Command: "true"})
+	defer a.Close()
+	defer b.Close()
+
+	// the screen hands every message to every widget
+	for _, msg := range []tea.Msg{a.Init()(), b.Init()()} {
+		next, _ := a.Update(msg)
+		a = next.(Model)
+		next, _ = b.Update(msg)
+		b = next.(Model)
+		for _, m := range []Model{a, b} {
+			if m.cur != nil && m.cur.snapshot().err != nil {
+				t.Fatalf("%s: a run was replaced: %v", m.name, m.cur.snapshot().err)
+			}
+		}
+	}
+	if a.cur == nil || b.cur == nil {
+		t.Fatal("a command did not start")
+	}
+	first := a.cur
+	next, _ := a.Update(runMsg{id: b.id})
+	if next.(Model).cur != first {
+		t.Error("another widget's runMsg restarted the command")
+	}
+}
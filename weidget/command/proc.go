@@ -0,0 +1,213 @@
+// Package command shows the output of a shell command, run on an interval
+// or followed as it runs.
+package command
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// killGrace is how long a cancelled command has between SIGTERM and
+// SIGKILL.
+const killGrace = 2 * time.Second
+
+var errTimeout = errors.New("timed out")
+
+// proc is one run of the command. Its output is collected into a bounded
+// line buffer the view reads under the lock.
+type proc struct {
+	cancel  context.CancelFunc
+	updates chan struct{} // signalled (never blocking) on output and exit
+	done    chan struct{} // closed when the run ended
+
+	mu       sync.Mutex
+	lines    []string
+	dropped  int // lines dropped off the top of the buffer
+	started  time.Time
+	ended    time.Time // zero while running
+	exitCode int
+	err      error // could not run, timed out or killed
+}
+
+// start runs command with sh -c. Its output, stdout and stderr merged, is
+// kept up to maxLines lines. A timeout of 0 lets it run until cancelled.
+func start(command string, timeout time.Duration, maxLines int, color bool) *proc {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeoutCause(context.Background(), timeout, errTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	p := &proc{cancel: cancel, updates: make(chan struct{}, 1), done: make(chan struct{}), started: time.Now()}
+	go p.run(ctx, command, maxLines, color)
+	return p
+}
+
+func (p *proc) run(ctx context.Context, command string, maxLines int, color bool) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	// its own process group, so pipelines and the children of scripts
+	// are stopped with it
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+	}
+	cmd.WaitDelay = killGrace
+	r, w := io.Pipe()
+	cmd.Stdout, cmd.Stderr = w, w
+
+	err := cmd.Start()
+	if err == nil {
+		go func() { w.CloseWithError(cmd.Wait()) }()
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 64*1024), 1024*1024)
+		for sc.Scan() {
+			p.add(cleanLine(sc.Text(), color), maxLines)
+		}
+		err = sc.Err()
+	}
+
+	code := 0
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() >= 0 {
+		code, err = exit.ExitCode(), nil // else killed by a signal, which err names
+	}
+	if ctx.Err() != nil {
+		code, err = 0, context.Cause(ctx) // timed out or cancelled, whatever the exit status
+	}
+	p.mu.Lock()
+	p.ended, p.exitCode, p.err = time.Now(), code, err
+	p.mu.Unlock()
+	p.cancel()
+	close(p.done)
+	p.notify()
+}
+
+func (p *proc) add(line string, maxLines int) {
+	p.mu.Lock()
+	p.lines = append(p.lines, line)
+	if over := len(p.lines) - maxLines; over > 0 {
+		p.lines = p.lines[over:]
+		p.dropped += over
+	}
+	p.mu.Unlock()
+	p.notify()
+}
+
+func (p *proc) notify() {
+	select {
+	case p.updates <- struct{}{}:
+	default:
+	}
+}
+
+// stop cancels the run; it ends with context.Canceled.
+func (p *proc) stop() {
+	p.cancel()
+}
+
+// stopWait cancels the run and waits for it to end, at most long enough
+// for the process group to be killed.
+func (p *proc) stopWait() {
+	p.cancel()
+	select {
+	case <-p.done:
+	case <-time.After(killGrace + time.Second):
+	}
+}
+
+// snapshot is a consistent copy of the run's state for the view.
+type snapshot struct {
+	lines    []string
+	dropped  int
+	started  time.Time
+	ended    time.Time
+	exitCode int
+	err      error
+}
+
+func (s snapshot) running() bool { return s.ended.IsZero() }
+
+// failed reports a run that did not end well: non-zero exit, timeout or
+// could not start. Cancelling by hand is not a failure.
+func (s snapshot) failed() bool {
+	return !s.running() && (s.exitCode != 0 || (s.err != nil && !errors.Is(s.err, context.Canceled)))
+}
+
+func (p *proc) snapshot() snapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return snapshot{p.lines, p.dropped, p.started, p.ended, p.exitCode, p.err}
+}
+
+// outputMsg says a run has new output or ended.
+type outputMsg struct{ proc *proc }
+
+// waitOutput wakes the UI on output; re-issue it after each one.
+func waitOutput(p *proc) tea.Cmd {
+	return func() tea.Msg {
+		<-p.updates
+		return outputMsg{proc: p}
+	}
+}
+
+// cleanLine makes a line of output safe to draw in a pane: colour (SGR)
+// sequences are kept when color is set and every other escape sequence,
+// which could move the cursor or retitle the terminal, is dropped.
+// Carriage returns keep only what was drawn last, as a terminal would for
+// progress bars.
+func cleanLine(s string, color bool) string {
+	if i := strings.LastIndexByte(strings.TrimRight(s, "\r"), '\r'); i >= 0 {
+		s = s[i+1:]
+	}
+	s = strings.TrimRight(s, "\r")
+	var b strings.Builder
+	sgr := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\t':
+			b.WriteString("    ")
+		case c == 0x1b && i+1 < len(s) && s[i+1] == '[':
+			// CSI: parameters and intermediates, then a final byte
+			j := i + 2
+			for j < len(s) && (s[j] < 0x40 || s[j] > 0x7e) {
+				j++
+			}
+			if j < len(s) && s[j] == 'm' && color {
+				b.WriteString(s[i : j+1])
+				sgr = true
+			}
+			i = j
+		case c == 0x1b && i+1 < len(s) && s[i+1] == ']':
+			// OSC, up to BEL or ST
+			j := i + 2
+			for j < len(s) && s[j] != 0x07 && !(s[j] == 0x1b && j+1 < len(s) && s[j+1] == '\\') {
+				j++
+			}
+			if j < len(s) && s[j] == 0x1b {
+				j++
+			}
+			i = j
+		case c == 0x1b:
+			i++ // a two byte escape
+		case c < 0x20 || c == 0x7f:
+			// other control characters
+		default:
+			b.WriteByte(c)
+		}
+	}
+	if sgr {
+		b.WriteString("\x1b[0m") // colours do not run into the next line
+	}
+	return b.String()
+}
@@ -0,0 +1,142 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antiloger/termctlr/config"
+	"github.com/antiloger/termctlr/theme"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// wait returns the run's state once it ended.
+func wait(t *testing.T, p *proc) snapshot {
+	t.Helper()
+	select {
+	case <-p.done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the run did not end")
+	}
+	return p.snapshot()
+}
+
+// waitLine waits until the run printed line.
+func waitLine(t *testing.T, p *proc, line string) {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for !slices.Contains(p.snapshot().lines, line) {
+		select {
+		case <-p.updates:
+		case <-deadline:
+			t.Fatalf("no %q in %q", line, p.snapshot().lines)
+		}
+	}
+}
+
+func TestRunExitStatus(t *testing.T) {
+	s := wait(t, start("echo out; echo err >&2; exit 3", time.Minute, 10, false))
+	if !slices.Equal(s.lines, []string{"out", "err"}) {
+		t.Errorf("lines = %q", s.lines)
+	}
+	if s.exitCode != 3 || s.err != nil || !s.failed() {
+		t.Errorf("exit %d err %v failed %v", s.exitCode, s.err, s.failed())
+	}
+	if s := wait(t, start("true", time.Minute, 10, false)); s.failed() {
+		t.Errorf("a clean exit failed: %+v", s)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	p := start("echo started; sleep 30", 200*time.Millisecond, 10, false)
+	s := wait(t, p)
+	if !errors.Is(s.err, errTimeout) || !s.failed() {
+		t.Errorf("err %v failed %v", s.err, s.failed())
+	}
+	if d := s.ended.Sub(s.started); d > killGrace {
+		t.Errorf("took %v to time out", d)
+	}
+}
+
+func TestRunStop(t *testing.T) {
+	// the background sleep keeps the pipe open unless the whole
+	// process group is stopped
+	p := start("sleep 30 & echo started; wait", 0, 10, false)
+	waitLine(t, p, "started")
+	p.stop()
+	s := wait(t, p)
+	if !errors.Is(s.err, context.Canceled) || s.failed() {
+		t.Errorf("err %v failed %v; stopping by hand is not a failure", s.err, s.failed())
+	}
+	if d := s.ended.Sub(s.started); d > killGrace {
+		t.Errorf("took %v to stop", d)
+	}
+}
+
+func TestRunStopWait(t *testing.T) {
+	// SIGTERM is ignored, so only SIGKILL after the grace ends it
+	p := start("trap '' TERM; echo started; sleep 30", 0, 10, false)
+	waitLine(t, p, "started")
+	begun := time.Now()
+	p.stopWait()
+	select {
+	case <-p.done:
+	default:
+		t.Fatal("stopWait returned before the run ended")
+	}
+	if d := time.Since(begun); d > killGrace+time.Second {
+		t.Errorf("stopWait took %v", d)
+	}
+}
+
+func TestRunKeepsLastLines(t *testing.T) {
+	s := wait(t, start("seq 1 20", time.Minute, 5, false))
+	if !slices.Equal(s.lines, []string{"16", "17", "18", "19", "20"}) || s.dropped != 15 {
+		t.Errorf("lines %q dropped %d", s.lines, s.dropped)
+	}
+}
+
+func TestCleanLine(t *testing.T) {
+	for _, c := range []struct {
+		in, color, plain string
+	}{
+		{"plain", "plain", "plain"},
+		{"a\tb", "a    b", "a    b"},
+		{"\x1b[31mred\x1b[0m", "\x1b[31mred\x1b[0m\x1b[0m", "red"},
+		{"\x1b[2J\x1b[Hhome", "home", "home"},
+		{"\x1b]0;title\x07text", "text", "text"},
+		{"\x1b]8;;http://x\x1b\\link", "link", "link"},
+		{"10%\r50%\r100%", "100%", "100%"},
+		{"done\r\r", "done", "done"},
+		{"bell\a\x7f\x1bc", "bell", "bell"},
+	} {
+		if got := cleanLine(c.in, true); got != c.color {
+			t.Errorf("cleanLine(%q, true) = %q, want %q", c.in, got, c.color)
+		}
+		if got := cleanLine(c.in, false); got != c.plain {
+			t.Errorf("cleanLine(%q, false) = %q, want %q", c.in, got, c.plain)
+		}
+	}
+}
+
+func TestFailedRunTitle(t *testing.T) {
+	defer lipgloss.SetColorProfile(lipgloss.ColorProfile())
+	lipgloss.SetColorProfile(termenv.ANSI256)
+
+	m := NewModel(config.CommandConfig{Name: "check", Command: "exit 1"})
+	m.shown = start(m.command, time.Minute, 10, false)
+	wait(t, m.shown)
+	alert := theme.Current().Style(theme.Alert).Bold(true).Render("check")
+	if !strings.Contains(m.View(), alert) {
+		t.Errorf("failed run's title not drawn as an alert:\n%q", m.View())
+	}
+	m.shown = start("true", time.Minute, 10, false)
+	wait(t, m.shown)
+	if strings.Contains(m.View(), alert) {
+		t.Error("successful run's title drawn as an alert")
+	}
+}
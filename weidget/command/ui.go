@@ -0,0 +1,140 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antiloger/termctlr/theme"
+	"github.com/antiloger/termctlr/weidget/components"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// bodyHeight is the number of output lines shown, below the title and
+// above the status and help lines.
+func (m Model) bodyHeight() int {
+	return max(m.height-3, 1)
+}
+
+// top is the first output line shown.
+func (m Model) top() int {
+	if m.shown == nil {
+		return 0
+	}
+	end := max(len(m.shown.snapshot().lines)-m.bodyHeight(), 0)
+	if m.pinned {
+		return end
+	}
+	return min(m.offset, end)
+}
+
+// scrollTo moves the first shown line; reaching the end pins the view to
+// new output again.
+func (m *Model) scrollTo(top int) {
+	if m.shown == nil {
+		return
+	}
+	end := max(len(m.shown.snapshot().lines)-m.bodyHeight(), 0)
+	m.offset = max(min(top, end), 0)
+	m.pinned = m.offset == end && m.follow
+}
+
+func (m Model) View() string {
+	t := theme.Current()
+	if m.command == "" {
+		return strings.Join([]string{
+			t.Style(theme.Muted).Render(m.name + ": no command configured"),
+			t.Style(theme.Muted).Render(`add one under commands, e.g. {"name": "disks", "command": "df -h", "interval": "1m"}`),
+		}, "\n")
+	}
+
+	var s snapshot
+	if m.shown != nil {
+		s = m.shown.snapshot()
+	}
+	titleStyle := t.Style(theme.Accent).Bold(true)
+	if s.failed() {
+		titleStyle = t.Style(theme.Alert).Bold(true)
+	}
+	title := components.Fit(titleStyle.Render(m.name)+" "+t.Style(theme.Muted).Render("$ "+m.command), m.width)
+
+	h := m.bodyHeight()
+	top := m.top()
+	shown := s.lines[min(top, len(s.lines)):min(top+h, len(s.lines))]
+	body := make([]string, h)
+	for i := range body {
+		switch {
+		case i < len(shown):
+			body[i] = components.Fit(shown[i], m.width)
+			if strings.Contains(shown[i], "\x1b") {
+				body[i] += "\x1b[0m" // in case the colour reset was cut off
+			}
+		case i == 0 && len(s.lines) == 0 && !s.running():
+			body[i] = t.Style(theme.Muted).Render(components.Fit("(no output)", m.width))
+		default:
+			body[i] = components.Fit("", m.width)
+		}
+	}
+
+	status := m.status(t, s)
+	if len(s.lines) > h {
+		status += t.Style(theme.Muted).Render(fmt.Sprintf(" · lines %d-%d of %d", s.dropped+top+1, s.dropped+top+len(shown), s.dropped+len(s.lines)))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		title,
+		strings.Join(body, "\n"),
+		components.Fit(status, m.width),
+		t.Style(theme.Muted).Render(components.Fit("r run · x stop · ↑ ↓ pgup pgdn scroll · g/G top/end", m.width)),
+	)
+}
+
+// status describes the shown run and, between runs, when the next is due.
+func (m Model) status(t *theme.Theme, s snapshot) string {
+	muted := t.Style(theme.Muted)
+	switch {
+	case s.started.IsZero():
+		return muted.Render("starting…")
+	case s.running() && m.follow:
+		return t.Style(theme.Accent).Render("● following") + muted.Render(" · since "+formatDuration(m.now.Sub(s.started))+" ago")
+	case s.running():
+		return t.Style(theme.Accent).Render("● running") + muted.Render(" · "+formatDuration(m.now.Sub(s.started)))
+	}
+
+	var head string
+	switch {
+	case errors.Is(s.err, errTimeout):
+		head = t.Style(theme.Alert).Bold(true).Render("✕ timed out after " + formatDuration(m.timeout))
+	case errors.Is(s.err, context.Canceled):
+		head = muted.Render("○ stopped · r run")
+	case s.err != nil:
+		head = t.Style(theme.Alert).Bold(true).Render("✕ " + s.err.Error())
+	case s.exitCode != 0:
+		head = t.Style(theme.Alert).Bold(true).Render(fmt.Sprintf("✕ exit %d", s.exitCode))
+	default:
+		head = t.Style(theme.Accent).Render("✓ exit 0")
+	}
+	tail := fmt.Sprintf(" · took %s · %s ago", formatDuration(s.ended.Sub(s.started)), formatDuration(m.now.Sub(s.ended)))
+	if m.running() {
+		tail += " · running again"
+	} else if !m.stopped {
+		tail += " · next in " + formatDuration(m.nextAt.Sub(m.now))
+	}
+	return head + muted.Render(tail)
+}
+
+// formatDuration is a short duration: 0.4s, 12s, 3m, 2h.
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < 0:
+		return "0s"
+	case d < 10*time.Second:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh", int(d.Hours()))
+}
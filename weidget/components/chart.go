@@ -0,0 +1,73 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/antiloger/termctlr/theme"
+)
+
+// braille dot bits indexed by [y][x] inside a 2x4 cell
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// LineChart plots values as a braille line chart of width x height cells.
+// Each cell holds 2x4 dots, so the chart shows the last 2*width values.
+// Values are scaled between lo and hi; when lo == hi the data range is used.
+func LineChart(values []float64, lo, hi float64, width, height int) string {
+	if width <= 0 || height <= 0 {
+		return ""
+	}
+	pw, ph := width*2, height*4
+	if len(values) > pw {
+		values = values[len(values)-pw:]
+	}
+	if lo == hi && len(values) > 0 {
+		lo, hi = values[0], values[0]
+		for _, v := range values {
+			lo, hi = min(lo, v), max(hi, v)
+		}
+	}
+
+	grid := make([][]rune, height)
+	for i := range grid {
+		grid[i] = make([]rune, width)
+	}
+	set := func(x, y int) {
+		grid[y/4][x/2] |= brailleDots[y%4][x%2]
+	}
+
+	// right-align the data so the newest value sits at the right edge
+	offset := pw - len(values)
+	prev := -1
+	for i, v := range values {
+		y := ph - 1
+		if hi > lo {
+			y = ph - 1 - int(clamp01((v-lo)/(hi-lo))*float64(ph-1)+0.5)
+		}
+		x := offset + i
+		// connect to the previous point with a vertical run so steep
+		// changes stay visible as a line rather than scattered dots
+		from, to := y, y
+		if prev >= 0 {
+			from, to = min(prev, y), max(prev, y)
+		}
+		for yy := from; yy <= to; yy++ {
+			set(x, yy)
+		}
+		prev = y
+	}
+
+	lines := make([]string, height)
+	for i, row := range grid {
+		var b strings.Builder
+		for _, dots := range row {
+			b.WriteRune(0x2800 + dots)
+		}
+		lines[i] = b.String()
+	}
+	return theme.Current().Style(theme.Accent).Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,80 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/antiloger/termctlr/theme"
+)
+
+// Eighth blocks give gauges sub-cell precision: a 20 cell bar has 160 steps.
+var (
+	hEighths = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+	vEighths = []string{"", "▁", "▂", "▃", "▄", "▅", "▆", "▇"}
+)
+
+// Gauge renders a ratio (0–1) as a bar colored by the theme's gauge roles.
+type Gauge struct {
+	Ratio    float64
+	Role     theme.Role // overrides the low/med/high color when set
+	Vertical bool
+}
+
+// NewGauge builds a gauge from a value and its maximum.
+func NewGauge(value, max float64) Gauge {
+	if max <= 0 {
+		return Gauge{}
+	}
+	return Gauge{Ratio: value / max}
+}
+
+// Render draws the gauge into size cells: width for horizontal gauges,
+// height for vertical ones (returned as lines, top to bottom).
+func (g Gauge) Render(size int) string {
+	if size <= 0 {
+		return ""
+	}
+	t := theme.Current()
+	ratio := clamp01(g.Ratio)
+	role := g.Role
+	if role == "" {
+		role = t.GaugeRole(ratio)
+	}
+
+	eighths := int(ratio*float64(size*8) + 0.5)
+	full, part := eighths/8, eighths%8
+
+	if g.Vertical {
+		fill := t.Style(role)
+		empty := t.Style(theme.GaugeEmpty)
+		lines := make([]string, size)
+		for i := range size {
+			row := size - 1 - i // row 0 is the bottom cell
+			switch {
+			case row < full:
+				lines[i] = fill.Render(t.Glyphs.GaugeFull)
+			case row == full && part > 0:
+				lines[i] = fill.Render(vEighths[part])
+			default:
+				lines[i] = empty.Render(t.Glyphs.GaugeEmpty)
+			}
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	bar := strings.Repeat(t.Glyphs.GaugeFull, full)
+	rest := size - full
+	if part > 0 {
+		bar += hEighths[part]
+		rest--
+	}
+	return t.Style(role).Render(bar) + t.Style(theme.GaugeEmpty).Render(strings.Repeat(t.Glyphs.GaugeEmpty, rest))
+}
+
+// Labeled renders a gauge with a label on the left, sized to width.
+func Labeled(label string, g Gauge, suffix string, width int) string {
+	barW := width - len([]rune(label)) - len([]rune(suffix))
+	if barW < 1 {
+		return Fit(label+suffix, width)
+	}
+	return label + g.Render(barW) + suffix
+}
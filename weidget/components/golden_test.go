@@ -0,0 +1,174 @@
+package components
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/antiloger/termctlr/config"
+	"github.com/antiloger/termctlr/theme"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/*.golden")
+
+func TestMain(m *testing.M) {
+	// render colors as a 256 color terminal would, whatever runs the tests
+	lipgloss.SetColorProfile(termenv.ANSI256)
+	os.Exit(m.Run())
+}
+
+// goldenCase is one rendering in a golden file.
+type goldenCase struct {
+	title  string
+	render func() string
+}
+
+// golden renders each case in every built-in theme and compares the
+// output with testdata/<name>-<theme>.golden.
+func golden(t *testing.T, name string, cases []goldenCase) {
+	t.Helper()
+	defer theme.Set(theme.Current())
+	for _, themeName := range theme.Names() {
+		t.Run(themeName, func(t *testing.T) {
+			th, err := theme.Load(config.Config{Theme: themeName})
+			if err != nil {
+				t.Fatal(err)
+			}
+			theme.Set(th)
+
+			var b strings.Builder
+			for _, c := range cases {
+				fmt.Fprintf(&b, "── %s ──\n%s\n", c.title, c.render())
+			}
+			got := b.String()
+
+			path := filepath.Join("testdata", name+"-"+themeName+".golden")
+			if *update {
+				if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if got != string(want) {
+				t.Errorf("%s differs from the rendering (go test -update rewrites it)\ngot:\n%s\nwant:\n%s", path, got, want)
+			}
+		})
+	}
+}
+
+func TestGaugeGolden(t *testing.T) {
+	var cases []goldenCase
+	for _, width := range []int{1, 10, 25} {
+		for _, ratio := range []float64{0, 0.33, 0.7, 0.9, 1.2} {
+			g := Gauge{Ratio: ratio}
+			cases = append(cases, goldenCase{fmt.Sprintf("width %d ratio %.2f", width, ratio), func() string { return g.Render(width) }})
+		}
+	}
+	cases = append(cases,
+		goldenCase{"vertical height 4 ratio 0.55", func() string { return Gauge{Ratio: 0.55, Vertical: true}.Render(4) }},
+		goldenCase{"alert role width 12", func() string { return Gauge{Ratio: 0.5, Role: theme.Alert}.Render(12) }},
+		goldenCase{"labeled width 30", func() string { return Labeled("cpu ", NewGauge(3, 4), " 75%", 30) }},
+		goldenCase{"labeled width 6", func() string { return Labeled("cpu ", NewGauge(3, 4), " 75%", 6) }},
+	)
+	golden(t, "gauge", cases)
+}
+
+func TestSparklineGolden(t *testing.T) {
+	values := []float64{0, 1, 2, 4, 8, 6, 3, 5, 7, 9, 4, 2}
+	var cases []goldenCase
+	for _, width := range []int{4, 12, 20} {
+		cases = append(cases,
+			goldenCase{fmt.Sprintf("width %d auto max", width), func() string { return Sparkline(values, 0, width) }},
+			goldenCase{fmt.Sprintf("width %d max 18", width), func() string { return Sparkline(values, 18, width) }},
+		)
+	}
+	cases = append(cases, goldenCase{"all zero", func() string { return Sparkline([]float64{0, 0, 0}, 0, 5) }})
+	golden(t, "sparkline", cases)
+}
+
+func TestChartGolden(t *testing.T) {
+	var wave []float64
+	for i := range 60 {
+		wave = append(wave, float64((i*7)%23))
+	}
+	cases := []goldenCase{
+		{"10x3 data range", func() string { return LineChart(wave, 0, 0, 10, 3) }},
+		{"20x4 fixed 0-40", func() string { return LineChart(wave, 0, 40, 20, 4) }},
+		{"40x2 fewer values than dots", func() string { return LineChart(wave[:30], 0, 0, 40, 2) }},
+		{"flat", func() string { return LineChart([]float64{5, 5, 5}, 0, 0, 6, 2) }},
+	}
+	golden(t, "chart", cases)
+}
+
+func TestTableGolden(t *testing.T) {
+	table := func(selected, height int) Table {
+		tb := NewTable("NAME", "STATE", "CPU%")
+		tb.Align = []bool{false, false, true}
+		tb.Height = height
+		tb.Selected = selected
+		tb.SetRows([][]string{
+			{"web", "running", "12.5"},
+			{"database-primary", "running", "3.0"},
+			{"cache", "exited", ""},
+			{"worker", "running", "88.1"},
+		})
+		return tb
+	}
+	scrolled := table(0, 2)
+	scrolled.Down()
+	scrolled.Down()
+	scrolled.Down()
+	cases := []goldenCase{
+		{"no selection width 40", func() string { return table(-1, 0).Render(40) }},
+		{"selected row 1 width 40", func() string { return table(1, 0).Render(40) }},
+		{"width 20 shrinks the widest column", func() string { return table(1, 0).Render(20) }},
+		{"height 2 scrolled to the last row", func() string { return scrolled.Render(40) }},
+		{"height 6 pads", func() string { return table(0, 6).Render(40) }},
+	}
+	golden(t, "table", cases)
+}
+
+func TestKeyValuesGolden(t *testing.T) {
+	rows := []KV{
+		{Key: "OS", Value: "Arch Linux rolling"},
+		{Key: "Kernel", Value: "6.9.1-arch1-1"},
+		{},
+		{Key: "Uptime", Value: "3 days, 4 hours, 12 minutes", Role: theme.Muted},
+		{Key: "Status", Value: "degraded", Role: theme.Alert},
+	}
+	cases := []goldenCase{
+		{"no limit", func() string { return KeyValues(rows, 0) }},
+		{"width 40", func() string { return KeyValues(rows, 40) }},
+		{"width 16", func() string { return KeyValues(rows, 16) }},
+	}
+	golden(t, "kv", cases)
+}
+
+func TestTableSelection(t *testing.T) {
+	tb := NewTable("A")
+	tb.SetRows([][]string{{"1"}, {"2"}})
+	tb.Down()
+	if tb.Selected != -1 || tb.SelectedRow() != nil {
+		t.Errorf("NewTable selects row %d; selection should be off", tb.Selected)
+	}
+
+	tb.Selected = 0
+	tb.SetRows(nil)
+	if tb.Selected != 0 {
+		t.Errorf("empty rows turned selection off (Selected = %d)", tb.Selected)
+	}
+	tb.SetRows([][]string{{"1"}, {"2"}})
+	tb.Down()
+	if row := tb.SelectedRow(); len(row) != 1 || row[0] != "2" {
+		t.Errorf("selected row = %v, want 2", row)
+	}
+}
@@ -0,0 +1,43 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/antiloger/termctlr/theme"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// KV is one row of a key/value list. An empty Key renders a blank line.
+type KV struct {
+	Key   string
+	Value string
+	Role  theme.Role // value color, Text when empty
+}
+
+// KeyValues renders aligned "key: value" rows. Values are truncated so each
+// row fits in width; width <= 0 means no limit.
+func KeyValues(rows []KV, width int) string {
+	t := theme.Current()
+	keyW := 0
+	for _, r := range rows {
+		keyW = max(keyW, ansi.StringWidth(r.Key))
+	}
+	label := t.Style(theme.Accent).Bold(true)
+
+	lines := make([]string, len(rows))
+	for i, r := range rows {
+		if r.Key == "" {
+			continue
+		}
+		role := r.Role
+		if role == "" {
+			role = theme.Text
+		}
+		value := r.Value
+		if width > 0 {
+			value = ansi.Truncate(value, max(width-keyW-2, 1), "…")
+		}
+		lines[i] = label.Render(Fit(r.Key+":", keyW+1)) + " " + t.Style(role).Render(value)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,182 @@
+package components
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/antiloger/termctlr/theme"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	logKept  = 1000 // lines kept while following
+	logShown = 20   // lines in the overlay
+	logWidth = 100
+)
+
+// LogView follows a stream of log lines (a container's logs, a unit's
+// journal) into a bounded buffer and draws it as an overlay box. Widgets
+// keep the pointer while the overlay is open and the scroll position in
+// their own model.
+type LogView struct {
+	title   string
+	endText string // status once the stream ended by itself
+	cancel  context.CancelFunc
+	updates chan struct{} // signalled (never blocking) on new lines
+
+	mu    sync.Mutex
+	lines []string
+	err   error
+	done  bool
+}
+
+// FollowLog starts reading the stream open returns. Cancelling its
+// context, on Close, ends the stream without an error.
+func FollowLog(title, endText string, open func(ctx context.Context) (io.ReadCloser, error)) *LogView {
+	ctx, cancel := context.WithCancel(context.Background())
+	v := &LogView{title: title, endText: endText, cancel: cancel, updates: make(chan struct{}, 1)}
+	go v.run(ctx, open)
+	return v
+}
+
+func (v *LogView) run(ctx context.Context, open func(ctx context.Context) (io.ReadCloser, error)) {
+	err := v.read(ctx, open)
+	if ctx.Err() != nil || errors.Is(err, io.EOF) {
+		err = nil // closed by the user
+	}
+	v.mu.Lock()
+	v.err, v.done = err, true
+	v.mu.Unlock()
+	v.notify()
+}
+
+func (v *LogView) read(ctx context.Context, open func(ctx context.Context) (io.ReadCloser, error)) error {
+	r, err := open(ctx)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		// escapes would break the overlay box
+		line := strings.ReplaceAll(strings.TrimRight(sc.Text(), "\r"), "\t", "    ")
+		v.add(ansi.Strip(line))
+	}
+	return sc.Err()
+}
+
+func (v *LogView) add(line string) {
+	v.mu.Lock()
+	v.lines = append(v.lines, line)
+	if len(v.lines) > logKept {
+		v.lines = v.lines[len(v.lines)-logKept:]
+	}
+	v.mu.Unlock()
+	v.notify()
+}
+
+func (v *LogView) notify() {
+	select {
+	case v.updates <- struct{}{}:
+	default:
+	}
+}
+
+// Lines returns the lines kept so far.
+func (v *LogView) Lines() []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.lines
+}
+
+// maxScroll is how far up the log can be scrolled.
+func (v *LogView) maxScroll() int {
+	return max(len(v.Lines())-logShown, 0)
+}
+
+// Close stops following.
+func (v *LogView) Close() {
+	v.cancel()
+}
+
+// LogMsg says a followed log has new lines or ended.
+type LogMsg struct{ View *LogView }
+
+// WaitLog wakes the UI on new lines; re-issue it after each one.
+func WaitLog(v *LogView) tea.Cmd {
+	return func() tea.Msg {
+		<-v.updates
+		return LogMsg{View: v}
+	}
+}
+
+// HandleKey moves scroll, the lines up from the bottom, for a key
+// pressed while the overlay is open. closed reports a key that closes
+// it; the caller then calls Close and drops the view.
+func (v *LogView) HandleKey(key string, scroll int) (newScroll int, closed bool) {
+	switch key {
+	case "esc", "l", "q":
+		return 0, true
+	case "up", "k":
+		return min(scroll+1, v.maxScroll()), false
+	case "down", "j":
+		return max(scroll-1, 0), false
+	case "pgup":
+		return min(scroll+logShown, v.maxScroll()), false
+	case "pgdown":
+		return max(scroll-logShown, 0), false
+	case "G", "end":
+		return 0, false
+	}
+	return scroll, false
+}
+
+// Render draws the overlay box, scrolled up scroll lines.
+func (v *LogView) Render(scroll int) string {
+	t := theme.Current()
+	v.mu.Lock()
+	lines := v.lines
+	err, done := v.err, v.done
+	v.mu.Unlock()
+
+	// scroll counts lines up from the bottom; 0 follows new output
+	scroll = min(scroll, max(len(lines)-logShown, 0))
+	end := len(lines) - scroll
+	shown := lines[max(end-logShown, 0):end]
+	body := make([]string, logShown)
+	for i := range body {
+		if i < len(shown) {
+			body[i] = Fit(shown[i], logWidth)
+		} else {
+			body[i] = Fit("", logWidth)
+		}
+	}
+
+	status := "following"
+	switch {
+	case err != nil:
+		status = t.Style(theme.Alert).Render(err.Error())
+	case done:
+		status = v.endText
+	case scroll > 0:
+		status = fmt.Sprintf("scrolled up %d lines · G follow", scroll)
+	}
+	return lipgloss.NewStyle().
+		Border(t.Border, true).
+		BorderForeground(t.Color(theme.Focus)).
+		Padding(0, 1).
+		Render(lipgloss.JoinVertical(lipgloss.Left,
+			t.Style(theme.Accent).Bold(true).Render(v.title),
+			strings.Join(body, "\n"),
+			t.Style(theme.Muted).Render(status),
+			t.Style(theme.Muted).Render("↑ ↓ pgup pgdn scroll · G follow · l/esc close"),
+		))
+}
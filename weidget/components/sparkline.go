@@ -0,0 +1,38 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/antiloger/termctlr/theme"
+)
+
+var sparkLevels = []string{" ", "▁", "▂", "▃", "▄", "▅", "▆", "▇", "█"}
+
+// Sparkline draws the last width values as a one line chart. Values are
+// scaled against max; a max of 0 scales against the largest value shown.
+func Sparkline(values []float64, max float64, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+	if max <= 0 {
+		for _, v := range values {
+			if v > max {
+				max = v
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.Repeat(" ", width-len(values)))
+	for _, v := range values {
+		level := 0
+		if max > 0 {
+			level = int(clamp01(v/max)*float64(len(sparkLevels)-1) + 0.5)
+		}
+		b.WriteString(sparkLevels[level])
+	}
+	return theme.Current().Style(theme.Accent).Render(b.String())
+}
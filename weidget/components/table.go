@@ -0,0 +1,153 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/antiloger/termctlr/theme"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Table is a scrollable table with an optional selected row. Tables that
+// select set Selected to 0 after NewTable.
+// It is a value type like the widgets that embed it: mutate, then store.
+type Table struct {
+	Headers  []string
+	Rows     [][]string
+	Align    []bool // per column, true = right aligned
+	Selected int    // -1 disables selection
+	Height   int    // visible rows, not counting the header; 0 = all
+	offset   int
+}
+
+func NewTable(headers ...string) Table {
+	return Table{Headers: headers, Selected: -1}
+}
+
+// SetRows replaces the rows, keeping the selection in range.
+func (t *Table) SetRows(rows [][]string) {
+	t.Rows = rows
+	if t.Selected >= len(rows) {
+		t.Selected = max(len(rows)-1, 0)
+	}
+	t.scrollToSelection()
+}
+
+func (t *Table) Up() {
+	if t.Selected > 0 {
+		t.Selected--
+	}
+	t.scrollToSelection()
+}
+
+func (t *Table) Down() {
+	if t.Selected >= 0 && t.Selected < len(t.Rows)-1 {
+		t.Selected++
+	}
+	t.scrollToSelection()
+}
+
+// SelectedRow returns the selected row, or nil.
+func (t Table) SelectedRow() []string {
+	if t.Selected < 0 || t.Selected >= len(t.Rows) {
+		return nil
+	}
+	return t.Rows[t.Selected]
+}
+
+func (t *Table) scrollToSelection() {
+	if t.Height <= 0 || t.Selected < 0 {
+		return
+	}
+	if t.Selected < t.offset {
+		t.offset = t.Selected
+	}
+	if t.Selected >= t.offset+t.Height {
+		t.offset = t.Selected - t.Height + 1
+	}
+	t.offset = max(0, min(t.offset, len(t.Rows)-t.Height))
+}
+
+// Render draws the visible part of the table in width cells. Columns get
+// their natural width; when that doesn't fit, the widest column shrinks.
+func (t Table) Render(width int) string {
+	th := theme.Current()
+	cols := len(t.Headers)
+	for _, r := range t.Rows {
+		cols = max(cols, len(r))
+	}
+	if cols == 0 || width <= 0 {
+		return ""
+	}
+
+	widths := make([]int, cols)
+	measure := func(r []string) {
+		for i, c := range r {
+			widths[i] = max(widths[i], ansi.StringWidth(c))
+		}
+	}
+	measure(t.Headers)
+	for _, r := range t.Rows {
+		measure(r)
+	}
+	shrink(widths, width-(cols-1))
+
+	row := func(cells []string) string {
+		parts := make([]string, cols)
+		for i := range cols {
+			c := ""
+			if i < len(cells) {
+				c = cells[i]
+			}
+			if i < len(t.Align) && t.Align[i] {
+				parts[i] = FitLeft(c, widths[i])
+			} else {
+				parts[i] = Fit(c, widths[i])
+			}
+		}
+		return strings.Join(parts, " ")
+	}
+
+	var lines []string
+	if len(t.Headers) > 0 {
+		lines = append(lines, th.Style(theme.Muted).Bold(true).Render(row(t.Headers)))
+	}
+
+	start, end := 0, len(t.Rows)
+	if t.Height > 0 {
+		start = t.offset
+		end = min(start+t.Height, len(t.Rows))
+	}
+	selected := th.Style(theme.Accent).Reverse(true)
+	for i := start; i < end; i++ {
+		line := row(t.Rows[i])
+		if i == t.Selected {
+			line = selected.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	for i := end - start; t.Height > 0 && i < t.Height; i++ {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// shrink narrows the widest columns until the total fits in avail.
+func shrink(widths []int, avail int) {
+	total := 0
+	for _, w := range widths {
+		total += w
+	}
+	for total > avail {
+		widest := 0
+		for i, w := range widths {
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= 1 {
+			return
+		}
+		widths[widest]--
+		total--
+	}
+}
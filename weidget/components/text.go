@@ -0,0 +1,45 @@
+// Package components holds width-aware building blocks shared by widgets:
+// gauges, sparklines, charts, tables and key/value lists. Everything renders
+// to a plain string sized to the width it is given and styled from the
+// current theme.
+package components
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Fit truncates s to width cells (adding "…") or pads it with spaces.
+func Fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	w := ansi.StringWidth(s)
+	if w > width {
+		return ansi.Truncate(s, width, "…")
+	}
+	return s + strings.Repeat(" ", width-w)
+}
+
+// FitLeft is Fit with the padding on the left, for right aligned columns.
+func FitLeft(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	w := ansi.StringWidth(s)
+	if w > width {
+		return ansi.Truncate(s, width, "…")
+	}
+	return strings.Repeat(" ", width-w) + s
+}
+
+func clamp01(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
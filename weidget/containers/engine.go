@@ -0,0 +1,194 @@
+// Package containers lists and controls Docker or Podman containers
+// through the Engine API on the local unix socket.
+package containers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	requestTimeout = 5 * time.Second
+	// actionTimeout covers stop and restart, which give the container ten
+	// seconds to exit before killing it.
+	actionTimeout = 30 * time.Second
+)
+
+// SocketPath picks the Engine API socket: the configured path, a unix://
+// $DOCKER_HOST, the Docker socket, or the rootless Podman socket.
+func SocketPath(configured string) string {
+	if configured != "" {
+		return strings.TrimPrefix(configured, "unix://")
+	}
+	if h := os.Getenv("DOCKER_HOST"); strings.HasPrefix(h, "unix://") {
+		return strings.TrimPrefix(h, "unix://")
+	}
+	candidates := []string{"/var/run/docker.sock"}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		candidates = append(candidates, filepath.Join(dir, "podman", "podman.sock"))
+	}
+	candidates = append(candidates, "/run/podman/podman.sock")
+	for _, c := range candidates {
+		if _, err := os.Stat(c); err == nil {
+			return c
+		}
+	}
+	return candidates[0]
+}
+
+// Client talks HTTP to the Engine API over a unix socket. Docker and
+// Podman's compatibility API both serve the endpoints used here.
+type Client struct {
+	Socket string
+	http   *http.Client
+}
+
+func NewClient(socket string) *Client {
+	dialer := net.Dialer{Timeout: requestTimeout}
+	return &Client{
+		Socket: socket,
+		http: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dialer.DialContext(ctx, "unix", socket)
+				},
+			},
+		},
+	}
+}
+
+// APIError is an error response from the engine.
+type APIError struct {
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("engine: HTTP %d", e.Status)
+	}
+	return "engine: " + e.Message
+}
+
+// Container is one entry of GET /containers/json.
+type Container struct {
+	ID     string `json:"Id"`
+	Names  []string
+	Image  string
+	State  string // running, exited, paused, created, …
+	Status string // human readable, "Up 2 hours"
+	Ports  []Port
+}
+
+type Port struct {
+	IP          string
+	PrivatePort uint16
+	PublicPort  uint16
+	Type        string
+}
+
+// Name is the container name without the leading slash.
+func (c Container) Name() string {
+	if len(c.Names) == 0 {
+		return c.ID[:min(12, len(c.ID))]
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
+
+// PortsString lists published ports as host→container/proto.
+func (c Container) PortsString() string {
+	var out []string
+	seen := map[string]bool{}
+	for _, p := range c.Ports {
+		s := fmt.Sprintf("%d/%s", p.PrivatePort, p.Type)
+		if p.PublicPort != 0 {
+			s = fmt.Sprintf("%d→%s", p.PublicPort, s)
+		}
+		if !seen[s] { // one entry per address family
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return strings.Join(out, ",")
+}
+
+// do sends a request; error statuses become *APIError.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values) (*http.Response, error) {
+	u := url.URL{Scheme: "http", Host: "engine", Path: path, RawQuery: query.Encode()}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		var body struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&body)
+		return nil, &APIError{Status: resp.StatusCode, Message: body.Message}
+	}
+	return resp, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, v any) error {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	resp, err := c.do(ctx, http.MethodGet, path, query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// List returns all containers, stopped ones included.
+func (c *Client) List(ctx context.Context) ([]Container, error) {
+	var list []Container
+	err := c.getJSON(ctx, "/containers/json", url.Values{"all": {"1"}}, &list)
+	return list, err
+}
+
+// Action is a container lifecycle operation.
+type Action string
+
+const (
+	Start   Action = "start"
+	Stop    Action = "stop"
+	Restart Action = "restart"
+)
+
+// Do applies an action. Starting a running container (304) is not an
+// error.
+func (c *Client) Do(ctx context.Context, id string, a Action) error {
+	ctx, cancel := context.WithTimeout(ctx, actionTimeout)
+	defer cancel()
+	resp, err := c.do(ctx, http.MethodPost, "/containers/"+url.PathEscape(id)+"/"+string(a), nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// tty reports whether the container has a terminal; its logs are then
+// raw instead of multiplexed.
+func (c *Client) tty(ctx context.Context, id string) (bool, error) {
+	var info struct {
+		Config struct{ Tty bool }
+	}
+	err := c.getJSON(ctx, "/containers/"+url.PathEscape(id)+"/json", nil, &info)
+	return info.Config.Tty, err
+}
@@ -0,0 +1,291 @@
+package containers
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antiloger/termctlr/weidget/components"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// fakeEngine serves the Engine API endpoints the widget uses on a unix
+// socket. Container "web" runs without a terminal, "shell" with one.
+func fakeEngine(t *testing.T) *Client {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "engine.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /containers/json", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("all") != "1" {
+			t.Errorf("list query = %q, want all=1", r.URL.RawQuery)
+		}
+		io.WriteString(w, `[
+			{"Id": "aaaaaaaaaaaaaaaa", "Names": ["/web"], "Image": "nginx", "State": "running", "Status": "Up 2 hours",
+			 "Ports": [{"IP": "0.0.0.0", "PrivatePort": 80, "PublicPort": 8080, "Type": "tcp"},
+			           {"IP": "::", "PrivatePort": 80, "PublicPort": 8080, "Type": "tcp"}]},
+			{"Id": "bbbbbbbbbbbbbbbb", "Names": ["/shell"], "Image": "alpine", "State": "exited", "Status": "Exited (0)"}
+		]`)
+	})
+	mux.HandleFunc("GET /containers/{id}/json", func(w http.ResponseWriter, r *http.Request) {
+		switch r.PathValue("id") {
+		case "web":
+			io.WriteString(w, `{"Config": {"Tty": false}}`)
+		case "shell":
+			io.WriteString(w, `{"Config": {"Tty": true}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"message": "No such container: `+r.PathValue("id")+`"}`)
+		}
+	})
+	mux.HandleFunc("POST /containers/{id}/{action}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.PathValue("id") {
+		case "running":
+			w.WriteHeader(http.StatusNotModified) // already started
+		case "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+			io.WriteString(w, `{"message": "cannot `+r.PathValue("action")+`: device busy"}`)
+		case "silent":
+			w.WriteHeader(http.StatusConflict)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	mux.HandleFunc("GET /containers/{id}/logs", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("follow") != "1" || q.Get("tail") != fmt.Sprint(logTailLines) {
+			t.Errorf("logs query = %q", r.URL.RawQuery)
+		}
+		switch r.PathValue("id") {
+		case "web":
+			w.Write(frame(1, "GET / 200\n"))
+			w.Write(frame(2, "warn: \x1b[33mslow\x1b[0m\tupstream\n"))
+			w.Write(frame(1, "GET /favicon.ico 404\r\n"))
+		case "shell":
+			io.WriteString(w, "$ ls\r\nbin  etc\r\n")
+		}
+	})
+	mux.HandleFunc("GET /containers/{id}/stats", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("stream") != "1" {
+			t.Errorf("stats query = %q, want stream=1", r.URL.RawQuery)
+		}
+		for i := range 2 {
+			fmt.Fprintf(w, `{"cpu_stats": {"cpu_usage": {"total_usage": %d}, "system_cpu_usage": %d, "online_cpus": 2},
+				"precpu_stats": {"cpu_usage": {"total_usage": 0}, "system_cpu_usage": 0},
+				"memory_stats": {"usage": 300, "limit": 1000, "stats": {"inactive_file": 100}}}`+"\n", 100*(i+1), 1000*(i+1))
+			w.(http.Flusher).Flush()
+		}
+		<-r.Context().Done()
+	})
+	srv := httptest.NewUnstartedServer(mux)
+	srv.Listener.Close()
+	srv.Listener = l
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return NewClient(socket)
+}
+
+// frame is one multiplexed log frame of a non-TTY container.
+func frame(stream byte, s string) []byte {
+	hdr := []byte{stream, 0, 0, 0, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(hdr[4:], uint32(len(s)))
+	return append(hdr, s...)
+}
+
+func TestList(t *testing.T) {
+	c := fakeEngine(t)
+	list, err := c.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("got %d containers, want 2", len(list))
+	}
+	web := list[0]
+	if web.Name() != "web" || web.State != "running" || web.Image != "nginx" {
+		t.Errorf("web = %+v", web)
+	}
+	if got := web.PortsString(); got != "8080→80/tcp" {
+		t.Errorf("ports = %q, want one entry for both address families", got)
+	}
+	if list[1].Name() != "shell" || list[1].PortsString() != "" {
+		t.Errorf("shell = %+v", list[1])
+	}
+}
+
+func TestDo(t *testing.T) {
+	c := fakeEngine(t)
+	ctx := context.Background()
+	if err := c.Do(ctx, "web", Restart); err != nil {
+		t.Errorf("restart: %v", err)
+	}
+	if err := c.Do(ctx, "running", Start); err != nil {
+		t.Errorf("start of a running container: %v, want 304 taken as success", err)
+	}
+
+	err := c.Do(ctx, "broken", Stop)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusInternalServerError {
+		t.Fatalf("err = %v, want *APIError 500", err)
+	}
+	if err.Error() != "engine: cannot stop: device busy" {
+		t.Errorf("err = %q, want the engine's message", err)
+	}
+
+	err = c.Do(ctx, "silent", Start)
+	if err == nil || err.Error() != "engine: HTTP 409" {
+		t.Errorf("err = %v, want the status for an empty body", err)
+	}
+}
+
+func TestTTYUnknownContainer(t *testing.T) {
+	c := fakeEngine(t)
+	_, err := c.tty(context.Background(), "gone")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound || apiErr.Message != "No such container: gone" {
+		t.Errorf("err = %v", err)
+	}
+}
+
+// readLog follows a container's log until the engine ends it.
+func readLog(t *testing.T, c *Client, id string) []string {
+	t.Helper()
+	v := followLogs(c, Container{ID: id, Names: []string{"/" + id}})
+	defer v.Close()
+	timeout := time.After(5 * time.Second)
+	for {
+		done := make(chan struct{})
+		go func() { components.WaitLog(v)(); close(done) }()
+		select {
+		case <-done:
+		case <-timeout:
+			t.Fatalf("log of %s did not end; have %q", id, v.Lines())
+		}
+		if out := ansi.Strip(v.Render(0)); strings.Contains(out, "log ended") {
+			return v.Lines()
+		} else if strings.Contains(out, "engine:") {
+			t.Fatalf("log of %s failed:\n%s", id, out)
+		}
+	}
+}
+
+func TestLogsMultiplexed(t *testing.T) {
+	got := readLog(t, fakeEngine(t), "web")
+	want := []string{"GET / 200", "warn: slow    upstream", "GET /favicon.ico 404"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+}
+
+func TestLogsTTY(t *testing.T) {
+	got := readLog(t, fakeEngine(t), "shell")
+	want := []string{"$ ls", "bin  etc"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+}
+
+func TestLogsUnknownContainer(t *testing.T) {
+	v := followLogs(fakeEngine(t), Container{ID: "gone"})
+	defer v.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(ansi.Strip(v.Render(0)), "engine: No such container: gone") {
+		if time.Now().After(deadline) {
+			t.Fatalf("overlay does not show the engine's error:\n%s", ansi.Strip(v.Render(0)))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDemux(t *testing.T) {
+	var in bytes.Buffer
+	in.Write(frame(1, "out "))
+	in.Write(frame(2, "err\n"))
+	in.Write(frame(1, ""))
+	in.Write(frame(1, "tail"))
+	var out bytes.Buffer
+	if err := demux(&in, &out); !errors.Is(err, io.EOF) {
+		t.Errorf("err = %v, want EOF at the end of the stream", err)
+	}
+	if out.String() != "out err\ntail" {
+		t.Errorf("out = %q", out.String())
+	}
+
+	short := bytes.NewReader(frame(1, "cut off")[:10])
+	if err := demux(short, io.Discard); !errors.Is(err, io.EOF) {
+		t.Errorf("truncated frame: err = %v, want EOF", err)
+	}
+}
+
+func TestSample(t *testing.T) {
+	decode := func(s string) statsJSON {
+		var v statsJSON
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		name  string
+		json  string
+		cpu   float64
+		mem   uint64
+		limit uint64
+	}{
+		{"cgroup v2", `{"cpu_stats": {"cpu_usage": {"total_usage": 400}, "system_cpu_usage": 2000, "online_cpus": 4},
+			"precpu_stats": {"cpu_usage": {"total_usage": 200}, "system_cpu_usage": 1000},
+			"memory_stats": {"usage": 500, "limit": 2000, "stats": {"inactive_file": 200}}}`, 80, 300, 2000},
+		{"cgroup v1 without online_cpus", `{"cpu_stats": {"cpu_usage": {"total_usage": 300, "percpu_usage": [1, 2]}, "system_cpu_usage": 1000},
+			"precpu_stats": {"cpu_usage": {"total_usage": 200}, "system_cpu_usage": 500},
+			"memory_stats": {"usage": 500, "limit": 2000, "stats": {"total_inactive_file": 100, "cache": 400}}}`, 40, 400, 2000},
+		{"first reading", `{"cpu_stats": {"cpu_usage": {"total_usage": 400}, "system_cpu_usage": 2000, "online_cpus": 4},
+			"memory_stats": {"usage": 500, "stats": {"cache": 900}}}`, 80, 500, 0},
+		{"no progress", `{"cpu_stats": {"cpu_usage": {"total_usage": 400}, "system_cpu_usage": 2000, "online_cpus": 4},
+			"precpu_stats": {"cpu_usage": {"total_usage": 400}, "system_cpu_usage": 2000}}`, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := decode(tt.json).sample("id")
+			if s.ID != "id" || s.CPU != tt.cpu || s.Mem != tt.mem || s.MemLimit != tt.limit {
+				t.Errorf("sample = %+v, want CPU %v Mem %d limit %d", s, tt.cpu, tt.mem, tt.limit)
+			}
+		})
+	}
+}
+
+func TestStatsStream(t *testing.T) {
+	h := newStatsHub(fakeEngine(t))
+	h.sync([]string{"web"})
+	defer h.stop()
+
+	var got []Sample
+	timeout := time.After(5 * time.Second)
+	for len(got) < 2 {
+		select {
+		case s := <-h.samples:
+			got = append(got, s)
+		case <-timeout:
+			t.Fatalf("got %d samples, want 2", len(got))
+		}
+	}
+	for i, s := range got {
+		want := Sample{ID: "web", CPU: 20, Mem: 200, MemLimit: 1000}
+		if s != want {
+			t.Errorf("sample %d = %+v, want %+v", i, s, want)
+		}
+	}
+}
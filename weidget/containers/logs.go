@@ -0,0 +1,80 @@
+package containers
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/antiloger/termctlr/weidget/components"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const logTailLines = 200 // asked for when the overlay opens
+
+// followLogs starts following a container's stdout and stderr.
+func followLogs(c *Client, ctr Container) *components.LogView {
+	id := ctr.ID
+	return components.FollowLog("logs · "+ctr.Name(), "log ended", func(ctx context.Context) (io.ReadCloser, error) {
+		return c.logs(ctx, id)
+	})
+}
+
+// logs opens the followed log of a container, with the frame headers of
+// non-TTY containers removed.
+func (c *Client) logs(ctx context.Context, id string) (io.ReadCloser, error) {
+	tty, err := c.tty(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	q := url.Values{"follow": {"1"}, "stdout": {"1"}, "stderr": {"1"}, "tail": {fmt.Sprint(logTailLines)}}
+	resp, err := c.do(ctx, http.MethodGet, "/containers/"+url.PathEscape(id)+"/logs", q)
+	if err != nil {
+		return nil, err
+	}
+	if tty {
+		return resp.Body, nil
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		defer resp.Body.Close()
+		pw.CloseWithError(demux(resp.Body, pw))
+	}()
+	return pr, nil
+}
+
+// demux strips the 8 byte frame headers (stream, 0, 0, 0, big endian
+// length) that non-TTY containers' logs are sent in.
+func demux(r io.Reader, w io.Writer) error {
+	var hdr [8]byte
+	for {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return err
+		}
+		n := int64(binary.BigEndian.Uint32(hdr[4:]))
+		if _, err := io.CopyN(w, r, n); err != nil {
+			return err
+		}
+	}
+}
+
+// updateLogs handles keys while the log overlay is open.
+func (m Model) updateLogs(msg tea.KeyMsg) (Model, tea.Cmd) {
+	scroll, closed := m.logs.HandleKey(msg.String(), m.scroll)
+	if closed {
+		m.logs.Close()
+		m.logs = nil
+	}
+	m.scroll = scroll
+	return m, nil
+}
+
+// Overlay shows the followed log over the dashboard while it is open.
+func (m Model) Overlay() (string, bool) {
+	if m.logs == nil {
+		return "", false
+	}
+	return m.logs.Render(m.scroll), true
+}
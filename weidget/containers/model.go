@@ -0,0 +1,212 @@
+package containers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/antiloger/termctlr/config"
+	"github.com/antiloger/termctlr/types"
+	"github.com/antiloger/termctlr/weidget/components"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// listEvery is how many shared ticks pass between container list reads;
+// stats stream in between.
+const listEvery = 3
+
+// Model is the containers widget.
+type Model struct {
+	client     *Client
+	hub        *statsHub
+	containers []Container
+	stats      map[string]Sample
+	table      components.Table
+	width      int
+	pos        types.Position
+	err        error // last list error; the list is kept but stale
+	actionErr  error
+	listed     bool // the first list arrived
+	listing    bool // a list request is in flight
+	ticks      int
+	pending    string // "stopping web…" while an action runs
+
+	logs   *components.LogView // followed log, nil when the overlay is closed
+	scroll int                 // log lines scrolled up from the bottom
+}
+
+func NewModel(cfg config.ContainersConfig) Model {
+	client := NewClient(SocketPath(cfg.Socket))
+	table := components.NewTable("NAME", "STATE", "CPU%", "MEM", "PORTS")
+	table.Align = []bool{false, false, true, true, false}
+	table.Selected = 0
+	return Model{
+		client: client,
+		hub:    newStatsHub(client),
+		stats:  map[string]Sample{},
+		table:  table,
+		width:  60,
+	}
+}
+
+// listMsg is the result of a container list request.
+type listMsg struct {
+	containers []Container
+	err        error
+}
+
+// actionMsg is the result of start/stop/restart.
+type actionMsg struct {
+	name   string
+	action Action
+	err    error
+}
+
+func (m Model) list() tea.Cmd {
+	c := m.client
+	return func() tea.Msg {
+		list, err := c.List(context.Background())
+		return listMsg{containers: list, err: err}
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return tea.Batch(m.list(), waitStats(m.hub))
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case types.SizeMsg:
+		m.width = msg.Width
+		m.table.Height = max(msg.Height-3, 1) // header, status and help lines
+		return m, nil
+	case types.TickMsg:
+		m.ticks++
+		if m.ticks%listEvery != 0 || m.listing {
+			return m, nil
+		}
+		m.listing = true
+		return m, m.list()
+	case listMsg:
+		return m.handleList(msg), nil
+	case statsMsg:
+		for _, s := range msg {
+			m.stats[s.ID] = s
+		}
+		m.setRows()
+		return m, waitStats(m.hub)
+	case actionMsg:
+		m.pending = ""
+		m.actionErr = nil
+		if msg.err != nil {
+			m.actionErr = fmt.Errorf("%s %s: %w", msg.action, msg.name, msg.err)
+		}
+		m.listing = true
+		return m, m.list()
+	case components.LogMsg:
+		if msg.View != m.logs {
+			return m, nil // closed since
+		}
+		return m, components.WaitLog(m.logs)
+	case tea.KeyMsg:
+		if m.logs != nil {
+			return m.updateLogs(msg)
+		}
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m Model) handleList(msg listMsg) Model {
+	m.listing = false
+	m.err = msg.err
+	if msg.err != nil {
+		m.hub.stop()
+		return m
+	}
+	m.listed = true
+	prev, _ := m.selected()
+	m.containers = msg.containers
+	sort.Slice(m.containers, func(i, j int) bool { return m.containers[i].Name() < m.containers[j].Name() })
+	for i, c := range m.containers {
+		if c.ID == prev.ID {
+			m.table.Selected = i // follow the container, not the row
+		}
+	}
+	var running []string
+	seen := map[string]bool{}
+	for _, c := range m.containers {
+		seen[c.ID] = true
+		if c.State == "running" {
+			running = append(running, c.ID)
+		} else {
+			delete(m.stats, c.ID) // no stale numbers for stopped containers
+		}
+	}
+	for id := range m.stats {
+		if !seen[id] {
+			delete(m.stats, id)
+		}
+	}
+	m.hub.sync(running)
+	m.setRows()
+	return m
+}
+
+func (m Model) handleKey(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		m.table.Up()
+		return m, nil
+	case "down", "j":
+		m.table.Down()
+		return m, nil
+	}
+
+	c, ok := m.selected()
+	if !ok {
+		return m, nil
+	}
+	switch msg.String() {
+	case "s":
+		return m.do(c, Start)
+	case "x":
+		return m.do(c, Stop)
+	case "r":
+		return m.do(c, Restart)
+	case "l":
+		m.logs, m.scroll = followLogs(m.client, c), 0
+		return m, components.WaitLog(m.logs)
+	}
+	return m, nil
+}
+
+// do runs an action off the UI goroutine; the list is re-read when it
+// finishes.
+func (m Model) do(c Container, a Action) (Model, tea.Cmd) {
+	if m.pending != "" {
+		return m, nil // one at a time
+	}
+	m.pending = fmt.Sprintf("%s %s…", a, c.Name())
+	client := m.client
+	return m, func() tea.Msg {
+		err := client.Do(context.Background(), c.ID, a)
+		return actionMsg{name: c.Name(), action: a, err: err}
+	}
+}
+
+func (m Model) selected() (Container, bool) {
+	if m.table.Selected < 0 || m.table.Selected >= len(m.containers) {
+		return Container{}, false
+	}
+	return m.containers[m.table.Selected], true
+}
+
+func (m Model) SetPosition(x, y int) {
+	m.pos.X = x
+	m.pos.Y = y
+}
+
+func (m Model) Name() string {
+	return "containers"
+}
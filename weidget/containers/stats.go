@@ -0,0 +1,155 @@
+package containers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Sample is one stats reading of a container.
+type Sample struct {
+	ID       string
+	CPU      float64 // percent of one CPU, like `docker stats`
+	Mem      uint64  // bytes, without page cache
+	MemLimit uint64
+}
+
+type cpuStats struct {
+	CPUUsage struct {
+		TotalUsage  uint64   `json:"total_usage"`
+		PercpuUsage []uint64 `json:"percpu_usage"`
+	} `json:"cpu_usage"`
+	SystemUsage uint64 `json:"system_cpu_usage"`
+	OnlineCPUs  uint32 `json:"online_cpus"`
+}
+
+type statsJSON struct {
+	CPUStats    cpuStats `json:"cpu_stats"`
+	PreCPUStats cpuStats `json:"precpu_stats"`
+	MemoryStats struct {
+		Usage uint64            `json:"usage"`
+		Limit uint64            `json:"limit"`
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"`
+}
+
+// sample computes CPU% against the previous reading the engine includes,
+// and memory the way the docker CLI does.
+func (s statsJSON) sample(id string) Sample {
+	out := Sample{ID: id, MemLimit: s.MemoryStats.Limit}
+
+	cpuDelta := float64(s.CPUStats.CPUUsage.TotalUsage) - float64(s.PreCPUStats.CPUUsage.TotalUsage)
+	sysDelta := float64(s.CPUStats.SystemUsage) - float64(s.PreCPUStats.SystemUsage)
+	cpus := float64(s.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(s.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && sysDelta > 0 {
+		out.CPU = cpuDelta / sysDelta * cpus * 100
+	}
+
+	// cgroup v2 reports inactive_file, v1 total_inactive_file or cache
+	out.Mem = s.MemoryStats.Usage
+	for _, k := range []string{"inactive_file", "total_inactive_file", "cache"} {
+		if v, ok := s.MemoryStats.Stats[k]; ok && v < out.Mem {
+			out.Mem -= v
+			break
+		}
+	}
+	return out
+}
+
+// statsHub keeps one streaming stats request per running container and
+// merges their samples.
+type statsHub struct {
+	client  *Client
+	samples chan Sample
+
+	mu      sync.Mutex
+	streams map[string]*stream
+}
+
+type stream struct{ cancel context.CancelFunc }
+
+func newStatsHub(c *Client) *statsHub {
+	return &statsHub{client: c, samples: make(chan Sample, 256), streams: map[string]*stream{}}
+}
+
+// sync starts streams for new running containers and ends those of
+// containers that stopped or went away. A stream that failed is restarted
+// by the next sync.
+func (h *statsHub) sync(running []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	want := map[string]bool{}
+	for _, id := range running {
+		want[id] = true
+		if _, ok := h.streams[id]; !ok {
+			ctx, cancel := context.WithCancel(context.Background())
+			st := &stream{cancel: cancel}
+			h.streams[id] = st
+			go h.stream(ctx, id, st)
+		}
+	}
+	for id, st := range h.streams {
+		if !want[id] {
+			st.cancel()
+			delete(h.streams, id)
+		}
+	}
+}
+
+// stop ends all streams.
+func (h *statsHub) stop() {
+	h.sync(nil)
+}
+
+func (h *statsHub) stream(ctx context.Context, id string, st *stream) {
+	defer func() {
+		h.mu.Lock()
+		if h.streams[id] == st {
+			delete(h.streams, id)
+		}
+		h.mu.Unlock()
+		st.cancel()
+	}()
+	resp, err := h.client.do(ctx, http.MethodGet, "/containers/"+url.PathEscape(id)+"/stats", url.Values{"stream": {"1"}})
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var s statsJSON
+		if err := dec.Decode(&s); err != nil {
+			return
+		}
+		select {
+		case h.samples <- s.sample(id):
+		default: // the UI is behind; the next sample supersedes this one
+		}
+	}
+}
+
+// statsMsg carries the samples that arrived since the last one.
+type statsMsg []Sample
+
+// waitStats delivers the next batch of samples. Re-issue it after each
+// one.
+func waitStats(h *statsHub) tea.Cmd {
+	return func() tea.Msg {
+		batch := statsMsg{<-h.samples}
+		for {
+			select {
+			case s := <-h.samples:
+				batch = append(batch, s)
+			default:
+				return batch
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package containers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antiloger/termctlr/theme"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// setRows rebuilds the table from the containers and their latest stats.
+func (m *Model) setRows() {
+	t := theme.Current()
+	rows := make([][]string, len(m.containers))
+	for i, c := range m.containers {
+		cpu, mem := "-", "-"
+		if s, ok := m.stats[c.ID]; ok && c.State == "running" {
+			cpu = fmt.Sprintf("%.1f", s.CPU)
+			mem = formatBytes(s.Mem)
+		}
+		rows[i] = []string{c.Name(), stateLabel(t, c.State), cpu, mem, c.PortsString()}
+	}
+	m.table.SetRows(rows)
+}
+
+func stateLabel(t *theme.Theme, state string) string {
+	switch state {
+	case "running":
+		return t.Style(theme.Accent).Render("● " + state)
+	case "restarting", "dead", "removing":
+		return t.Style(theme.Alert).Render("● " + state)
+	}
+	return t.Style(theme.Muted).Render("○ " + state)
+}
+
+func (m Model) View() string {
+	t := theme.Current()
+	if !m.listed {
+		if m.err != nil {
+			return m.unreachableView()
+		}
+		return t.Style(theme.Muted).Render("containers: connecting to " + m.client.Socket + "…")
+	}
+
+	lines := []string{}
+	if len(m.containers) == 0 {
+		lines = append(lines, t.Style(theme.Muted).Render("no containers"))
+	} else {
+		lines = append(lines, m.table.Render(m.width))
+	}
+	switch {
+	case m.err != nil:
+		lines = append(lines, t.Style(theme.Alert).Render("stale: "+m.err.Error()))
+	case m.actionErr != nil:
+		lines = append(lines, t.Style(theme.Alert).Render(m.actionErr.Error()))
+	case m.pending != "":
+		lines = append(lines, t.Style(theme.Muted).Render(m.pending))
+	}
+	lines = append(lines, t.Style(theme.Muted).Render("↑ ↓ select · s start · x stop · r restart · l logs"))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// unreachableView explains why there is no list yet.
+func (m Model) unreachableView() string {
+	t := theme.Current()
+	return strings.Join([]string{
+		t.Style(theme.Alert).Bold(true).Render("containers: engine unreachable"),
+		t.Style(theme.Muted).Render(m.err.Error()),
+		t.Style(theme.Muted).Render("socket " + m.client.Socket + " · set containers.socket to change it"),
+	}, "\n")
+}
+
+func formatBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%dB", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
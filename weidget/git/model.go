@@ -0,0 +1,252 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/antiloger/termctlr/config"
+	"github.com/antiloger/termctlr/types"
+	"github.com/antiloger/termctlr/weidget/components"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// repo is one configured repository.
+type repo struct {
+	path     string
+	status   Status
+	err      error // last refresh failed; status is stale
+	watchErr error // changes are not (all) seen
+	loaded   bool
+	busy     bool // a refresh is running
+	again    bool // changed while busy
+}
+
+func (r repo) name() string {
+	return filepath.Base(r.path)
+}
+
+// Model is the git widget.
+type Model struct {
+	repos    []repo // as configured
+	watcher  *watcher
+	watchErr error // inotify itself is unavailable
+	open     []string
+	terminal bool
+	table    components.Table
+	width    int
+	now      time.Time
+	pos      types.Position
+	openErr  error
+}
+
+func NewModel(cfg config.GitConfig) Model {
+	table := components.NewTable("REPO", "BRANCH", "SYNC", "CHANGES", "LAST COMMIT")
+	table.Selected = 0
+	m := Model{
+		open:     strings.Fields(cfg.Open),
+		terminal: cfg.OpenInTerminal,
+		table:    table,
+		width:    60,
+		now:      time.Now(),
+	}
+	for _, p := range cfg.Repos {
+		// busy already: Init, a value receiver, starts the first refresh
+		m.repos = append(m.repos, repo{path: filepath.Clean(config.ExpandPath(p)), busy: true})
+	}
+	if len(m.repos) > 0 {
+		m.watcher, m.watchErr = newWatcher()
+	}
+	m.setRows()
+	return m
+}
+
+// statusMsg is the result of a refresh.
+type statusMsg struct {
+	repo   int
+	status Status
+	err    error
+}
+
+// watchedMsg says whether watching a repository was set up.
+type watchedMsg struct {
+	repo int
+	err  error
+}
+
+// openedMsg is the result of the open command.
+type openedMsg struct{ err error }
+
+func (m Model) Init() tea.Cmd {
+	var cmds []tea.Cmd
+	for i := range m.repos {
+		cmds = append(cmds, readRepo(i, m.repos[i].path))
+		if m.watcher != nil {
+			cmds = append(cmds, watchRepo(m.watcher, i, m.repos[i].path))
+		}
+	}
+	if m.watcher != nil {
+		cmds = append(cmds, waitChanges(m.watcher))
+	}
+	return tea.Batch(cmds...)
+}
+
+func watchRepo(w *watcher, i int, path string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		dir, err := gitDir(ctx, path)
+		if err != nil {
+			return watchedMsg{repo: i, err: err}
+		}
+		tree, err := worktreeDirs(ctx, path)
+		if err != nil {
+			return watchedMsg{repo: i, err: err}
+		}
+		return watchedMsg{repo: i, err: w.addRepo(i, dir, tree)}
+	}
+}
+
+// refresh reads the status of repository i off the UI goroutine. Changes
+// arriving meanwhile are folded into one more refresh afterwards.
+func (m *Model) refresh(i int) tea.Cmd {
+	r := &m.repos[i]
+	if r.busy {
+		r.again = true
+		return nil
+	}
+	r.busy = true
+	return readRepo(i, r.path)
+}
+
+func readRepo(i int, path string) tea.Cmd {
+	return func() tea.Msg {
+		s, err := readStatus(context.Background(), path)
+		return statusMsg{repo: i, status: s, err: err}
+	}
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case types.SizeMsg:
+		m.width = msg.Width
+		m.table.Height = max(msg.Height-3, 1) // header, status and help lines
+		return m, nil
+	case types.TickMsg:
+		m.now = time.Time(msg)
+		m.setRows() // commit ages
+		return m, nil
+	case statusMsg:
+		r := &m.repos[msg.repo]
+		r.busy = false
+		r.err = msg.err
+		if msg.err == nil || !r.loaded {
+			r.status, r.loaded = msg.status, true
+		}
+		m.setRows()
+		if r.again {
+			r.again = false
+			return m, m.refresh(msg.repo)
+		}
+		return m, nil
+	case watchedMsg:
+		m.repos[msg.repo].watchErr = msg.err
+		return m, nil
+	case changedMsg:
+		cmds := []tea.Cmd{waitChanges(m.watcher)}
+		for _, i := range msg {
+			cmds = append(cmds, m.refresh(i))
+		}
+		return m, tea.Batch(cmds...)
+	case openedMsg:
+		m.openErr = msg.err
+		return m, nil
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m Model) handleKey(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		m.table.Up()
+	case "down", "j":
+		m.table.Down()
+	case "r":
+		var cmds []tea.Cmd
+		for i := range m.repos {
+			cmds = append(cmds, m.refresh(i))
+		}
+		return m, tea.Batch(cmds...)
+	case "o", "enter":
+		if i := m.table.Selected; i >= 0 && i < len(m.repos) {
+			m.openErr = nil
+			return m, m.openRepo(m.repos[i].path)
+		}
+	}
+	return m, nil
+}
+
+// openRepo runs the configured open command in the repository. "{}" in
+// the command is replaced by the path, which is appended otherwise.
+// Terminal programs get the terminal until they exit; others run on their
+// own.
+func (m Model) openRepo(path string) tea.Cmd {
+	if len(m.open) == 0 {
+		return func() tea.Msg {
+			return openedMsg{err: errors.New(`no open command; set git.open, e.g. "code {}"`)}
+		}
+	}
+	args := make([]string, 0, len(m.open)+1)
+	placed := false
+	for _, a := range m.open[1:] {
+		if strings.Contains(a, "{}") {
+			a, placed = strings.ReplaceAll(a, "{}", path), true
+		}
+		args = append(args, a)
+	}
+	if !placed {
+		args = append(args, path)
+	}
+	cmd := exec.Command(config.ExpandPath(m.open[0]), args...)
+	cmd.Dir = path
+	if m.terminal {
+		return tea.ExecProcess(cmd, func(err error) tea.Msg {
+			return openedMsg{err: commandErr(m.open[0], err)}
+		})
+	}
+	return func() tea.Msg {
+		if err := cmd.Start(); err != nil {
+			return openedMsg{err: commandErr(m.open[0], err)}
+		}
+		go cmd.Wait() // reap it whenever it exits
+		return openedMsg{}
+	}
+}
+
+func commandErr(name string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("open with %s: %w", name, err)
+}
+
+func (m Model) SetPosition(x, y int) {
+	m.pos.X = x
+	m.pos.Y = y
+}
+
+func (m Model) Name() string {
+	return "git"
+}
+
+// Close stops watching the repositories.
+func (m Model) Close() {
+	if m.watcher != nil {
+		m.watcher.close()
+	}
+}
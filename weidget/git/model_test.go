@@ -0,0 +1,33 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/antiloger/termctlr/config"
+)
+
+func TestChangeDuringFirstRefreshIsQueued(t *testing.T) {
+	m := NewModel(config.GitConfig{Repos: []string{t.TempDir()}})
+	if m.watcher != nil {
+		defer m.watcher.close()
+	}
+	if m.Init() == nil {
+		t.Fatal("no initial refresh")
+	}
+
+	// the first refresh is still out, so a change only asks for another
+	next, _ := m.Update(changedMsg{0})
+	m = next.(Model)
+	if r := m.repos[0]; !r.busy || !r.again {
+		t.Fatalf("after a change: busy %v again %v", r.busy, r.again)
+	}
+	next, cmd := m.Update(statusMsg{repo: 0})
+	m = next.(Model)
+	if cmd == nil || !m.repos[0].busy || m.repos[0].again {
+		t.Errorf("first status: cmd %v busy %v again %v, want the queued refresh", cmd != nil, m.repos[0].busy, m.repos[0].again)
+	}
+	next, cmd = m.Update(statusMsg{repo: 0})
+	if m = next.(Model); cmd != nil || m.repos[0].busy {
+		t.Errorf("second status: cmd %v busy %v, want idle", cmd != nil, m.repos[0].busy)
+	}
+}
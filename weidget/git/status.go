@@ -0,0 +1,158 @@
+// Package git shows the state of local git repositories and refreshes it
+// when inotify says something under them changed.
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gitTimeout bounds one git invocation; a hung network filesystem should
+// not wedge the refresh forever.
+const gitTimeout = 10 * time.Second
+
+// Status is what the widget shows for one repository.
+type Status struct {
+	Branch    string // "" when detached
+	Commit    string // abbreviated HEAD, for detached heads
+	Upstream  string // "" when the branch tracks nothing
+	Ahead     int
+	Behind    int
+	Staged    int
+	Dirty     int // modified or deleted in the worktree
+	Untracked int
+	Conflicts int
+	Subject   string // of the last commit; "" in a repository without commits
+	When      time.Time
+}
+
+// Clean reports whether nothing is staged, changed or untracked.
+func (s Status) Clean() bool {
+	return s.Staged+s.Dirty+s.Untracked+s.Conflicts == 0
+}
+
+// git runs git in dir. --no-optional-locks keeps status from rewriting
+// the index, which would wake the watcher again.
+func git(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", append([]string{"--no-optional-locks", "-C", dir}, args...)...)
+	cmd.Env = append(cmd.Environ(), "GIT_TERMINAL_PROMPT=0", "LC_ALL=C")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			msg, _, _ = strings.Cut(msg, "\n")
+			return nil, errors.New(strings.TrimPrefix(msg, "fatal: "))
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// readStatus reads branch, upstream distance, change counts and the last
+// commit of the repository at dir.
+func readStatus(ctx context.Context, dir string) (Status, error) {
+	out, err := git(ctx, dir, "status", "--porcelain=v2", "--branch", "-z")
+	if err != nil {
+		return Status{}, err
+	}
+	s := parseStatus(out)
+	out, err = git(ctx, dir, "log", "-1", "--format=%ct%x00%s")
+	if err != nil {
+		if s.Commit == "" {
+			return s, nil // no commits yet
+		}
+		return s, err
+	}
+	ts, subject, _ := strings.Cut(strings.TrimRight(string(out), "\n"), "\x00")
+	if sec, err := strconv.ParseInt(ts, 10, 64); err == nil {
+		s.When = time.Unix(sec, 0)
+	}
+	s.Subject = subject
+	return s, nil
+}
+
+// parseStatus reads `git status --porcelain=v2 --branch -z` output.
+func parseStatus(out []byte) Status {
+	var s Status
+	fields := strings.Split(string(out), "\x00")
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		if f == "" {
+			continue
+		}
+		switch f[0] {
+		case '#':
+			key, val, _ := strings.Cut(strings.TrimPrefix(f, "# "), " ")
+			switch key {
+			case "branch.oid":
+				if val != "(initial)" {
+					s.Commit = val[:min(len(val), 7)]
+				}
+			case "branch.head":
+				if val != "(detached)" {
+					s.Branch = val
+				}
+			case "branch.upstream":
+				s.Upstream = val
+			case "branch.ab":
+				fmt.Sscanf(val, "+%d -%d", &s.Ahead, &s.Behind)
+			}
+		case '1', '2':
+			// "1 XY …"; X is the index, Y the worktree, '.' unchanged
+			if len(f) >= 4 {
+				if f[2] != '.' {
+					s.Staged++
+				}
+				if f[3] != '.' {
+					s.Dirty++
+				}
+			}
+			if f[0] == '2' {
+				i++ // renames and copies are followed by the original path
+			}
+		case 'u':
+			s.Conflicts++
+		case '?':
+			s.Untracked++
+		}
+	}
+	return s
+}
+
+// gitDir is the repository's git directory, which is not dir/.git for
+// worktrees and submodules.
+func gitDir(ctx context.Context, dir string) (string, error) {
+	out, err := git(ctx, dir, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// worktreeDirs lists the directories of the worktree that hold tracked or
+// untracked, not ignored, files, the top level included.
+func worktreeDirs(ctx context.Context, dir string) ([]string, error) {
+	out, err := git(ctx, dir, "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{".": true}
+	dirs := []string{dir}
+	for _, f := range strings.Split(string(out), "\x00") {
+		for d := filepath.Dir(f); f != "" && !seen[d]; d = filepath.Dir(d) {
+			seen[d] = true
+			dirs = append(dirs, filepath.Join(dir, d))
+		}
+	}
+	return dirs, nil
+}
@@ -0,0 +1,80 @@
+package git
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseStatus(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		lines []string
+		want  Status
+	}{
+		{
+			name: "tracking branch",
+			lines: []string{
+				"# branch.oid 0123456789abcdef0123456789abcdef01234567",
+				"# branch.head main",
+				"# branch.upstream origin/main",
+				"# branch.ab +2 -1",
+			},
+			want: Status{Branch: "main", Commit: "0123456", Upstream: "origin/main", Ahead: 2, Behind: 1},
+		},
+		{
+			name: "detached head",
+			lines: []string{
+				"# branch.oid 89abcdef0123456789abcdef0123456789abcdef",
+				"# branch.head (detached)",
+			},
+			want: Status{Commit: "89abcde"},
+		},
+		{
+			name:  "no commits yet",
+			lines: []string{"# branch.oid (initial)", "# branch.head main", "? README"},
+			want:  Status{Branch: "main", Untracked: 1},
+		},
+		{
+			name: "changes",
+			lines: []string{
+				"# branch.oid 0123456789abcdef0123456789abcdef01234567",
+				"# branch.head dev",
+				"1 .M N... 100644 100644 100644 1111111 1111111 main.go",
+				"1 A. N... 000000 100644 100644 0000000 2222222 new.go",
+				"1 MD N... 100644 100644 000000 3333333 4444444 gone.go",
+				"? notes.txt",
+				"? tmp/",
+			},
+			want: Status{Branch: "dev", Commit: "0123456", Staged: 2, Dirty: 2, Untracked: 2},
+		},
+		{
+			// the original path follows as its own field; one that looks
+			// like an entry must not be counted
+			name: "rename",
+			lines: []string{
+				"# branch.head dev",
+				"2 R. N... 100644 100644 100644 1111111 1111111 R100 renamed.go",
+				"? looks-untracked.go",
+				"2 RM N... 100644 100644 100644 2222222 2222222 R087 edited.go",
+				"1 .M N... 100644 100644 100644 3333333 3333333 old.go",
+			},
+			want: Status{Branch: "dev", Staged: 2, Dirty: 1},
+		},
+		{
+			name: "unmerged",
+			lines: []string{
+				"# branch.head main",
+				"# branch.ab +0 -3",
+				"u UU N... 100644 100644 100644 100644 1111111 2222222 3333333 both.go",
+				"u AA N... 000000 100644 100644 100644 0000000 2222222 3333333 added.go",
+				"1 M. N... 100644 100644 100644 4444444 5555555 merged.go",
+			},
+			want: Status{Branch: "main", Behind: 3, Staged: 1, Conflicts: 2},
+		},
+	} {
+		out := []byte(strings.Join(c.lines, "\x00") + "\x00")
+		if got := parseStatus(out); got != c.want {
+			t.Errorf("%s:\n got %+v\nwant %+v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antiloger/termctlr/theme"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// setRows rebuilds the table from the repositories.
+func (m *Model) setRows() {
+	t := theme.Current()
+	rows := make([][]string, len(m.repos))
+	for i, r := range m.repos {
+		switch {
+		case !r.loaded:
+			rows[i] = []string{r.name(), t.Style(theme.Muted).Render("reading…"), "", "", ""}
+			continue
+		case r.err != nil && r.status == Status{}:
+			rows[i] = []string{r.name(), t.Style(theme.Alert).Render("error"), "", "", r.err.Error()}
+			continue
+		}
+		s := r.status
+		branch := s.Branch
+		if branch == "" {
+			branch = t.Style(theme.Alert).Render("detached " + s.Commit)
+		}
+		last := "no commits"
+		if s.Subject != "" {
+			last = fmt.Sprintf("%s · %s", formatAge(m.now.Sub(s.When)), s.Subject)
+		}
+		rows[i] = []string{r.name(), branch, syncLabel(t, s), changesLabel(t, s), last}
+	}
+	m.table.SetRows(rows)
+}
+
+// syncLabel is the distance to the upstream: ↑2 ↓1, = when even.
+func syncLabel(t *theme.Theme, s Status) string {
+	switch {
+	case s.Upstream == "":
+		return t.Style(theme.Muted).Render("-")
+	case s.Ahead == 0 && s.Behind == 0:
+		return t.Style(theme.Muted).Render("=")
+	}
+	var parts []string
+	if s.Ahead > 0 {
+		parts = append(parts, t.Style(theme.Accent).Render(fmt.Sprintf("↑%d", s.Ahead)))
+	}
+	if s.Behind > 0 {
+		parts = append(parts, t.Style(theme.Alert).Render(fmt.Sprintf("↓%d", s.Behind)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// changesLabel counts staged (+), changed (~), untracked (?) and
+// conflicted (!) files.
+func changesLabel(t *theme.Theme, s Status) string {
+	if s.Clean() {
+		return t.Style(theme.Muted).Render("clean")
+	}
+	var parts []string
+	if s.Conflicts > 0 {
+		parts = append(parts, t.Style(theme.Alert).Bold(true).Render(fmt.Sprintf("!%d", s.Conflicts)))
+	}
+	if s.Staged > 0 {
+		parts = append(parts, t.Style(theme.Accent).Render(fmt.Sprintf("+%d", s.Staged)))
+	}
+	if s.Dirty > 0 {
+		parts = append(parts, t.Style(theme.Alert).Render(fmt.Sprintf("~%d", s.Dirty)))
+	}
+	if s.Untracked > 0 {
+		parts = append(parts, t.Style(theme.Muted).Render(fmt.Sprintf("?%d", s.Untracked)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatAge is a short relative time: 45s, 12m, 3h, 5d, 8w.
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", max(int(d.Seconds()), 0))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d < 8*7*24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+	return fmt.Sprintf("%dw", int(d.Hours()/24/7))
+}
+
+func (m Model) View() string {
+	t := theme.Current()
+	if len(m.repos) == 0 {
+		return strings.Join([]string{
+			t.Style(theme.Muted).Render("git: no repositories configured"),
+			t.Style(theme.Muted).Render(`list them under git.repos, e.g. "repos": ["~/src/termctrl"]`),
+		}, "\n")
+	}
+
+	lines := []string{m.table.Render(m.width)}
+	sel := m.table.Selected
+	switch {
+	case m.openErr != nil:
+		lines = append(lines, t.Style(theme.Alert).Render(m.openErr.Error()))
+	case m.watchErr != nil:
+		lines = append(lines, t.Style(theme.Alert).Render("not watching for changes: "+m.watchErr.Error()+" · r refresh"))
+	case sel >= 0 && sel < len(m.repos) && m.repos[sel].err != nil && m.repos[sel].loaded:
+		lines = append(lines, t.Style(theme.Alert).Render("stale: "+m.repos[sel].err.Error()))
+	case sel >= 0 && sel < len(m.repos) && m.repos[sel].watchErr != nil:
+		lines = append(lines, t.Style(theme.Muted).Render("not watching: "+m.repos[sel].watchErr.Error()))
+	}
+	lines = append(lines, t.Style(theme.Muted).Render("↑ ↓ select · o open · r refresh"))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
@@ -0,0 +1,239 @@
+package git
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// settle is how long changes are gathered before a refresh: a commit
+	// or checkout touches many files in a burst.
+	settle = 300 * time.Millisecond
+	// maxWatches bounds the watches of one repository's worktree, well
+	// under the default fs.inotify.max_user_watches.
+	maxWatches = 4096
+)
+
+// errWatchLimit says a worktree has more directories than are watched;
+// changes in the others are only seen on the next refresh.
+var errWatchLimit = errors.New("too many directories, some are not watched")
+
+const (
+	gitMask = unix.IN_CLOSE_WRITE | unix.IN_MOVED_TO | unix.IN_CREATE | unix.IN_DELETE | unix.IN_ONLYDIR
+	// worktree directories also report edits in place
+	treeMask = gitMask | unix.IN_MODIFY | unix.IN_MOVED_FROM | unix.IN_DELETE_SELF | unix.IN_ATTRIB
+)
+
+// watcher is one inotify instance for all repositories. It records which
+// repositories changed and wakes the UI; the UI reads and clears the set.
+type watcher struct {
+	fd      int
+	f       *os.File      // fd, read through the runtime poller
+	updates chan struct{} // signalled (never blocking) on changes
+	done    chan struct{} // closed by close
+	once    sync.Once
+
+	mu      sync.Mutex
+	closed  bool // fd is closed and may be another file's by now
+	wds     map[int32]watch
+	counts  map[int]int // watches per repository
+	changed map[int]bool
+}
+
+type watch struct {
+	repo int
+	dir  string
+	kind watchKind
+}
+
+type watchKind int
+
+const (
+	gitTop  watchKind = iota // the git directory itself
+	gitRefs                  // refs/heads, refs/remotes and below
+	tree                     // a worktree directory
+)
+
+func newWatcher() (*watcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	w := &watcher{
+		// non-blocking, so a pending read parks in the runtime poller
+		// rather than holding a thread
+		fd:      fd,
+		f:       os.NewFile(uintptr(fd), "inotify"),
+		updates: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		wds:     map[int32]watch{},
+		counts:  map[int]int{},
+		changed: map[int]bool{},
+	}
+	go w.run()
+	return w, nil
+}
+
+// addRepo watches the git directory (HEAD, index, refs) and the worktree
+// directories of repository repo.
+func (w *watcher) addRepo(repo int, gitDir string, dirs []string) error {
+	if err := w.add(repo, gitDir, gitTop); err != nil {
+		return err
+	}
+	// branches with slashes in their names are directories
+	for _, refs := range []string{"heads", "remotes"} {
+		filepath.WalkDir(filepath.Join(gitDir, "refs", refs), func(path string, d fs.DirEntry, err error) error {
+			if err == nil && d.IsDir() {
+				w.add(repo, path, gitRefs)
+			}
+			return nil
+		})
+	}
+	for _, d := range dirs {
+		if err := w.add(repo, d, tree); errors.Is(err, errWatchLimit) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *watcher) add(repo int, dir string, kind watchKind) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	if kind == tree && w.counts[repo] >= maxWatches {
+		return errWatchLimit
+	}
+	mask := uint32(gitMask)
+	if kind == tree {
+		mask = treeMask
+	}
+	wd, err := unix.InotifyAddWatch(w.fd, dir, mask)
+	if err != nil {
+		return &os.PathError{Op: "inotify_add_watch", Path: dir, Err: err}
+	}
+	if _, ok := w.wds[int32(wd)]; !ok {
+		w.counts[repo]++
+	}
+	w.wds[int32(wd)] = watch{repo: repo, dir: dir, kind: kind}
+	return nil
+}
+
+func (w *watcher) run() {
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := w.f.Read(buf)
+		if err != nil {
+			return // closed
+		}
+		for off := 0; off+unix.SizeofInotifyEvent <= n; {
+			ev := (*unix.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			name := strings.TrimRight(string(buf[off+unix.SizeofInotifyEvent:off+unix.SizeofInotifyEvent+int(ev.Len)]), "\x00")
+			off += unix.SizeofInotifyEvent + int(ev.Len)
+			w.event(ev.Wd, ev.Mask, name)
+		}
+	}
+}
+
+func (w *watcher) event(wd int32, mask uint32, name string) {
+	if mask&unix.IN_Q_OVERFLOW != 0 {
+		w.mu.Lock()
+		for _, wt := range w.wds {
+			w.changed[wt.repo] = true // events were lost, refresh all
+		}
+		w.mu.Unlock()
+		w.notify()
+		return
+	}
+	w.mu.Lock()
+	wt, ok := w.wds[wd]
+	if mask&unix.IN_IGNORED != 0 {
+		delete(w.wds, wd) // the directory went away
+		if ok {
+			w.counts[wt.repo]--
+		}
+	}
+	w.mu.Unlock()
+	if !ok || strings.HasSuffix(name, ".lock") {
+		return // git's lock files come and go around every write
+	}
+	if wt.kind == gitTop && !watchedGitFile(name) {
+		return // logs, objects, hooks and such
+	}
+	if wt.kind != gitTop && mask&unix.IN_ISDIR != 0 && mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0 {
+		w.add(wt.repo, filepath.Join(wt.dir, name), wt.kind) // a new directory, remote or branch prefix
+	}
+	w.mu.Lock()
+	w.changed[wt.repo] = true
+	w.mu.Unlock()
+	w.notify()
+}
+
+// close stops watching: closing the file ends the reader goroutine and
+// done releases a waiting waitChanges.
+func (w *watcher) close() {
+	w.once.Do(func() {
+		w.mu.Lock()
+		w.closed = true
+		w.mu.Unlock()
+		w.f.Close()
+		close(w.done)
+	})
+}
+
+// watchedGitFile says whether a file directly in the git directory
+// affects the status.
+func watchedGitFile(name string) bool {
+	switch name {
+	case "HEAD", "index", "packed-refs", "FETCH_HEAD", "ORIG_HEAD", "MERGE_HEAD":
+		return true
+	}
+	return false
+}
+
+func (w *watcher) notify() {
+	select {
+	case w.updates <- struct{}{}:
+	default:
+	}
+}
+
+// take returns and clears the changed repositories.
+func (w *watcher) take() []int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	repos := make([]int, 0, len(w.changed))
+	for r := range w.changed {
+		repos = append(repos, r)
+	}
+	clear(w.changed)
+	return repos
+}
+
+// changedMsg lists repositories with changes.
+type changedMsg []int
+
+// waitChanges wakes the UI once changes have settled; re-issue it after
+// each one. It returns nil once the watcher is closed.
+func waitChanges(w *watcher) tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case <-w.updates:
+		case <-w.done:
+			return nil
+		}
+		time.Sleep(settle)
+		return changedMsg(w.take())
+	}
+}
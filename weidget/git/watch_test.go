@@ -0,0 +1,60 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWatcherSeesChangesUntilClosed(t *testing.T) {
+	root := t.TempDir()
+	gitDir := filepath.Join(root, ".git")
+	if err := os.MkdirAll(filepath.Join(gitDir, "refs", "heads"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	w, err := newWatcher()
+	if err != nil {
+		t.Skip("no inotify:", err)
+	}
+	defer w.close()
+	if err := w.addRepo(3, gitDir, []string{root}); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := make(chan any, 1)
+	go func() { msgs <- waitChanges(w)() }()
+	if err := os.WriteFile(filepath.Join(root, "README"), []byte("hi"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-msgs:
+		if repos, ok := msg.(changedMsg); !ok || !slices.Equal(repos, []int{3}) {
+			t.Errorf("change gave %#v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change seen")
+	}
+
+	// a wait pending when the widget closes returns, and nothing more is added
+	select {
+	case <-w.updates: // the write's later events
+	default:
+	}
+	go func() { msgs <- waitChanges(w)() }()
+	w.close()
+	select {
+	case msg := <-msgs:
+		if msg != nil {
+			t.Errorf("closed watcher gave %#v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("waitChanges still blocked after close")
+	}
+	if err := w.add(3, root, tree); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("add after close: %v", err)
+	}
+	w.close() // twice is fine
+}
@@ -1,15 +1,64 @@
 package weidget
 
 import (
+	"fmt"
+	"math"
+
+	"github.com/antiloger/termctlr/theme"
+	"github.com/antiloger/termctlr/types"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
 type Layout int
 
 const (
-	Vertical Layout = iota
+	Vertical Layout = iota // widgets stacked at their natural size, centered
+	Columns                // side by side panes sharing the screen width
+	Rows                   // stacked panes sharing the screen height
 )
 
+// minPane is the smallest size (in cells, frame included) a split pane can
+// be dragged down to.
+const minPane = 6
+
+func ParseLayout(name string) (Layout, error) {
+	switch name {
+	case "", "vertical":
+		return Vertical, nil
+	case "columns":
+		return Columns, nil
+	case "rows":
+		return Rows, nil
+	}
+	return Vertical, fmt.Errorf("unknown layout %q", name)
+}
+
+// Rect is an area of the screen in cells.
+type Rect struct {
+	X, Y, W, H int
+}
+
+func (r Rect) Contains(x, y int) bool {
+	return x >= r.X && x < r.X+r.W && y >= r.Y && y < r.Y+r.H
+}
+
+// pane is where a widget lands on screen: outer includes the frame,
+// inner is the area the widget itself draws into.
+type pane struct {
+	outer Rect
+	inner Rect
+}
+
+func (W *WeidgetScreen) split() bool {
+	return W.layout == Columns || W.layout == Rows
+}
+
+// layoutArea is the screen minus the status line.
+func (W *WeidgetScreen) layoutArea() (int, int) {
+	return W.screenSize.X, max(W.screenSize.Y-1, 0)
+}
+
 func (W *WeidgetScreen) applyLayout() string {
 	switch W.layout {
 	case Vertical:
@@ -18,7 +67,7 @@ func (W *WeidgetScreen) applyLayout() string {
 			v := widget.View()
 			// Debug: print each widget view info
 			if i == W.focus {
-				v = lipgloss.NewStyle().Border(lipgloss.NormalBorder(), true).Render(v)
+				v = theme.Current().FocusStyle().Render(v)
 			}
 			views = append(views, v)
 		}
@@ -26,6 +75,170 @@ func (W *WeidgetScreen) applyLayout() string {
 		joined := lipgloss.JoinVertical(lipgloss.Center, views...)
 
 		return joined
+
+	case Columns, Rows:
+		t := theme.Current()
+		var views []string
+		for i, p := range W.panes() {
+			frame := lipgloss.NewStyle().Border(t.Border, true).BorderForeground(t.Color(theme.Muted))
+			if i == W.focus {
+				frame = t.FocusStyle()
+			}
+			content := lipgloss.NewStyle().MaxWidth(p.inner.W).MaxHeight(p.inner.H).Render(W.weidgets[i].View())
+			content = lipgloss.Place(p.inner.W, p.inner.H, lipgloss.Center, lipgloss.Center, content)
+			views = append(views, frame.Render(content))
+		}
+		if W.layout == Columns {
+			return lipgloss.JoinHorizontal(lipgloss.Top, views...)
+		}
+		return lipgloss.JoinVertical(lipgloss.Left, views...)
 	}
 	return "ERROR"
 }
+
+// panes computes where every widget is drawn, in screen coordinates.
+// It mirrors applyLayout and View (which centers the layout on screen).
+func (W *WeidgetScreen) panes() []pane {
+	areaW, areaH := W.layoutArea()
+	panes := make([]pane, len(W.weidgets))
+
+	switch W.layout {
+	case Vertical:
+		sizes := make([]Rect, len(W.weidgets))
+		blockW, blockH := 0, 0
+		for i, widget := range W.weidgets {
+			v := widget.View()
+			if i == W.focus {
+				v = theme.Current().FocusStyle().Render(v)
+			}
+			sizes[i] = Rect{W: lipgloss.Width(v), H: lipgloss.Height(v)}
+			blockW = max(blockW, sizes[i].W)
+			blockH += sizes[i].H
+		}
+
+		// lipgloss.Place centering of the joined block
+		left := centerOffset(areaW - blockW)
+		y := centerOffset(areaH - blockH)
+		for i, r := range sizes {
+			// lipgloss.JoinVertical centering inside the block
+			r.X = left + int(math.Round(float64(blockW-r.W)/2))
+			r.Y = y
+			y += r.H
+
+			inner := r
+			if i == W.focus {
+				inner = Rect{r.X + 1, r.Y + 1, r.W - 2, r.H - 2}
+			}
+			panes[i] = pane{outer: r, inner: inner}
+		}
+
+	case Columns, Rows:
+		total := areaW
+		if W.layout == Rows {
+			total = areaH
+		}
+		pos := 0
+		for i, size := range splitSizes(total, W.weights) {
+			r := Rect{X: pos, Y: 0, W: size, H: areaH}
+			if W.layout == Rows {
+				r = Rect{X: 0, Y: pos, W: areaW, H: size}
+			}
+			pos += size
+			panes[i] = pane{
+				outer: r,
+				inner: Rect{r.X + 1, r.Y + 1, max(r.W-2, 0), max(r.H-2, 0)},
+			}
+		}
+	}
+	return panes
+}
+
+// centerOffset matches how lipgloss.Place splits a gap when centering.
+func centerOffset(gap int) int {
+	if gap <= 0 {
+		return 0
+	}
+	return gap - int(math.Round(float64(gap)*0.5))
+}
+
+// splitSizes divides total cells between panes in proportion to weights.
+// Rounding leftovers go to the last pane so the panes always fill total.
+func splitSizes(total int, weights []float64) []int {
+	sizes := make([]int, len(weights))
+	if len(weights) == 0 {
+		return sizes
+	}
+	var sum float64
+	for _, w := range weights {
+		sum += w
+	}
+	used := 0
+	for i, w := range weights {
+		sizes[i] = int(float64(total) * w / sum)
+		used += sizes[i]
+	}
+	sizes[len(sizes)-1] += total - used
+	return sizes
+}
+
+// splitAt returns the index of the split border under (x, y): the border
+// between pane i and i+1. It returns -1 when there is none.
+func (W *WeidgetScreen) splitAt(x, y int) int {
+	if !W.split() {
+		return -1
+	}
+	panes := W.panes()
+	for i := 0; i < len(panes)-1; i++ {
+		a, b := panes[i].outer, panes[i+1].outer
+		if W.layout == Columns && (x == a.X+a.W-1 || x == b.X) {
+			return i
+		}
+		if W.layout == Rows && (y == a.Y+a.H-1 || y == b.Y) {
+			return i
+		}
+	}
+	return -1
+}
+
+// moveSplit drags the border after pane i to screen position pos
+// (a column for Columns, a row for Rows).
+func (W *WeidgetScreen) moveSplit(i, pos int) {
+	areaW, areaH := W.layoutArea()
+	total := areaW
+	if W.layout == Rows {
+		total = areaH
+	}
+	sizes := splitSizes(total, W.weights)
+
+	start := 0
+	for _, s := range sizes[:i] {
+		start += s
+	}
+	pair := sizes[i] + sizes[i+1]
+	if pair < 2*minPane {
+		return
+	}
+	sizes[i] = min(max(pos-start+1, minPane), pair-minPane)
+	sizes[i+1] = pair - sizes[i]
+
+	weights := make([]float64, len(sizes))
+	for j, s := range sizes {
+		weights[j] = float64(s)
+	}
+	W.weights = weights
+}
+
+// resize tells every widget how much room it has. Only split layouts
+// allot sizes; in Vertical widgets keep their natural size.
+func (W *WeidgetScreen) resize() tea.Cmd {
+	if !W.split() {
+		return nil
+	}
+	var cmds []tea.Cmd
+	for i, p := range W.panes() {
+		updated, cmd := W.weidgets[i].Update(types.SizeMsg{Width: p.inner.W, Height: p.inner.H})
+		W.weidgets[i] = updated.(Weidget)
+		cmds = append(cmds, cmd)
+	}
+	return tea.Batch(cmds...)
+}
@@ -0,0 +1,252 @@
+package media
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antiloger/termctlr/dbus"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Reconnect backoff while the bus is unreachable.
+const (
+	retryMin = 2 * time.Second
+	retryMax = 30 * time.Second
+)
+
+var errBusGone = errors.New("session bus went away")
+
+// connectedMsg is the result of a connection attempt made off the UI
+// goroutine.
+type connectedMsg struct {
+	bus     *dbus.Conn
+	players []Player
+	err     error
+}
+
+// retryMsg asks for another attempt; gen drops timers of older attempts.
+type retryMsg struct{ gen int }
+
+// signalMsg carries one signal from the bus it arrived on.
+type signalMsg struct {
+	bus *dbus.Conn
+	sig *dbus.Message
+}
+
+// busClosedMsg is sent when the signal stream of a connection ends.
+type busClosedMsg struct{ bus *dbus.Conn }
+
+// playerMsg is a freshly read player, after it appeared or its properties
+// were invalidated.
+type playerMsg struct {
+	player Player
+	err    error
+}
+
+// playersMsg replaces the whole player list after signals were lost.
+type playersMsg struct {
+	players []Player
+	err     error
+}
+
+// positionMsg is a position read after a change the player does not
+// signal (new track, resume).
+type positionMsg struct {
+	owner string
+	pos   time.Duration
+	at    time.Time
+}
+
+// actionMsg reports the result of a transport command.
+type actionMsg struct{ err error }
+
+// connect dials the bus, subscribes and reads the players.
+func connect(address string) tea.Cmd {
+	return func() tea.Msg {
+		bus, err := dbus.Dial(address)
+		if err != nil {
+			return connectedMsg{err: err}
+		}
+		for _, rule := range matchRules {
+			if err := bus.AddMatch(rule); err != nil {
+				bus.Close()
+				return connectedMsg{err: err}
+			}
+		}
+		players, err := loadPlayers(bus)
+		if err != nil {
+			bus.Close()
+			return connectedMsg{err: err}
+		}
+		return connectedMsg{bus: bus, players: players}
+	}
+}
+
+// waitSignal delivers the next signal. Re-issue it after each one.
+func waitSignal(bus *dbus.Conn) tea.Cmd {
+	return func() tea.Msg {
+		sig, ok := <-bus.Signals()
+		if !ok {
+			return busClosedMsg{bus: bus}
+		}
+		return signalMsg{bus: bus, sig: sig}
+	}
+}
+
+func retryAfter(d time.Duration, gen int) tea.Cmd {
+	return tea.Tick(d, func(time.Time) tea.Msg {
+		return retryMsg{gen: gen}
+	})
+}
+
+func fetchPlayer(bus *dbus.Conn, name string) tea.Cmd {
+	return func() tea.Msg {
+		p, err := loadPlayer(bus, name)
+		return playerMsg{player: p, err: err}
+	}
+}
+
+func fetchPlayers(bus *dbus.Conn) tea.Cmd {
+	return func() tea.Msg {
+		players, err := loadPlayers(bus)
+		return playersMsg{players: players, err: err}
+	}
+}
+
+func fetchPosition(bus *dbus.Conn, p Player) tea.Cmd {
+	return func() tea.Msg {
+		v, err := bus.Get(p.Bus, mprisPath, playerIfc, "Position")
+		if err != nil {
+			return nil // keep extrapolating
+		}
+		return positionMsg{owner: p.Owner, pos: micros(v), at: time.Now()}
+	}
+}
+
+// handleConn deals with the connection lifecycle messages.
+func (m Model) handleConn(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case connectedMsg:
+		m.connecting = false
+		if msg.err != nil {
+			return m.disconnected(msg.err)
+		}
+		m.bus, m.reason, m.backoff = msg.bus, nil, 0
+		m.players = msg.players
+		m.sel = min(m.sel, max(len(m.players)-1, 0))
+		return m, waitSignal(m.bus)
+
+	case retryMsg:
+		if msg.gen != m.gen || m.bus != nil || m.connecting {
+			return m, nil
+		}
+		m.connecting = true
+		return m, connect(m.address)
+
+	case busClosedMsg:
+		if msg.bus != m.bus {
+			return m, nil
+		}
+		err := errBusGone
+		if cerr := m.bus.Err(); cerr != nil {
+			err = fmt.Errorf("%w: %v", errBusGone, cerr)
+		}
+		m.bus, m.players = nil, nil
+		return m.disconnected(err)
+	}
+	return m, nil
+}
+
+// disconnected records why there is no bus and schedules the next attempt
+// with exponential backoff.
+func (m Model) disconnected(reason error) (Model, tea.Cmd) {
+	m.reason = reason
+	m.backoff = min(max(m.backoff*2, retryMin), retryMax)
+	m.retryAt = m.now.Add(m.backoff)
+	m.gen++
+	return m, retryAfter(m.backoff, m.gen)
+}
+
+// retryNow skips the remaining backoff.
+func (m Model) retryNow() (Model, tea.Cmd) {
+	if m.bus != nil || m.connecting {
+		return m, nil
+	}
+	m.gen++
+	m.backoff = 0
+	m.connecting = true
+	return m, connect(m.address)
+}
+
+// handleSignal updates the players from one signal, asking the player for
+// what signals leave out.
+func (m Model) handleSignal(msg signalMsg) (Model, tea.Cmd) {
+	if msg.bus != m.bus {
+		return m, nil // from a connection that has since been dropped
+	}
+	next := waitSignal(m.bus)
+	sig := msg.sig
+	if sig == dbus.Resync {
+		return m, tea.Batch(next, fetchPlayers(m.bus))
+	}
+
+	switch sig.Member {
+	case "NameOwnerChanged":
+		name, _ := bodyString(sig, 0)
+		newOwner, _ := bodyString(sig, 2)
+		if !strings.HasPrefix(name, mprisPrefix) {
+			return m, next
+		}
+		m.removePlayer(name)
+		if newOwner == "" {
+			return m, next
+		}
+		return m, tea.Batch(next, fetchPlayer(m.bus, name))
+
+	case "Seeked":
+		if i := m.byOwner(sig.Sender); i >= 0 && len(sig.Body) > 0 {
+			m.players[i].Position, m.players[i].PosAt = micros(sig.Body[0]), time.Now()
+		}
+		return m, next
+
+	case "PropertiesChanged":
+		i := m.byOwner(sig.Sender)
+		if i < 0 || len(sig.Body) < 3 {
+			return m, next
+		}
+		iface, _ := bodyString(sig, 0)
+		changed, _ := sig.Body[1].(map[string]any)
+		invalidated, _ := sig.Body[2].([]string)
+		p := &m.players[i]
+		if len(invalidated) > 0 {
+			// the player only says what changed, read it all again
+			return m, tea.Batch(next, fetchPlayer(m.bus, p.Bus))
+		}
+		changed = dbus.Unwrap(changed)
+		switch iface {
+		case rootIfc:
+			if id, ok := changed["Identity"].(string); ok {
+				p.Identity = id
+			}
+		case playerIfc:
+			p.apply(changed, time.Now())
+			_, track := changed["Metadata"]
+			_, status := changed["PlaybackStatus"]
+			if track || status {
+				// position changes are not signalled; ask once
+				return m, tea.Batch(next, fetchPosition(m.bus, *p))
+			}
+		}
+	}
+	return m, next
+}
+
+func bodyString(m *dbus.Message, i int) (string, bool) {
+	if i >= len(m.Body) {
+		return "", false
+	}
+	s, ok := m.Body[i].(string)
+	return s, ok
+}
@@ -0,0 +1,193 @@
+// Package media shows what MPRIS media players on the session bus are
+// playing and controls them.
+package media
+
+import (
+	"sort"
+	"time"
+
+	"github.com/antiloger/termctlr/config"
+	"github.com/antiloger/termctlr/dbus"
+	"github.com/antiloger/termctlr/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Model is the media widget. Like the audio widget it starts disconnected
+// and connects in the background.
+type Model struct {
+	address  string
+	seekStep time.Duration
+	bus      *dbus.Conn // nil while disconnected
+	players  []Player   // sorted by bus name
+	sel      int        // selected player
+	pos      types.Position
+	err      error
+
+	now        time.Time
+	connecting bool
+	reason     error         // why the widget is disconnected
+	backoff    time.Duration // current retry delay
+	retryAt    time.Time
+	gen        int // retry generation
+}
+
+func NewModel(cfg config.MediaConfig) Model {
+	address := cfg.Bus
+	if address == "" {
+		address = dbus.SessionAddress()
+	}
+	step, err := time.ParseDuration(cfg.SeekStep)
+	if err != nil || step <= 0 {
+		step = 5 * time.Second
+	}
+	return Model{
+		address:    address,
+		seekStep:   step,
+		now:        time.Now(),
+		connecting: true,
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return connect(m.address)
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case types.TickMsg:
+		m.now = time.Time(msg) // moves the position of playing players
+		return m, nil
+	case connectedMsg, retryMsg, busClosedMsg:
+		return m.handleConn(msg)
+	}
+	if m.bus == nil {
+		if msg, ok := msg.(tea.KeyMsg); ok && msg.String() == "r" {
+			return m.retryNow()
+		}
+		return m, nil
+	}
+
+	switch msg := msg.(type) {
+	case signalMsg:
+		return m.handleSignal(msg)
+	case playerMsg:
+		if msg.err == nil {
+			m.setPlayer(msg.player)
+		}
+	case playersMsg:
+		if msg.err == nil {
+			m.players = msg.players
+			m.sel = min(m.sel, max(len(m.players)-1, 0))
+		}
+	case positionMsg:
+		if i := m.byOwner(msg.owner); i >= 0 {
+			m.players[i].Position, m.players[i].PosAt = msg.pos, msg.at
+		}
+	case actionMsg:
+		m.err = msg.err
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "[":
+		if n := len(m.players); n > 0 {
+			m.sel = (m.sel + n - 1) % n
+		}
+		return m, nil
+	case "]":
+		if n := len(m.players); n > 0 {
+			m.sel = (m.sel + 1) % n
+		}
+		return m, nil
+	}
+
+	p, ok := m.selected()
+	if !ok {
+		return m, nil
+	}
+	switch msg.String() {
+	case " ", "enter":
+		return m, m.call(p, "PlayPause")
+	case "s":
+		return m, m.call(p, "Stop")
+	case "n":
+		return m, m.call(p, "Next")
+	case "p":
+		return m, m.call(p, "Previous")
+	case "left":
+		return m, m.call(p, "Seek", int64(-m.seekStep/time.Microsecond))
+	case "right":
+		return m, m.call(p, "Seek", int64(m.seekStep/time.Microsecond))
+	}
+	return m, nil
+}
+
+// call invokes a Player method off the UI goroutine. The player answers
+// through signals, so nothing is updated here.
+func (m Model) call(p Player, method string, args ...any) tea.Cmd {
+	bus := m.bus
+	return func() tea.Msg {
+		_, err := bus.Call(p.Bus, mprisPath, playerIfc, method, args...)
+		return actionMsg{err: err}
+	}
+}
+
+func (m Model) selected() (Player, bool) {
+	if m.sel < 0 || m.sel >= len(m.players) {
+		return Player{}, false
+	}
+	return m.players[m.sel], true
+}
+
+func (m Model) byOwner(owner string) int {
+	for i, p := range m.players {
+		if p.Owner == owner {
+			return i
+		}
+	}
+	return -1
+}
+
+// setPlayer adds or replaces a player, keeping the selection on the same
+// player.
+func (m *Model) setPlayer(p Player) {
+	cur, _ := m.selected()
+	m.removePlayer(p.Bus)
+	m.players = append(m.players, p)
+	sort.Slice(m.players, func(i, j int) bool { return m.players[i].Bus < m.players[j].Bus })
+	m.selectBus(cur.Bus)
+}
+
+func (m *Model) removePlayer(bus string) {
+	cur, _ := m.selected()
+	for i, p := range m.players {
+		if p.Bus == bus {
+			m.players = append(m.players[:i:i], m.players[i+1:]...)
+			break
+		}
+	}
+	m.selectBus(cur.Bus)
+}
+
+func (m *Model) selectBus(bus string) {
+	for i, p := range m.players {
+		if p.Bus == bus {
+			m.sel = i
+			return
+		}
+	}
+	m.sel = min(m.sel, max(len(m.players)-1, 0))
+}
+
+func (m Model) SetPosition(x, y int) {
+	m.pos.X = x
+	m.pos.Y = y
+}
+
+func (m Model) Name() string {
+	return "media"
+}
@@ -0,0 +1,198 @@
+package media
+
+import (
+	"bufio"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antiloger/termctlr/config"
+	"github.com/antiloger/termctlr/dbus"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// privateBus starts a dbus-daemon of its own and returns its address.
+func privateBus(t *testing.T) string {
+	t.Helper()
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("no dbus-daemon to run a private bus")
+	}
+	cmd := exec.Command(daemon, "--session", "--nofork", "--print-address")
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	address, err := bufio.NewReader(out).ReadString('\n')
+	if err != nil {
+		t.Fatalf("dbus-daemon: %v", err)
+	}
+	return strings.TrimSpace(address)
+}
+
+// player starts a stand-in player on its own connection.
+func player(t *testing.T, address, name string) *dbus.Conn {
+	t.Helper()
+	bus, err := dbus.Dial(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { bus.Close() })
+	if _, err := serveStandIn(bus, name); err != nil {
+		t.Fatal(err)
+	}
+	return bus
+}
+
+// driver runs commands the way the bubbletea runtime does, feeding their
+// messages back into the model.
+type driver struct {
+	t    *testing.T
+	m    Model
+	msgs chan tea.Msg
+}
+
+func newDriver(t *testing.T, m Model) *driver {
+	d := &driver{t: t, m: m, msgs: make(chan tea.Msg, 64)}
+	d.run(m.Init())
+	t.Cleanup(func() {
+		if d.m.bus != nil {
+			d.m.bus.Close()
+		}
+	})
+	return d
+}
+
+func (d *driver) run(cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	go func() {
+		msg := cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			for _, c := range batch {
+				d.run(c)
+			}
+			return
+		}
+		if msg != nil {
+			d.msgs <- msg
+		}
+	}()
+}
+
+func (d *driver) update(msg tea.Msg) {
+	next, cmd := d.m.Update(msg)
+	d.m = next.(Model)
+	d.run(cmd)
+}
+
+func (d *driver) key(k string) {
+	d.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+}
+
+// until handles messages until the model satisfies ok.
+func (d *driver) until(what string, ok func(Model) bool) {
+	d.t.Helper()
+	timeout := time.After(5 * time.Second)
+	for !ok(d.m) {
+		select {
+		case msg := <-d.msgs:
+			d.update(msg)
+		case <-timeout:
+			d.t.Fatalf("timed out waiting for %s; view:\n%s", what, ansi.Strip(d.m.View()))
+		}
+	}
+}
+
+func selected(m Model) Player {
+	p, _ := m.selected()
+	return p
+}
+
+func TestModelFollowsPlayer(t *testing.T) {
+	address := privateBus(t)
+	d := newDriver(t, NewModel(config.MediaConfig{Bus: address}))
+	d.until("the connection", func(m Model) bool { return m.bus != nil })
+	if !strings.Contains(ansi.Strip(d.m.View()), "no media players") {
+		t.Errorf("view without players:\n%s", ansi.Strip(d.m.View()))
+	}
+
+	// a player that appears later is picked up from NameOwnerChanged
+	bus := player(t, address, "standin")
+	d.until("the player", func(m Model) bool { return len(m.players) == 1 })
+	p := selected(d.m)
+	if p.Bus != mprisPrefix+"standin" || p.Owner != bus.Name() || p.Identity != "Stand-in" {
+		t.Errorf("player = %+v", p)
+	}
+	if p.Title != "Stand-in One" || p.Status != "Stopped" || p.Length != 3*time.Minute+12*time.Second {
+		t.Errorf("player state = %q %q %v", p.Title, p.Status, p.Length)
+	}
+	if len(p.Artists) != 1 || p.Artists[0] != "The Placeholders" || p.TrackID != "/org/termctrl/standin/track/0" {
+		t.Errorf("metadata = %v %q", p.Artists, p.TrackID)
+	}
+
+	// transport keys call the player, which answers with PropertiesChanged
+	d.key(" ")
+	d.until("playback", func(m Model) bool { return selected(m).Status == "Playing" })
+	d.key("n")
+	d.until("the next track", func(m Model) bool { return selected(m).Title == "Second Stand-in" })
+	if p := selected(d.m); p.TrackID != "/org/termctrl/standin/track/1" || p.Position > time.Second {
+		t.Errorf("after next: track %q position %v", p.TrackID, p.Position)
+	}
+	view := ansi.Strip(d.m.View())
+	for _, want := range []string{"▶ Second Stand-in", "The Placeholders — Test Signals", "/ 4:05"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view lacks %q:\n%s", want, view)
+		}
+	}
+
+	// Seeked carries the new position
+	d.key("s")
+	d.until("stop", func(m Model) bool { return selected(m).Status == "Stopped" })
+	d.update(tea.KeyMsg{Type: tea.KeyRight})
+	d.until("the seek", func(m Model) bool { return selected(m).Position == 5*time.Second })
+
+	// the player leaving removes it
+	bus.Close()
+	d.until("the player to go", func(m Model) bool { return len(m.players) == 0 })
+}
+
+func TestModelListsRunningPlayers(t *testing.T) {
+	address := privateBus(t)
+	player(t, address, "zed")
+	player(t, address, "alpha")
+	d := newDriver(t, NewModel(config.MediaConfig{Bus: address}))
+	d.until("the players", func(m Model) bool { return len(m.players) == 2 })
+	if d.m.players[0].Bus != mprisPrefix+"alpha" || d.m.players[1].Bus != mprisPrefix+"zed" {
+		t.Errorf("players not sorted by bus name: %s, %s", d.m.players[0].Bus, d.m.players[1].Bus)
+	}
+	d.key("]")
+	if selected(d.m).Bus != mprisPrefix+"zed" {
+		t.Errorf("] selected %s", selected(d.m).Bus)
+	}
+}
+
+func TestModelReconnects(t *testing.T) {
+	d := newDriver(t, NewModel(config.MediaConfig{Bus: "unix:path=/nonexistent/bus"}))
+	d.until("the failure", func(m Model) bool { return !m.connecting })
+	if d.m.reason == nil || d.m.backoff != retryMin {
+		t.Fatalf("reason %v backoff %v", d.m.reason, d.m.backoff)
+	}
+	if !strings.Contains(ansi.Strip(d.m.View()), "r retry") {
+		t.Errorf("disconnected view:\n%s", ansi.Strip(d.m.View()))
+	}
+	d.key("r")
+	if !d.m.connecting {
+		t.Error("r does not retry")
+	}
+}
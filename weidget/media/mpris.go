@@ -0,0 +1,163 @@
+package media
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/antiloger/termctlr/dbus"
+)
+
+// MPRIS names, from the MPRIS D-Bus interface specification.
+const (
+	mprisPrefix = "org.mpris.MediaPlayer2."
+	mprisPath   = dbus.ObjectPath("/org/mpris/MediaPlayer2")
+	rootIfc     = "org.mpris.MediaPlayer2"
+	playerIfc   = "org.mpris.MediaPlayer2.Player"
+)
+
+// matchRules subscribe to everything the widget reacts to: property
+// changes and seeks of players, and players coming and going.
+var matchRules = []string{
+	"type='signal',interface='" + dbus.PropertiesIfc + "',member='PropertiesChanged',path='" + string(mprisPath) + "'",
+	"type='signal',interface='" + playerIfc + "',member='Seeked',path='" + string(mprisPath) + "'",
+	"type='signal',sender='org.freedesktop.DBus',interface='org.freedesktop.DBus',member='NameOwnerChanged',arg0namespace='org.mpris.MediaPlayer2'",
+}
+
+// Player is what is known about one MPRIS player.
+type Player struct {
+	Bus      string // well-known name, org.mpris.MediaPlayer2.<name>
+	Owner    string // unique name, the sender of its signals
+	Identity string
+
+	Status  string // Playing, Paused or Stopped
+	TrackID dbus.ObjectPath
+	Title   string
+	Artists []string
+	Album   string
+	Length  time.Duration
+
+	Position time.Duration // at PosAt; players only signal seeks
+	PosAt    time.Time
+	Rate     float64
+
+	CanSeek, CanNext, CanPrev bool
+}
+
+// Name is the short player name: Identity, or the bus name suffix.
+func (p Player) Name() string {
+	if p.Identity != "" {
+		return p.Identity
+	}
+	return strings.TrimPrefix(p.Bus, mprisPrefix)
+}
+
+// PositionAt extrapolates the position to t while playing.
+func (p Player) PositionAt(t time.Time) time.Duration {
+	pos := p.Position
+	if p.Status == "Playing" && !p.PosAt.IsZero() {
+		pos += time.Duration(float64(t.Sub(p.PosAt)) * p.Rate)
+	}
+	if p.Length > 0 {
+		pos = min(pos, p.Length)
+	}
+	return max(pos, 0)
+}
+
+// apply updates the player from org.mpris.MediaPlayer2.Player properties,
+// all of them or a PropertiesChanged subset.
+func (p *Player) apply(props map[string]any, now time.Time) {
+	// metadata first: a new track resets the position read below
+	if md, ok := props["Metadata"].(map[string]any); ok {
+		p.applyMetadata(dbus.Unwrap(md))
+	}
+	for k, v := range props {
+		switch k {
+		case "PlaybackStatus":
+			// keep the extrapolated position when pausing or resuming
+			p.Position, p.PosAt = p.PositionAt(now), now
+			p.Status, _ = v.(string)
+		case "Position":
+			p.Position, p.PosAt = micros(v), now
+		case "Rate":
+			p.Position, p.PosAt = p.PositionAt(now), now
+			p.Rate, _ = v.(float64)
+		case "CanSeek":
+			p.CanSeek, _ = v.(bool)
+		case "CanGoNext":
+			p.CanNext, _ = v.(bool)
+		case "CanGoPrevious":
+			p.CanPrev, _ = v.(bool)
+		}
+	}
+}
+
+func (p *Player) applyMetadata(md map[string]any) {
+	id, _ := md["mpris:trackid"].(dbus.ObjectPath)
+	if id != p.TrackID || id == "" {
+		p.Position, p.PosAt = 0, time.Time{}
+	}
+	p.TrackID = id
+	p.Title, _ = md["xesam:title"].(string)
+	p.Album, _ = md["xesam:album"].(string)
+	p.Artists, _ = md["xesam:artist"].([]string)
+	p.Length = micros(md["mpris:length"])
+}
+
+// micros reads a time in microseconds. The spec says int64, but players
+// send whatever integer type their binding picked.
+func micros(v any) time.Duration {
+	var us int64
+	switch v := v.(type) {
+	case int64:
+		us = v
+	case uint64:
+		us = int64(v)
+	case int32:
+		us = int64(v)
+	case uint32:
+		us = int64(v)
+	case float64:
+		us = int64(v)
+	}
+	return time.Duration(us) * time.Microsecond
+}
+
+// loadPlayer reads everything about the player on a bus name.
+func loadPlayer(bus *dbus.Conn, name string) (Player, error) {
+	p := Player{Bus: name, Rate: 1}
+	owner, err := bus.GetNameOwner(name)
+	if err != nil {
+		return p, err
+	}
+	p.Owner = owner
+	if root, err := bus.GetAll(name, mprisPath, rootIfc); err == nil {
+		p.Identity, _ = root["Identity"].(string)
+	}
+	props, err := bus.GetAll(name, mprisPath, playerIfc)
+	if err != nil {
+		return p, err
+	}
+	p.apply(props, time.Now())
+	return p, nil
+}
+
+// loadPlayers reads every player on the bus, sorted by bus name. Players
+// that fail to answer are left out.
+func loadPlayers(bus *dbus.Conn) ([]Player, error) {
+	names, err := bus.ListNames()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	var players []Player
+	for _, n := range names {
+		if !strings.HasPrefix(n, mprisPrefix) {
+			continue
+		}
+		if p, err := loadPlayer(bus, n); err == nil {
+			players = append(players, p)
+		}
+	}
+	return players, nil
+}
@@ -0,0 +1,168 @@
+package media
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/antiloger/termctlr/dbus"
+)
+
+// standIn is a fake MPRIS player with a fixed playlist, for testing the
+// widget on a private bus. It behaves like a real player: transport calls
+// change its state and it signals the changes.
+type standIn struct {
+	bus *dbus.Conn
+
+	mu     sync.Mutex
+	track  int
+	status string
+	pos    time.Duration // at posAt while playing
+	posAt  time.Time
+}
+
+type standInTrack struct {
+	title, artist, album string
+	length               time.Duration
+}
+
+var standInTracks = []standInTrack{
+	{"Stand-in One", "The Placeholders", "Test Signals", 3*time.Minute + 12*time.Second},
+	{"Second Stand-in", "The Placeholders", "Test Signals", 4*time.Minute + 5*time.Second},
+	{"Last Call", "Loopback", "Local Bus", 2*time.Minute + 48*time.Second},
+}
+
+// serveStandIn claims org.mpris.MediaPlayer2.<name> on bus and answers
+// MPRIS calls until the connection is closed.
+func serveStandIn(bus *dbus.Conn, name string) (*standIn, error) {
+	s := &standIn{bus: bus, status: "Stopped"}
+	bus.Handle(s.handle)
+	if err := bus.RequestName(mprisPrefix + name); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *standIn) handle(m *dbus.Message) ([]any, error) {
+	if m.Path != mprisPath {
+		return nil, &dbus.Error{Name: "org.freedesktop.DBus.Error.UnknownObject", Body: []any{string(m.Path)}}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch m.Interface + "." + m.Member {
+	case dbus.PropertiesIfc + ".GetAll":
+		iface, _ := arg[string](m, 0)
+		return []any{s.props(iface)}, nil
+	case dbus.PropertiesIfc + ".Get":
+		iface, _ := arg[string](m, 0)
+		prop, _ := arg[string](m, 1)
+		v, ok := s.props(iface)[prop]
+		if !ok {
+			return nil, &dbus.Error{Name: "org.freedesktop.DBus.Error.UnknownProperty", Body: []any{prop}}
+		}
+		return []any{v}, nil
+	case playerIfc + ".PlayPause":
+		if s.status == "Playing" {
+			s.setStatus("Paused")
+		} else {
+			s.setStatus("Playing")
+		}
+	case playerIfc + ".Play":
+		s.setStatus("Playing")
+	case playerIfc + ".Pause":
+		s.setStatus("Paused")
+	case playerIfc + ".Stop":
+		s.setStatus("Stopped")
+		s.pos = 0
+	case playerIfc + ".Next":
+		s.setTrack((s.track + 1) % len(standInTracks))
+	case playerIfc + ".Previous":
+		s.setTrack((s.track + len(standInTracks) - 1) % len(standInTracks))
+	case playerIfc + ".Seek":
+		off, _ := arg[int64](m, 0)
+		s.seek(s.position() + time.Duration(off)*time.Microsecond)
+	case playerIfc + ".SetPosition":
+		at, _ := arg[int64](m, 1)
+		s.seek(time.Duration(at) * time.Microsecond)
+	case rootIfc + ".Raise", rootIfc + ".Quit":
+	default:
+		return nil, &dbus.Error{Name: "org.freedesktop.DBus.Error.UnknownMethod",
+			Body: []any{fmt.Sprintf("no method %s.%s", m.Interface, m.Member)}}
+	}
+	return nil, nil
+}
+
+func arg[T any](m *dbus.Message, i int) (T, bool) {
+	var zero T
+	if i >= len(m.Body) {
+		return zero, false
+	}
+	v, ok := m.Body[i].(T)
+	return v, ok
+}
+
+func (s *standIn) props(iface string) map[string]dbus.Variant {
+	v := dbus.MakeVariant
+	switch iface {
+	case rootIfc:
+		return map[string]dbus.Variant{
+			"Identity":     v("Stand-in"),
+			"CanQuit":      v(false),
+			"CanRaise":     v(false),
+			"HasTrackList": v(false),
+		}
+	case playerIfc:
+		return map[string]dbus.Variant{
+			"PlaybackStatus": v(s.status),
+			"Metadata":       v(s.metadata()),
+			"Position":       v(int64(s.position() / time.Microsecond)),
+			"Rate":           v(1.0),
+			"CanSeek":        v(true),
+			"CanGoNext":      v(true),
+			"CanGoPrevious":  v(true),
+			"CanPlay":        v(true),
+			"CanPause":       v(true),
+			"CanControl":     v(true),
+		}
+	}
+	return map[string]dbus.Variant{}
+}
+
+func (s *standIn) metadata() map[string]dbus.Variant {
+	t := standInTracks[s.track]
+	return map[string]dbus.Variant{
+		"mpris:trackid": dbus.MakeVariant(dbus.ObjectPath(fmt.Sprintf("/org/termctrl/standin/track/%d", s.track))),
+		"mpris:length":  dbus.MakeVariant(int64(t.length / time.Microsecond)),
+		"xesam:title":   dbus.MakeVariant(t.title),
+		"xesam:artist":  dbus.MakeVariant([]string{t.artist}),
+		"xesam:album":   dbus.MakeVariant(t.album),
+	}
+}
+
+func (s *standIn) position() time.Duration {
+	if s.status != "Playing" {
+		return s.pos
+	}
+	return min(s.pos+time.Since(s.posAt), standInTracks[s.track].length)
+}
+
+func (s *standIn) setStatus(status string) {
+	s.pos, s.posAt = s.position(), time.Now()
+	s.status = status
+	s.changed(map[string]dbus.Variant{"PlaybackStatus": dbus.MakeVariant(status)})
+}
+
+func (s *standIn) setTrack(i int) {
+	s.track, s.pos, s.posAt = i, 0, time.Now()
+	s.changed(map[string]dbus.Variant{"Metadata": dbus.MakeVariant(s.metadata())})
+}
+
+func (s *standIn) seek(to time.Duration) {
+	s.pos, s.posAt = max(0, min(to, standInTracks[s.track].length)), time.Now()
+	s.bus.Emit(mprisPath, playerIfc, "Seeked", int64(s.pos/time.Microsecond))
+}
+
+func (s *standIn) changed(props map[string]dbus.Variant) {
+	s.bus.Emit(mprisPath, dbus.PropertiesIfc, "PropertiesChanged", playerIfc, props, []string{})
+}
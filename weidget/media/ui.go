@@ -0,0 +1,121 @@
+package media
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antiloger/termctlr/theme"
+	"github.com/antiloger/termctlr/weidget/components"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const barWidth = 24
+
+func (m Model) View() string {
+	t := theme.Current()
+	if m.bus == nil {
+		return m.disconnectedView()
+	}
+	p, ok := m.selected()
+	if !ok {
+		return lipgloss.JoinVertical(lipgloss.Left,
+			t.Style(theme.Muted).Render("no media players"),
+			t.Style(theme.Muted).Render("players show up here when they start"),
+		)
+	}
+
+	title := p.Title
+	if title == "" {
+		title = "—"
+	}
+	lines := []string{
+		statusIcon(p.Status) + " " + t.Style(theme.Text).Bold(true).Render(title),
+	}
+	if sub := byline(p); sub != "" {
+		lines = append(lines, t.Style(theme.Muted).Render(sub))
+	}
+	lines = append(lines, m.progressView(p), m.playersView())
+	if m.err != nil {
+		lines = append(lines, t.Style(theme.Alert).Render(m.err.Error()))
+	}
+	lines = append(lines, t.Style(theme.Muted).Render("space play/pause · n p track · ← → seek · [ ] player"))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+func statusIcon(status string) string {
+	t := theme.Current()
+	switch status {
+	case "Playing":
+		return t.Style(theme.Accent).Render("▶")
+	case "Paused":
+		return t.Style(theme.Muted).Render("⏸")
+	}
+	return t.Style(theme.Muted).Render("■")
+}
+
+// byline is "artist — album", whichever of them the track has.
+func byline(p Player) string {
+	var parts []string
+	if len(p.Artists) > 0 {
+		parts = append(parts, strings.Join(p.Artists, ", "))
+	}
+	if p.Album != "" {
+		parts = append(parts, p.Album)
+	}
+	return strings.Join(parts, " — ")
+}
+
+func (m Model) progressView(p Player) string {
+	t := theme.Current()
+	pos := p.PositionAt(m.now)
+	if p.Length <= 0 {
+		// streams have no length; show how long it has played
+		empty := t.Style(theme.GaugeEmpty).Render(strings.Repeat(t.Glyphs.GaugeEmpty, barWidth))
+		return lipgloss.JoinHorizontal(lipgloss.Center, empty, "  ", formatTime(pos))
+	}
+	g := components.NewGauge(pos.Seconds(), p.Length.Seconds())
+	g.Role = theme.Accent
+	return lipgloss.JoinHorizontal(lipgloss.Center,
+		g.Render(barWidth), "  ", formatTime(pos)+" / "+formatTime(p.Length))
+}
+
+// playersView lists the players, the selected one highlighted.
+func (m Model) playersView() string {
+	t := theme.Current()
+	names := make([]string, len(m.players))
+	for i, p := range m.players {
+		if i == m.sel {
+			names[i] = t.Style(theme.Focus).Render("● " + p.Name())
+		} else {
+			names[i] = t.Style(theme.Muted).Render(p.Name())
+		}
+	}
+	return strings.Join(names, t.Style(theme.Muted).Render(" · "))
+}
+
+// disconnectedView explains why there are no players and when the next
+// attempt is due.
+func (m Model) disconnectedView() string {
+	t := theme.Current()
+	status := "connecting…"
+	if !m.connecting && m.reason != nil {
+		wait := max(m.retryAt.Sub(m.now).Round(time.Second), 0)
+		status = fmt.Sprintf("retrying in %s · r retry now", wait)
+	}
+	lines := []string{t.Style(theme.Alert).Bold(true).Render("media: no session bus")}
+	if m.reason != nil {
+		lines = append(lines, t.Style(theme.Muted).Render(m.reason.Error()))
+	}
+	lines = append(lines, t.Style(theme.Muted).Render(status))
+	return strings.Join(lines, "\n")
+}
+
+// formatTime is m:ss, or h:mm:ss from an hour on.
+func formatTime(d time.Duration) string {
+	s := int(d.Seconds())
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
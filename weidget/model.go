@@ -3,6 +3,8 @@ package weidget
 import (
 	"fmt"
 
+	"github.com/antiloger/termctlr/message"
+	"github.com/antiloger/termctlr/theme"
 	"github.com/antiloger/termctlr/types"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -11,6 +13,20 @@ import (
 type Weidget interface {
 	tea.Model
 	SetPosition(x, y int)
+	Name() string // stable id used by focus commands, e.g. "audio"
+}
+
+// Closer is implemented by widgets that hold on to things outliving the
+// program, like child processes. Close is called once, after it exits.
+type Closer interface {
+	Close()
+}
+
+// KeyCapturer is implemented by widgets that want every key press while
+// they have focus, tab included, like a terminal running a shell. They
+// must offer a key of their own to let go of the keyboard.
+type KeyCapturer interface {
+	CapturesKeys() bool
 }
 
 type WeidgetScreen struct {
@@ -19,19 +35,45 @@ type WeidgetScreen struct {
 	screenSize types.Position
 	idle       bool
 	layout     Layout
+	weights    []float64 // relative pane sizes for split layouts
+	drag       int       // split border being dragged, -1 when none
 	Tick       int
 }
 
 func NewWeidgetScreen(layout Layout, weidgets ...Weidget) WeidgetScreen {
+	weights := make([]float64, len(weidgets))
+	for i := range weights {
+		weights[i] = 1
+	}
 	return WeidgetScreen{
 		weidgets: weidgets,
 		focus:    0,
 		idle:     true,
 		layout:   layout,
+		weights:  weights,
+		drag:     -1,
 		Tick:     0,
 	}
 }
 
+// Names returns the names of the widgets, in order, for focus commands.
+func (W WeidgetScreen) Names() []string {
+	names := make([]string, len(W.weidgets))
+	for i, w := range W.weidgets {
+		names[i] = w.Name()
+	}
+	return names
+}
+
+// Close closes the widgets that are Closers.
+func (W WeidgetScreen) Close() {
+	for _, widget := range W.weidgets {
+		if c, ok := widget.(Closer); ok {
+			c.Close()
+		}
+	}
+}
+
 func (W WeidgetScreen) Init() tea.Cmd {
 	var cmds []tea.Cmd
 	cmds = append(cmds, types.Tick()) // ONE tick for all widgets
@@ -44,6 +86,20 @@ func (W WeidgetScreen) Init() tea.Cmd {
 func (W WeidgetScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// a widget showing an overlay owns the keyboard until it closes it
+		if i, _ := W.overlay(); i >= 0 {
+			updated, cmd := W.weidgets[i].Update(msg)
+			W.weidgets[i] = updated.(Weidget)
+			return W, cmd
+		}
+		if len(W.weidgets) > 0 {
+			if c, ok := W.weidgets[W.focus].(KeyCapturer); ok && c.CapturesKeys() {
+				updated, cmd := W.weidgets[W.focus].Update(msg)
+				W.weidgets[W.focus] = updated.(Weidget)
+				return W, cmd
+			}
+		}
+
 		// Focus navigation
 		switch msg.String() {
 		case "tab":
@@ -63,18 +119,34 @@ func (W WeidgetScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			W.weidgets[W.focus] = updated.(Weidget)
 			return W, cmd
 		}
+	case message.FocusMsg:
+		for i, widget := range W.weidgets {
+			if widget.Name() == string(msg) {
+				W.focus = i
+				break
+			}
+		}
+		return W, nil
+
+	case tea.MouseMsg:
+		return W.handleMouse(msg)
+
 	case tea.WindowSizeMsg:
 		W.screenSize.X = msg.Width
 		W.screenSize.Y = msg.Height
+		return W, W.resize()
 
 	case types.TickMsg:
-		// Broadcast to ALL widgets but screen owns the next tick
+		// Broadcast to ALL widgets but screen owns the next tick;
+		// widgets may still react to a tick (e.g. a timer finishing)
+		cmds := []tea.Cmd{types.Tick()} // ONE tick continues ✅
 		for i, widget := range W.weidgets {
-			updated, _ := widget.Update(msg) // ignore widget's cmd
+			updated, cmd := widget.Update(msg)
 			W.weidgets[i] = updated.(Weidget)
+			cmds = append(cmds, cmd)
 		}
 		W.Tick++
-		return W, types.Tick() // ONE tick continues ✅
+		return W, tea.Batch(cmds...)
 
 	default:
 		// ← EVERYTHING else (tickMsg, WindowSizeMsg, etc.) → ALL widgets
@@ -116,5 +188,9 @@ func (W WeidgetScreen) View() string {
 		layout,
 	)
 
-	return lipgloss.JoinVertical(lipgloss.Left, centered, status)
+	if i, box := W.overlay(); i >= 0 {
+		centered = composite(centered, box, W.screenSize.X, W.screenSize.Y-1)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, centered, theme.Current().Style(theme.Muted).Render(status))
 }
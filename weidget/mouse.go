@@ -0,0 +1,69 @@
+package weidget
+
+import (
+	"github.com/antiloger/termctlr/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// handleMouse hit-tests mouse events against the layout:
+//   - left click on a widget focuses it (the click is forwarded too)
+//   - the wheel goes to the focused widget
+//   - dragging a split border resizes the panes on both sides
+func (W WeidgetScreen) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if len(W.weidgets) == 0 {
+		return W, nil
+	}
+
+	if W.drag >= 0 {
+		switch msg.Action {
+		case tea.MouseActionMotion:
+			pos := msg.X
+			if W.layout == Rows {
+				pos = msg.Y
+			}
+			W.moveSplit(W.drag, pos)
+			return W, W.resize()
+		case tea.MouseActionRelease:
+			W.drag = -1
+			return W, nil
+		}
+	}
+
+	if tea.MouseEvent(msg).IsWheel() {
+		return W.forwardMouse(W.focus, msg)
+	}
+
+	if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+		if i := W.splitAt(msg.X, msg.Y); i >= 0 {
+			W.drag = i
+			return W, nil
+		}
+		for i, p := range W.panes() {
+			if p.outer.Contains(msg.X, msg.Y) {
+				W.focus = i
+				return W.forwardMouse(i, msg)
+			}
+		}
+		return W, nil
+	}
+
+	// other presses, releases and motion go to whatever is under the cursor
+	for i, p := range W.panes() {
+		if p.outer.Contains(msg.X, msg.Y) {
+			return W.forwardMouse(i, msg)
+		}
+	}
+	return W, nil
+}
+
+// forwardMouse hands the event to widget i in its local coordinates.
+func (W WeidgetScreen) forwardMouse(i int, msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	inner := W.panes()[i].inner
+	local := tea.MouseEvent(msg)
+	local.X -= inner.X
+	local.Y -= inner.Y
+
+	updated, cmd := W.weidgets[i].Update(types.MouseMsg{MouseEvent: local})
+	W.weidgets[i] = updated.(Weidget)
+	return W, cmd
+}
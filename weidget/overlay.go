@@ -0,0 +1,54 @@
+package weidget
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Overlayer is implemented by widgets that can put a modal box over the
+// whole dashboard (alarm banners, log views). While a widget's overlay is
+// up it receives every key press, whichever widget has focus.
+type Overlayer interface {
+	Overlay() (string, bool)
+}
+
+// overlay returns the index and content of the first active overlay.
+func (W WeidgetScreen) overlay() (int, string) {
+	for i, widget := range W.weidgets {
+		if o, ok := widget.(Overlayer); ok {
+			if box, active := o.Overlay(); active {
+				return i, box
+			}
+		}
+	}
+	return -1, ""
+}
+
+// composite draws box centered on top of bg (both multi-line strings).
+func composite(bg, box string, width, height int) string {
+	lines := strings.Split(bg, "\n")
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	boxLines := strings.Split(box, "\n")
+	boxW := lipgloss.Width(box)
+	x := max((width-boxW)/2, 0)
+	y := max((height-len(boxLines))/2, 0)
+
+	for i, bl := range boxLines {
+		row := y + i
+		if row >= len(lines) {
+			break
+		}
+		line := lines[row]
+		if w := ansi.StringWidth(line); w < x+boxW {
+			line += strings.Repeat(" ", x+boxW-w)
+		}
+		left := ansi.Truncate(line, x, "")
+		right := ansi.TruncateLeft(line, x+boxW, "")
+		lines[row] = left + bl + strings.Repeat(" ", boxW-ansi.StringWidth(bl)) + right
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,192 @@
+package sysinfo
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/shirou/gopsutil/v4/host"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// GetLocale returns the locale in effect for text, following the usual
+// LC_ALL > LC_CTYPE > LANG precedence.
+func GetLocale() string {
+	for _, v := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if l := os.Getenv(v); l != "" {
+			return l
+		}
+	}
+	return "C"
+}
+
+// terminalEnv maps variables that terminal emulators export to their name.
+var terminalEnv = []struct{ env, name string }{
+	{"KITTY_WINDOW_ID", "kitty"},
+	{"ALACRITTY_WINDOW_ID", "alacritty"},
+	{"WEZTERM_PANE", "wezterm"},
+	{"KONSOLE_VERSION", "konsole"},
+	{"GNOME_TERMINAL_SCREEN", "gnome-terminal"},
+	{"TILIX_ID", "tilix"},
+	{"WT_SESSION", "windows-terminal"},
+}
+
+// notTerminals are parent processes skipped when looking for the emulator.
+var notTerminals = map[string]bool{
+	"sh": true, "bash": true, "zsh": true, "fish": true, "dash": true, "ksh": true,
+	"nu": true, "sudo": true, "su": true, "doas": true, "login": true, "go": true,
+}
+
+// GetTerminal names the terminal emulator TermCTRL runs in: TERM_PROGRAM
+// when set, then emulator specific variables, then the first parent
+// process that isn't a shell. $TERM is the last resort.
+func GetTerminal() string {
+	if p := os.Getenv("TERM_PROGRAM"); p != "" {
+		if v := os.Getenv("TERM_PROGRAM_VERSION"); v != "" {
+			return p + " " + v
+		}
+		return p
+	}
+	for _, t := range terminalEnv {
+		if os.Getenv(t.env) != "" {
+			return t.name
+		}
+	}
+
+	self := filepath.Base(os.Args[0])
+	proc, err := process.NewProcess(int32(os.Getppid()))
+	for i := 0; err == nil && i < 8; i++ {
+		name, nerr := proc.Name()
+		if nerr != nil || proc.Pid <= 1 {
+			break
+		}
+		if !notTerminals[name] && name != self {
+			return name
+		}
+		proc, err = proc.Parent()
+	}
+
+	if t := os.Getenv("TERM"); t != "" {
+		return t
+	}
+	return "unknown"
+}
+
+// GetIP returns the address of the interface holding the default route.
+// Connecting a UDP socket sends nothing; it only asks the kernel which
+// source address it would use. Without a route the first non-loopback
+// address is used.
+func GetIP() string {
+	if conn, err := net.Dial("udp", "192.0.2.1:9"); err == nil {
+		defer conn.Close()
+		if addr, ok := conn.LocalAddr().(*net.UDPAddr); ok {
+			return addr.IP.String()
+		}
+	}
+
+	ifaces, _ := net.Interfaces()
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, _ := iface.Addrs()
+		for _, a := range addrs {
+			if ipnet, ok := a.(*net.IPNet); ok && ipnet.IP.To4() != nil {
+				return ipnet.IP.String()
+			}
+		}
+	}
+	return "offline"
+}
+
+// GetVirtualization reports the container runtime or hypervisor the
+// system runs under, or "none" on bare metal.
+func GetVirtualization(info *host.InfoStat) string {
+	switch {
+	case fileExists("/.dockerenv"):
+		return "docker (container)"
+	case fileExists("/run/.containerenv"):
+		return "podman (container)"
+	}
+	if c := os.Getenv("container"); c != "" { // systemd-nspawn, lxc, flatpak...
+		return c + " (container)"
+	}
+	if info != nil && info.VirtualizationRole == "guest" && info.VirtualizationSystem != "" {
+		return info.VirtualizationSystem + " (vm)"
+	}
+	return "none"
+}
+
+// GetPackages counts installed packages for every package manager found,
+// e.g. "1834 (dpkg), 12 (flatpak)". It may spawn rpm, so it should not run
+// on the UI goroutine.
+func GetPackages() string {
+	var parts []string
+	add := func(n int, manager string) {
+		if n > 0 {
+			parts = append(parts, fmt.Sprintf("%d (%s)", n, manager))
+		}
+	}
+
+	add(countDpkg("/var/lib/dpkg/status"), "dpkg")
+	if _, err := exec.LookPath("rpm"); err == nil {
+		if out, err := exec.Command("rpm", "-qa").Output(); err == nil {
+			add(bytes.Count(out, []byte("\n")), "rpm")
+		}
+	}
+	add(countDirs("/var/lib/pacman/local"), "pacman")
+
+	flatpaks := countDirs("/var/lib/flatpak/app")
+	if home, err := os.UserHomeDir(); err == nil {
+		flatpaks += countDirs(filepath.Join(home, ".local/share/flatpak/app"))
+	}
+	add(flatpaks, "flatpak")
+
+	if len(parts) == 0 {
+		return "unknown"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// countDpkg counts installed packages in the dpkg status database.
+func countDpkg(path string) int {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	n := 0
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		if sc.Text() == "Status: install ok installed" {
+			n++
+		}
+	}
+	return n
+}
+
+func countDirs(dir string) int {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+	n := 0
+	for _, e := range entries {
+		if e.IsDir() {
+			n++
+		}
+	}
+	return n
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
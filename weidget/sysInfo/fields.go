@@ -0,0 +1,85 @@
+package sysinfo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// field is one row of the widget. value formats it from the collected info.
+type field struct {
+	name  string
+	label string
+	value func(S SysInfoWidget) string
+}
+
+// fields in their default order.
+var fields = []field{
+	{"os", "OS", func(S SysInfoWidget) string { return S.Distro }},
+	{"host", "Host", func(S SysInfoWidget) string { return S.Hostname }},
+	{"kernel", "Kernel", func(S SysInfoWidget) string { return S.KernelVersion }},
+	{"uptime", "Uptime", func(S SysInfoWidget) string { return formatUptime(S.Uptime()) }},
+	{"boot", "Boot", func(S SysInfoWidget) string { return S.BootTime.Format("2006-01-02 15:04") }},
+	{"packages", "Packages", func(S SysInfoWidget) string { return S.Packages }},
+	{"shell", "Shell", func(S SysInfoWidget) string { return S.Shell }},
+	{"terminal", "Terminal", func(S SysInfoWidget) string { return S.Terminal }},
+	{"locale", "Locale", func(S SysInfoWidget) string { return S.Locale }},
+	{"cpu", "CPU", func(S SysInfoWidget) string { return S.SystemSpec.PROCESSOR }},
+	{"cores", "Cores", func(S SysInfoWidget) string {
+		return fmt.Sprintf("%d cores / %d threads", S.Cores, S.Threads)
+	}},
+	{"gpu", "GPU", func(S SysInfoWidget) string { return S.SystemSpec.GPU }},
+	{"ram", "RAM", func(S SysInfoWidget) string { return S.SystemSpec.RAM }},
+	{"storage", "Storage", func(S SysInfoWidget) string { return S.SystemSpec.Storage }},
+	{"virt", "Virt", func(S SysInfoWidget) string { return S.Virtualization }},
+	{"ip", "IP", func(S SysInfoWidget) string { return S.IP }},
+}
+
+// FieldNames lists the names accepted in the sysinfo "fields" config.
+func FieldNames() []string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.name
+	}
+	return names
+}
+
+// selectFields picks the configured fields in the configured order. No
+// names means every field. Unknown names are skipped and reported.
+func selectFields(names []string) ([]field, error) {
+	if len(names) == 0 {
+		return fields, nil
+	}
+	var out []field
+	var unknown []string
+next:
+	for _, n := range names {
+		for _, f := range fields {
+			if f.name == strings.ToLower(n) {
+				out = append(out, f)
+				continue next
+			}
+		}
+		unknown = append(unknown, n)
+	}
+	if len(unknown) > 0 {
+		return out, fmt.Errorf("unknown fields %s (want %s)",
+			strings.Join(unknown, ", "), strings.Join(FieldNames(), "|"))
+	}
+	return out, nil
+}
+
+// formatUptime prints a duration as "3d 4h 12m".
+func formatUptime(d time.Duration) string {
+	d = d.Truncate(time.Minute)
+	days := int(d / (24 * time.Hour))
+	hours := int(d/time.Hour) % 24
+	mins := int(d/time.Minute) % 60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh %dm", days, hours, mins)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, mins)
+	}
+	return fmt.Sprintf("%dm", mins)
+}
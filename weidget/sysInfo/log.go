@@ -0,0 +1,57 @@
+package sysinfo
+
+import (
+	"strings"
+	"time"
+
+	"github.com/antiloger/termctlr/theme"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxLog is how many collection errors the log pane keeps.
+const maxLog = 100
+
+// logShown is how many of the newest entries fit in the overlay.
+const logShown = 15
+
+type logEntry struct {
+	At  time.Time
+	Err error
+}
+
+func (S *SysInfoWidget) logError(err error) {
+	S.log = append(S.log, logEntry{At: time.Now(), Err: err})
+	if len(S.log) > maxLog {
+		S.log = S.log[len(S.log)-maxLog:]
+	}
+}
+
+// Overlay shows the collection log over the dashboard while it is open.
+func (S SysInfoWidget) Overlay() (string, bool) {
+	if !S.showLog {
+		return "", false
+	}
+	t := theme.Current()
+
+	var lines []string
+	if len(S.log) == 0 {
+		lines = append(lines, t.Style(theme.Muted).Render("no collection errors"))
+	}
+	for _, e := range S.log[max(len(S.log)-logShown, 0):] {
+		lines = append(lines,
+			t.Style(theme.Muted).Render(e.At.Format("15:04:05"))+" "+t.Style(theme.Alert).Render(e.Err.Error()))
+	}
+
+	body := lipgloss.JoinVertical(lipgloss.Left,
+		t.Style(theme.Accent).Bold(true).Render("sysinfo log"),
+		"",
+		strings.Join(lines, "\n"),
+		"",
+		t.Style(theme.Muted).Render("r retry · l/esc close"),
+	)
+	return lipgloss.NewStyle().
+		Border(t.Border, true).
+		BorderForeground(t.Color(theme.Focus)).
+		Padding(0, 2).
+		Render(body), true
+}
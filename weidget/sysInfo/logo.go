@@ -0,0 +1,76 @@
+package sysinfo
+
+import "strings"
+
+// logos are small neofetch-style distro logos keyed by the os-release ID
+// (gopsutil's Platform). "linux" is the fallback.
+var logos = map[string]string{
+	"linux": `
+    ___
+   (.. |
+   (<> |
+  / __  \
+ ( /  \ /|
+_/\ __)/_)
+\/-____\/`,
+	"debian": `
+  _____
+ /  __ \
+|  /    |
+|  \___-
+-_
+  --_`,
+	"ubuntu": `
+         _
+     ---(_)
+ _/  ---  \
+(_) |   |
+  \  --- _/
+     ---(_)`,
+	"arch": `
+      /\
+     /  \
+    /\   \
+   /      \
+  /   ,,   \
+ /   |  |  -\
+/_-''    ''-_\`,
+	"fedora": `
+      _____
+     /   __)\
+     |  /  \ \
+  ___|  |__/ /
+ / (_    _)_/
+/ /  |  |
+\ \__/  |
+ \(_____/`,
+	"alpine": `
+   /\ /\
+  // \  \
+ //   \  \
+///    \  \
+//      \  \
+         \`,
+	"linuxmint": `
+ ___________
+|_          \
+  | | _____ |
+  | | | | | |
+  | | | | | |
+  | \_____/ |
+  \_________/`,
+}
+
+// Logo returns the logo for a distro id, falling back to its family and
+// then to Tux. "none" disables the logo.
+func Logo(ids ...string) string {
+	for _, id := range ids {
+		if id == "none" {
+			return ""
+		}
+		if l, ok := logos[strings.ToLower(id)]; ok {
+			return strings.TrimPrefix(l, "\n")
+		}
+	}
+	return strings.TrimPrefix(logos["linux"], "\n")
+}
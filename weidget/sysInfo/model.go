@@ -1,16 +1,19 @@
 package sysinfo
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/antiloger/termctlr/config"
+	"github.com/antiloger/termctlr/theme"
+	"github.com/antiloger/termctlr/types"
+	"github.com/antiloger/termctlr/weidget/components"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/shirou/gopsutil/v4/cpu"
-	"github.com/shirou/gopsutil/v4/disk"
-	"github.com/shirou/gopsutil/v4/host"
-	"github.com/shirou/gopsutil/v4/mem"
 )
 
 type SystemSpec struct {
@@ -20,87 +23,257 @@ type SystemSpec struct {
 	Storage   string
 }
 
+// facts is one collection from the provider: every value and the
+// collectors that failed.
+type facts struct {
+	Username       string
+	Hostname       string
+	Distro         string
+	KernelVersion  string
+	Shell          string
+	Terminal       string
+	Locale         string
+	IP             string
+	Virtualization string
+	Cores          int
+	Threads        int
+	BootTime       time.Time
+	SystemSpec     SystemSpec
+
+	platform []string        // os-release ID and family, for the logo
+	errs     []*CollectError // failures of the collection
+}
+
 type SysInfoWidget struct {
-	Username      string
-	Distro        string
-	KernelVersion string
-	Shell         string
-	SystemSpec    SystemSpec
+	facts
+	Packages  string // filled in by Init, counting can be slow
+	collected bool   // the first collection is in
+
+	now      time.Time
+	fields   []field
+	logoName string // configured logo, "" or "auto" to follow the distro
+	logo     string
+	fieldErr error
+
+	provider Provider
+	log      []logEntry // every failure since start, for the log pane
+	showLog  bool
 }
 
-func NewSysInfoWidget() SysInfoWidget {
-	S := SysInfoWidget{}
-	S.GetSystemSpec()
-	S.GetSysInfo()
+// packagesMsg carries the package counts collected off the UI goroutine.
+type packagesMsg string
+
+// collectedMsg carries a collection made off the UI goroutine: host
+// lookups, process walks and dialling out can all be slow.
+type collectedMsg facts
+
+func NewSysInfoWidget(cfg config.SysInfoConfig) SysInfoWidget {
+	return NewSysInfoWidgetWithProvider(cfg, System{})
+}
+
+// NewSysInfoWidgetWithProvider builds the widget on top of p instead of
+// the running system.
+func NewSysInfoWidgetWithProvider(cfg config.SysInfoConfig, p Provider) SysInfoWidget {
+	S := SysInfoWidget{now: time.Now(), Packages: "counting…", provider: p, logoName: cfg.Logo}
+	S.fields, S.fieldErr = selectFields(cfg.Fields)
+	if S.logoName != "" && S.logoName != "auto" {
+		S.logo = Logo(S.logoName)
+	}
 	return S
 }
 
 func (S SysInfoWidget) Init() tea.Cmd {
-	return nil
+	p := S.provider
+	return tea.Batch(
+		func() tea.Msg { return collectedMsg(collect(p)) },
+		func() tea.Msg { return packagesMsg(GetPackages()) },
+	)
 }
 
 func (S SysInfoWidget) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case types.TickMsg:
+		S.now = time.Time(msg)
+	case packagesMsg:
+		S.Packages = string(msg)
+	case collectedMsg:
+		S.facts, S.collected = facts(msg), true
+		for _, e := range S.errs {
+			S.logError(e)
+		}
+		if S.logoName == "" || S.logoName == "auto" {
+			S.logo = Logo(S.platform...)
+		}
+	case tea.KeyMsg:
+		return S.handleKey(msg.String())
+	}
 	return S, nil
 }
 
-func (S SysInfoWidget) View() string {
-	s := lipgloss.NewStyle().Margin(1, 2).Render(fmt.Sprintf("CPU: %s\nGPU: %s\nRAM: %s\nStorage: %s\n\n%s@%s\nKernel: %s\nShell: %s", S.SystemSpec.PROCESSOR, S.SystemSpec.GPU, S.SystemSpec.RAM, S.SystemSpec.Storage, S.Username, S.Distro, S.KernelVersion, S.Shell))
-	return s
+func (S SysInfoWidget) handleKey(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "l":
+		S.showLog = !S.showLog
+	case "esc", "q":
+		if S.showLog {
+			S.showLog = false
+			return S, nil
+		}
+		if key == "q" {
+			return S, tea.Quit
+		}
+	case "r":
+		return S, S.Init()
+	}
+	return S, nil
 }
 
-func (S *SysInfoWidget) GetSystemSpec() {
-	// RAM
-	vmStat, _ := mem.VirtualMemory()
-	ram := fmt.Sprintf("%d MB", vmStat.Total/1024/1024)
+// Uptime is computed from the boot time so it follows the screen tick.
+func (S SysInfoWidget) Uptime() time.Duration {
+	if S.BootTime.IsZero() {
+		return 0
+	}
+	return S.now.Sub(S.BootTime)
+}
 
-	// CPU
-	cpuInfo, _ := cpu.Info()
-	processor := cpuInfo[0].ModelName
+func (S SysInfoWidget) View() string {
+	t := theme.Current()
+	hostname := S.Hostname
+	if hostname == "" {
+		hostname = "unknown"
+	}
+	header := t.Style(theme.Accent).Bold(true).Render(S.Username) + t.Style(theme.Text).Render("@"+hostname)
 
-	// Storage
-	diskStat, _ := disk.Usage("/")
-	storage := fmt.Sprintf("%d GB", diskStat.Total/1024/1024/1024)
+	rows := make([]components.KV, len(S.fields))
+	for i, f := range S.fields {
+		if !S.collected && f.name != "packages" {
+			rows[i] = components.KV{Key: f.label, Value: "…", Role: theme.Muted}
+			continue
+		}
+		if S.unavailable(f.name) != nil {
+			rows[i] = components.KV{Key: f.label, Value: "unavailable", Role: theme.Muted}
+			continue
+		}
+		rows[i] = components.KV{Key: f.label, Value: f.value(S)}
+	}
+	info := lipgloss.JoinVertical(lipgloss.Left,
+		header,
+		t.Style(theme.Muted).Render(strings.Repeat("─", lipgloss.Width(header))),
+		components.KeyValues(rows, 0),
+	)
+	if S.fieldErr != nil {
+		info += "\n" + t.Style(theme.Alert).Render("sysinfo: "+S.fieldErr.Error())
+	}
+	if n := len(S.errs); n > 0 {
+		info += "\n" + t.Style(theme.Alert).Render(fmt.Sprintf("%d collector(s) failed", n))
+	}
+	info += "\n" + t.Style(theme.Muted).Render("r refresh · l log")
 
-	S.SystemSpec = SystemSpec{
-		RAM:       ram,
-		PROCESSOR: processor,
-		Storage:   storage,
-		GPU:       GetGPU(), // gopsutil doesn't have GPU
+	if S.logo != "" {
+		info = lipgloss.JoinHorizontal(lipgloss.Top,
+			t.Style(theme.Accent).Bold(true).Render(S.logo), "   ", info)
 	}
+	return lipgloss.NewStyle().Margin(1, 2).Render(info)
 }
 
-func (S *SysInfoWidget) GetSysInfo() {
-	hostInfo, _ := host.Info()
-	S.Username = os.Getenv("USER")
-	S.Distro = hostInfo.Platform + " " + hostInfo.PlatformVersion
-	S.KernelVersion = hostInfo.KernelVersion
+// collect reads every fact from the provider. A failing collector only
+// blanks its own fields; its error is kept for the view and the log pane.
+func collect(p Provider) facts {
+	var S facts
+	fail := func(collector string, err error, fields ...string) {
+		S.errs = append(S.errs, &CollectError{Collector: collector, Fields: fields, Err: err})
+	}
+
 	S.Shell = os.Getenv("SHELL")
-}
+	S.Terminal = GetTerminal()
+	S.Locale = GetLocale()
+	S.IP = GetIP()
+
+	if name, err := p.User(); err != nil {
+		fail("user", err)
+		S.Username = "unknown"
+	} else {
+		S.Username = name
+	}
+
+	hostInfo, err := p.Host()
+	if err == nil && hostInfo == nil {
+		err = errNoData
+	}
+	if err != nil {
+		fail("host", err, "os", "host", "kernel", "boot", "uptime")
+		hostInfo = nil
+	} else {
+		S.Distro = hostInfo.Platform + " " + hostInfo.PlatformVersion
+		S.KernelVersion = hostInfo.KernelVersion
+		S.Hostname = hostInfo.Hostname
+		S.platform = []string{hostInfo.Platform, hostInfo.PlatformFamily}
+		S.BootTime = time.Unix(int64(hostInfo.BootTime), 0)
+	}
+	S.Virtualization = GetVirtualization(hostInfo)
+
+	// CPU
+	if cpuInfo, err := p.CPU(); err != nil || len(cpuInfo) == 0 {
+		if err == nil {
+			err = errNoData
+		}
+		fail("cpu", err, "cpu")
+	} else {
+		S.SystemSpec.PROCESSOR = cpuInfo[0].ModelName
+	}
+	var cerr error
+	if S.Cores, cerr = p.CPUCounts(false); cerr == nil {
+		S.Threads, cerr = p.CPUCounts(true)
+	}
+	if cerr != nil {
+		fail("cpu counts", cerr, "cores")
+	}
 
-func GetGPU() string {
-	// Try nvidia first
-	entries, err := os.ReadDir("/proc/driver/nvidia/gpus")
-	if err == nil && len(entries) > 0 {
-		data, _ := os.ReadFile("/proc/driver/nvidia/gpus/" + entries[0].Name() + "/information")
-		for _, line := range strings.Split(string(data), "\n") {
-			if strings.HasPrefix(line, "Model:") {
-				return strings.TrimSpace(strings.SplitN(line, ":", 2)[1])
-			}
+	// RAM
+	if vmStat, err := p.Memory(); err != nil || vmStat == nil {
+		if err == nil {
+			err = errNoData
 		}
+		fail("memory", err, "ram")
+	} else {
+		S.SystemSpec.RAM = fmt.Sprintf("%d MB", vmStat.Total/1024/1024)
 	}
 
-	// Fallback: read from /sys
-	data, err := os.ReadFile("/sys/class/drm/card0/device/uevent")
-	if err == nil {
-		for _, line := range strings.Split(string(data), "\n") {
-			if strings.HasPrefix(line, "DRIVER=") {
-				return strings.SplitN(line, "=", 2)[1]
-			}
+	// Storage
+	if diskStat, err := p.Disk("/"); err != nil || diskStat == nil {
+		if err == nil {
+			err = errNoData
 		}
+		fail("disk", err, "storage")
+	} else {
+		S.SystemSpec.Storage = fmt.Sprintf("%d GB", diskStat.Total/1024/1024/1024)
 	}
 
-	return "unknown"
+	// gopsutil doesn't have GPU
+	if gpu, err := p.GPU(); err != nil {
+		fail("gpu", err, "gpu")
+	} else {
+		S.SystemSpec.GPU = gpu
+	}
+	return S
+}
+
+// errNoData is reported when a collector succeeds but returns nothing.
+var errNoData = errors.New("no data returned")
+
+// unavailable returns the error that left a field without a value.
+func (S SysInfoWidget) unavailable(name string) error {
+	for _, e := range S.errs {
+		if slices.Contains(e.Fields, name) {
+			return e
+		}
+	}
+	return nil
 }
 
 func (S SysInfoWidget) SetPosition(x, y int) {
 }
+
+func (S SysInfoWidget) Name() string {
+	return "sysinfo"
+}
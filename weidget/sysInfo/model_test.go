@@ -0,0 +1,226 @@
+package sysinfo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/antiloger/termctlr/config"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/disk"
+	"github.com/shirou/gopsutil/v4/host"
+	"github.com/shirou/gopsutil/v4/mem"
+)
+
+// fakeProvider answers like a healthy system, except for the collector
+// named in fail.
+type fakeProvider struct {
+	fail string
+}
+
+var errFake = errors.New("simulated failure")
+
+func (f fakeProvider) err(name string) error {
+	if f.fail == name {
+		return errFake
+	}
+	return nil
+}
+
+func (f fakeProvider) Host() (*host.InfoStat, error) {
+	if err := f.err("host"); err != nil {
+		return nil, err
+	}
+	return &host.InfoStat{Hostname: "box", Platform: "arch", PlatformVersion: "rolling", KernelVersion: "6.9.1", BootTime: 1_700_000_000}, nil
+}
+
+func (f fakeProvider) CPU() ([]cpu.InfoStat, error) {
+	if err := f.err("cpu"); err != nil {
+		return nil, err
+	}
+	return []cpu.InfoStat{{ModelName: "Fake CPU 3000"}}, nil
+}
+
+func (f fakeProvider) CPUCounts(logical bool) (int, error) {
+	if err := f.err("cpu counts"); err != nil {
+		return 0, err
+	}
+	if logical {
+		return 8, nil
+	}
+	return 4, nil
+}
+
+func (f fakeProvider) Memory() (*mem.VirtualMemoryStat, error) {
+	if err := f.err("memory"); err != nil {
+		return nil, err
+	}
+	return &mem.VirtualMemoryStat{Total: 16 << 30}, nil
+}
+
+func (f fakeProvider) Disk(path string) (*disk.UsageStat, error) {
+	if err := f.err("disk"); err != nil {
+		return nil, err
+	}
+	return &disk.UsageStat{Total: 512 << 30}, nil
+}
+
+func (f fakeProvider) GPU() (string, error) {
+	if err := f.err("gpu"); err != nil {
+		return "", err
+	}
+	return "Fake GPU", nil
+}
+
+func (f fakeProvider) User() (string, error) {
+	if err := f.err("user"); err != nil {
+		return "", err
+	}
+	return "tester", nil
+}
+
+// collected builds the widget on p and applies one collection, as Init
+// would.
+func collected(p Provider) SysInfoWidget {
+	S := NewSysInfoWidgetWithProvider(config.SysInfoConfig{Logo: "none"}, p)
+	updated, _ := S.Update(collectedMsg(collect(p)))
+	return updated.(SysInfoWidget)
+}
+
+// row is the rendered line of a field label.
+func row(t *testing.T, view, label string) string {
+	t.Helper()
+	for _, line := range strings.Split(ansi.Strip(view), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), label+":") {
+			return line
+		}
+	}
+	t.Fatalf("no %s row in\n%s", label, ansi.Strip(view))
+	return ""
+}
+
+func TestCollectAllHealthy(t *testing.T) {
+	S := collected(fakeProvider{})
+	if len(S.errs) != 0 || len(S.log) != 0 {
+		t.Fatalf("errs = %v, log = %v", S.errs, S.log)
+	}
+	view := S.View()
+	for label, want := range map[string]string{
+		"OS":      "arch rolling",
+		"Host":    "box",
+		"Kernel":  "6.9.1",
+		"CPU":     "Fake CPU 3000",
+		"Cores":   "4 cores / 8 threads",
+		"GPU":     "Fake GPU",
+		"RAM":     "16384 MB",
+		"Storage": "512 GB",
+	} {
+		if r := row(t, view, label); !strings.Contains(r, want) {
+			t.Errorf("%s row = %q, want %q", label, r, want)
+		}
+	}
+	if !strings.Contains(ansi.Strip(view), "tester@box") {
+		t.Errorf("header missing tester@box:\n%s", ansi.Strip(view))
+	}
+}
+
+func TestCollectEachFailure(t *testing.T) {
+	tests := []struct {
+		collector string
+		labels    []string // rows that must read unavailable
+	}{
+		{"host", []string{"OS", "Host", "Kernel", "Boot", "Uptime"}},
+		{"cpu", []string{"CPU"}},
+		{"cpu counts", []string{"Cores"}},
+		{"memory", []string{"RAM"}},
+		{"disk", []string{"Storage"}},
+		{"gpu", []string{"GPU"}},
+		{"user", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.collector, func(t *testing.T) {
+			S := collected(fakeProvider{fail: tt.collector})
+
+			if len(S.errs) != 1 {
+				t.Fatalf("errs = %v, want one", S.errs)
+			}
+			e := S.errs[0]
+			if e.Collector != tt.collector || !errors.Is(e, errFake) {
+				t.Errorf("err = %v, want %s: %v", e, tt.collector, errFake)
+			}
+			if len(S.log) != 1 || S.log[0].Err != error(e) {
+				t.Errorf("log = %v, want the error once", S.log)
+			}
+
+			view := S.View()
+			for _, label := range tt.labels {
+				if r := row(t, view, label); !strings.Contains(r, "unavailable") {
+					t.Errorf("%s row = %q, want unavailable", label, r)
+				}
+			}
+			// the other collectors still fill their rows
+			if tt.collector != "cpu" {
+				if r := row(t, view, "CPU"); !strings.Contains(r, "Fake CPU 3000") {
+					t.Errorf("CPU row = %q", r)
+				}
+			}
+			if !strings.Contains(ansi.Strip(view), "1 collector(s) failed") {
+				t.Errorf("view does not count the failure:\n%s", ansi.Strip(view))
+			}
+
+			overlay, _ := S.handleKey("l")
+			box, ok := overlay.(SysInfoWidget).Overlay()
+			if !ok || !strings.Contains(ansi.Strip(box), tt.collector+": "+errFake.Error()) {
+				t.Errorf("log pane does not show the failure:\n%s", ansi.Strip(box))
+			}
+		})
+	}
+}
+
+func TestCollectUserFallback(t *testing.T) {
+	S := collected(fakeProvider{fail: "user"})
+	if S.Username != "unknown" {
+		t.Errorf("Username = %q, want unknown", S.Username)
+	}
+}
+
+// nilProvider succeeds without data, which must not be taken as data.
+type nilProvider struct{ fakeProvider }
+
+func (nilProvider) Host() (*host.InfoStat, error)             { return nil, nil }
+func (nilProvider) CPU() ([]cpu.InfoStat, error)              { return nil, nil }
+func (nilProvider) Memory() (*mem.VirtualMemoryStat, error)   { return nil, nil }
+func (nilProvider) Disk(path string) (*disk.UsageStat, error) { return nil, nil }
+
+func TestCollectNoData(t *testing.T) {
+	S := collected(nilProvider{})
+	got := map[string]bool{}
+	for _, e := range S.errs {
+		if !errors.Is(e, errNoData) {
+			t.Errorf("%v, want errNoData", e)
+		}
+		got[e.Collector] = true
+	}
+	for _, c := range []string{"host", "cpu", "memory", "disk"} {
+		if !got[c] {
+			t.Errorf("no error for %s in %v", c, S.errs)
+		}
+	}
+}
+
+func TestRetryCollectsAgain(t *testing.T) {
+	S := collected(fakeProvider{fail: "gpu"})
+	S.provider = fakeProvider{}
+	updated, _ := S.Update(collectedMsg(collect(S.provider)))
+	S = updated.(SysInfoWidget)
+	if len(S.errs) != 0 {
+		t.Errorf("errs after retry = %v", S.errs)
+	}
+	if len(S.log) != 1 {
+		t.Errorf("log = %v, want the earlier failure kept", S.log)
+	}
+	if r := row(t, S.View(), "GPU"); !strings.Contains(r, "Fake GPU") {
+		t.Errorf("GPU row = %q", r)
+	}
+}
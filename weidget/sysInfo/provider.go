@@ -0,0 +1,92 @@
+package sysinfo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/disk"
+	"github.com/shirou/gopsutil/v4/host"
+	"github.com/shirou/gopsutil/v4/mem"
+)
+
+// Provider is where the widget gets its facts from. The default reads the
+// running system through gopsutil; a fake one can simulate failures.
+type Provider interface {
+	Host() (*host.InfoStat, error)
+	CPU() ([]cpu.InfoStat, error)
+	CPUCounts(logical bool) (int, error)
+	Memory() (*mem.VirtualMemoryStat, error)
+	Disk(path string) (*disk.UsageStat, error)
+	GPU() (string, error)
+	User() (string, error)
+}
+
+// System is the Provider backed by gopsutil and /proc, /sys.
+type System struct{}
+
+func (System) Host() (*host.InfoStat, error)             { return host.Info() }
+func (System) CPU() ([]cpu.InfoStat, error)              { return cpu.Info() }
+func (System) CPUCounts(logical bool) (int, error)       { return cpu.Counts(logical) }
+func (System) Memory() (*mem.VirtualMemoryStat, error)   { return mem.VirtualMemory() }
+func (System) Disk(path string) (*disk.UsageStat, error) { return disk.Usage(path) }
+func (System) GPU() (string, error)                      { return GetGPU() }
+
+// User prefers $USER and asks the user database when it isn't set
+// (cron, containers, some ssh setups).
+func (System) User() (string, error) {
+	if u := os.Getenv("USER"); u != "" {
+		return u, nil
+	}
+	u, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	return u.Username, nil
+}
+
+// errNoGPU is returned when neither the nvidia driver nor DRM name a GPU.
+var errNoGPU = errors.New("no gpu found")
+
+func GetGPU() (string, error) {
+	// Try nvidia first
+	entries, err := os.ReadDir("/proc/driver/nvidia/gpus")
+	if err == nil && len(entries) > 0 {
+		data, _ := os.ReadFile("/proc/driver/nvidia/gpus/" + entries[0].Name() + "/information")
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.HasPrefix(line, "Model:") {
+				return strings.TrimSpace(strings.SplitN(line, ":", 2)[1]), nil
+			}
+		}
+	}
+
+	// Fallback: read from /sys
+	data, err := os.ReadFile("/sys/class/drm/card0/device/uevent")
+	if err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.HasPrefix(line, "DRIVER=") {
+				return strings.SplitN(line, "=", 2)[1], nil
+			}
+		}
+	}
+
+	return "", errNoGPU
+}
+
+// CollectError is a failed collector and the fields it leaves unavailable.
+type CollectError struct {
+	Collector string   // host, cpu, memory...
+	Fields    []string // field names without a value because of it
+	Err       error
+}
+
+func (e *CollectError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Collector, e.Err)
+}
+
+func (e *CollectError) Unwrap() error {
+	return e.Err
+}
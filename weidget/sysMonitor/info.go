@@ -10,8 +10,12 @@ import (
 	"github.com/shirou/gopsutil/v4/mem"
 )
 
+// historyLen is how many CPU samples are kept for the sparkline (~30s).
+const historyLen = 60
+
 type SystemStats struct {
 	CPUPercent  float64
+	CPUHistory  []float64 // oldest first
 	RAMPercent  float64
 	RAMUsed     uint64
 	RAMTotal    uint64
@@ -43,6 +47,10 @@ func (s *SystemStats) Start(ctx context.Context) {
 			s.mu.Lock()
 			if len(cpuPct) > 0 {
 				s.CPUPercent = cpuPct[0]
+				s.CPUHistory = append(s.CPUHistory, cpuPct[0])
+				if len(s.CPUHistory) > historyLen {
+					s.CPUHistory = s.CPUHistory[len(s.CPUHistory)-historyLen:]
+				}
 			}
 			s.RAMPercent = ram.UsedPercent
 			s.RAMUsed = ram.Used
@@ -56,5 +64,14 @@ func (s *SystemStats) Start(ctx context.Context) {
 func (s *SystemStats) Read() SystemStats {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return *s // copy — caller gets a snapshot
+	// copy field by field — caller gets a snapshot without the lock
+	return SystemStats{
+		CPUPercent:  s.CPUPercent,
+		CPUHistory:  append([]float64(nil), s.CPUHistory...),
+		RAMPercent:  s.RAMPercent,
+		RAMUsed:     s.RAMUsed,
+		RAMTotal:    s.RAMTotal,
+		DiskPercent: s.DiskPercent,
+		logger:      s.logger,
+	}
 }
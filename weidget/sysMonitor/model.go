@@ -2,7 +2,6 @@ package sysmonitor
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/antiloger/termctlr/types"
 	tea "github.com/charmbracelet/bubbletea"
@@ -38,10 +37,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) View() string {
+	stats := m.info.Read()
 	return lipgloss.JoinVertical(lipgloss.Left,
-		lipgloss.JoinHorizontal(lipgloss.Left, "CPU:  ", renderBar(m.info.CPUPercent, 100.0, 20), fmt.Sprintf("  %.1f%%", m.info.CPUPercent)),
-		lipgloss.JoinHorizontal(lipgloss.Left, "RAM:  ", renderBar(m.info.RAMPercent, 100.0, 20), fmt.Sprintf("  %.1f%%", m.info.RAMPercent)),
-		lipgloss.JoinHorizontal(lipgloss.Left, "Disk: ", renderBar(m.info.DiskPercent, 100.0, 20), fmt.Sprintf("  %.1f%%", m.info.DiskPercent)),
+		statBar("CPU:  ", stats.CPUPercent),
+		cpuHistory(stats.CPUHistory),
+		statBar("RAM:  ", stats.RAMPercent),
+		statBar("Disk: ", stats.DiskPercent),
 	)
 }
 
@@ -49,3 +50,7 @@ func (m Model) SetPosition(x, y int) {
 	m.pos.X = x
 	m.pos.Y = y
 }
+
+func (m Model) Name() string {
+	return "sysmonitor"
+}
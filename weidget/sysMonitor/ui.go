@@ -1,19 +1,17 @@
 package sysmonitor
 
-const (
-	filled = "⠿"
-	empty  = " "
+import (
+	"fmt"
+
+	"github.com/antiloger/termctlr/weidget/components"
 )
 
-func renderBar(percent float64, maxPercent float64, length int) string {
-	filledCount := int(percent / maxPercent * float64(length))
-	var bar string
-	for i := range length {
-		if i < filledCount {
-			bar += filled
-			continue
-		}
-		bar += empty
-	}
-	return bar
+const barWidth = 20
+
+func statBar(label string, percent float64) string {
+	return components.Labeled(label, components.NewGauge(percent, 100), fmt.Sprintf("  %5.1f%%", percent), len(label)+barWidth+8)
+}
+
+func cpuHistory(history []float64) string {
+	return "      " + components.Sparkline(history, 100, barWidth)
 }
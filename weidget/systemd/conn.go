@@ -0,0 +1,198 @@
+package systemd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/antiloger/termctlr/dbus"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// retryEvery is how many shared ticks pass between attempts to reach a
+// manager that is down.
+const retryEvery = 10
+
+var errBusGone = errors.New("bus went away")
+
+// manager is the connection to one systemd instance.
+type manager struct {
+	scope      Scope
+	address    string
+	names      []string   // configured units, in order
+	bus        *dbus.Conn // nil while disconnected
+	connecting bool
+	err        error // why bus is nil
+}
+
+// connectedMsg is the result of a connection attempt made off the UI
+// goroutine.
+type connectedMsg struct {
+	scope Scope
+	bus   *dbus.Conn
+	units []Unit
+	err   error
+}
+
+// signalMsg carries one signal from the bus it arrived on.
+type signalMsg struct {
+	scope Scope
+	bus   *dbus.Conn
+	sig   *dbus.Message
+}
+
+// busClosedMsg is sent when the signal stream of a connection ends.
+type busClosedMsg struct {
+	scope Scope
+	bus   *dbus.Conn
+}
+
+// unitMsg is a freshly read unit.
+type unitMsg struct{ unit Unit }
+
+// unitsMsg replaces all units of a scope after signals were lost or the
+// manager reloaded.
+type unitsMsg struct {
+	scope Scope
+	units []Unit
+}
+
+// actionMsg is the result of start/stop/restart.
+type actionMsg struct {
+	unit   Unit
+	action Action
+	err    error
+}
+
+// connect dials the manager's bus, subscribes and reads the units.
+func connect(mg manager) tea.Cmd {
+	return func() tea.Msg {
+		bus, err := dbus.Dial(mg.address)
+		if err != nil {
+			return connectedMsg{scope: mg.scope, err: err}
+		}
+		for _, rule := range matchRules {
+			if err := bus.AddMatch(rule); err != nil {
+				bus.Close()
+				return connectedMsg{scope: mg.scope, err: err}
+			}
+		}
+		if _, err := bus.Call(systemdName, managerPath, managerIfc, "Subscribe"); err != nil {
+			bus.Close()
+			return connectedMsg{scope: mg.scope, err: err}
+		}
+		return connectedMsg{scope: mg.scope, bus: bus, units: loadUnits(bus, mg.scope, mg.names)}
+	}
+}
+
+func loadUnits(bus *dbus.Conn, scope Scope, names []string) []Unit {
+	units := make([]Unit, len(names))
+	for i, name := range names {
+		units[i] = loadUnit(bus, scope, name)
+	}
+	return units
+}
+
+// waitSignal delivers the next signal. Re-issue it after each one.
+func waitSignal(scope Scope, bus *dbus.Conn) tea.Cmd {
+	return func() tea.Msg {
+		sig, ok := <-bus.Signals()
+		if !ok {
+			return busClosedMsg{scope: scope, bus: bus}
+		}
+		return signalMsg{scope: scope, bus: bus, sig: sig}
+	}
+}
+
+func fetchUnit(bus *dbus.Conn, scope Scope, name string) tea.Cmd {
+	return func() tea.Msg {
+		return unitMsg{unit: loadUnit(bus, scope, name)}
+	}
+}
+
+func fetchUnits(mg manager) tea.Cmd {
+	bus := mg.bus
+	return func() tea.Msg {
+		return unitsMsg{scope: mg.scope, units: loadUnits(bus, mg.scope, mg.names)}
+	}
+}
+
+// handleConn deals with the connection lifecycle messages.
+func (m Model) handleConn(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case connectedMsg:
+		mg := m.managers[msg.scope]
+		mg.connecting = false
+		mg.bus, mg.err = msg.bus, msg.err
+		if msg.err != nil {
+			m.setUnits(msg.scope, nil)
+			return m, nil // tried again on a later tick
+		}
+		m.setUnits(msg.scope, msg.units)
+		return m, waitSignal(msg.scope, msg.bus)
+
+	case busClosedMsg:
+		mg := m.managers[msg.scope]
+		if msg.bus != mg.bus {
+			return m, nil
+		}
+		mg.err = errBusGone
+		if cerr := mg.bus.Err(); cerr != nil {
+			mg.err = fmt.Errorf("%w: %v", errBusGone, cerr)
+		}
+		mg.bus = nil
+		m.setUnits(msg.scope, nil)
+	}
+	return m, nil
+}
+
+// reconnect tries again to reach the managers that are down.
+func (m Model) reconnect() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, mg := range m.managers {
+		if mg.bus == nil && !mg.connecting {
+			mg.connecting = true
+			cmds = append(cmds, connect(*mg))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// handleSignal updates the units from one signal.
+func (m Model) handleSignal(msg signalMsg) (Model, tea.Cmd) {
+	mg := m.managers[msg.scope]
+	if msg.bus != mg.bus {
+		return m, nil // from a connection that has since been dropped
+	}
+	next := waitSignal(msg.scope, mg.bus)
+	sig := msg.sig
+	if sig == dbus.Resync {
+		return m, tea.Batch(next, fetchUnits(*mg))
+	}
+
+	switch sig.Member {
+	case "Reloading":
+		// units may have been added, removed or changed on disk; the
+		// signal is sent before (true) and after (false) the reload
+		if len(sig.Body) > 0 && sig.Body[0] == false {
+			return m, tea.Batch(next, fetchUnits(*mg))
+		}
+
+	case "PropertiesChanged":
+		i := m.byPath(msg.scope, sig.Path)
+		if i < 0 || len(sig.Body) < 3 {
+			return m, next
+		}
+		if iface, _ := sig.Body[0].(string); iface != unitIfc {
+			return m, next // the Service, Socket, … interfaces
+		}
+		changed, _ := sig.Body[1].(map[string]any)
+		invalidated, _ := sig.Body[2].([]string)
+		u := &m.units[i]
+		if len(invalidated) > 0 {
+			return m, tea.Batch(next, fetchUnit(mg.bus, u.Scope, u.Name))
+		}
+		u.apply(dbus.Unwrap(changed))
+		m.setRows()
+	}
+	return m, next
+}
@@ -0,0 +1,67 @@
+package systemd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+
+	"github.com/antiloger/termctlr/weidget/components"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const journalTailLines = 200 // asked for when the overlay opens
+
+// followJournal starts journalctl --follow for the unit. journalctl knows
+// where the journal lives and who may read it.
+func followJournal(u Unit) *components.LogView {
+	title := fmt.Sprintf("journal · %s (%s)", u.Name, u.Scope)
+	return components.FollowLog(title, "journalctl ended", func(ctx context.Context) (io.ReadCloser, error) {
+		return openJournal(ctx, u)
+	})
+}
+
+func openJournal(ctx context.Context, u Unit) (io.ReadCloser, error) {
+	args := []string{"--follow", "--no-pager", "--quiet", "--output=short", "-n", fmt.Sprint(journalTailLines)}
+	if u.Scope == User {
+		args = append(args, "--user-unit", u.Name)
+	} else {
+		args = append(args, "--unit", u.Name)
+	}
+	cmd := exec.CommandContext(ctx, "journalctl", args...)
+	cmd.Env = append(cmd.Environ(), "SYSTEMD_COLORS=0", "SYSTEMD_PAGER=")
+	r, w := io.Pipe()
+	cmd.Stdout, cmd.Stderr = w, w // journalctl explains missing permissions on stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	go func() {
+		err := cmd.Wait()
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			err = fmt.Errorf("journalctl exited with status %d", exit.ExitCode())
+		}
+		w.CloseWithError(err)
+	}()
+	return r, nil
+}
+
+// updateJournal handles keys while the journal overlay is open.
+func (m Model) updateJournal(msg tea.KeyMsg) (Model, tea.Cmd) {
+	scroll, closed := m.journal.HandleKey(msg.String(), m.scroll)
+	if closed {
+		m.journal.Close()
+		m.journal = nil
+	}
+	m.scroll = scroll
+	return m, nil
+}
+
+// Overlay shows the followed journal over the dashboard while it is open.
+func (m Model) Overlay() (string, bool) {
+	if m.journal == nil {
+		return "", false
+	}
+	return m.journal.Render(m.scroll), true
+}
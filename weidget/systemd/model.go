@@ -0,0 +1,215 @@
+package systemd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/antiloger/termctlr/config"
+	"github.com/antiloger/termctlr/dbus"
+	"github.com/antiloger/termctlr/types"
+	"github.com/antiloger/termctlr/weidget/components"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Model is the systemd widget. System and user units are watched through
+// their own manager; either one may be unreachable.
+type Model struct {
+	managers map[Scope]*manager // only scopes with configured units
+	units    []Unit             // system units, then user units, as configured
+	table    components.Table
+	width    int
+	pos      types.Position
+	now      time.Time
+	ticks    int
+
+	actionErr error
+	pending   string // "restart nginx.service…" while a job is queued
+
+	journal *components.LogView // followed journal, nil when the overlay is closed
+	scroll  int                 // journal lines scrolled up from the bottom
+}
+
+func NewModel(cfg config.SystemdConfig) Model {
+	table := components.NewTable("UNIT", "SCOPE", "STATE", "SINCE", "DESCRIPTION")
+	table.Selected = 0
+	m := Model{
+		managers: map[Scope]*manager{},
+		table:    table,
+		width:    60,
+		now:      time.Now(),
+	}
+	if len(cfg.System) > 0 {
+		m.managers[System] = &manager{scope: System, address: dbus.SystemAddress(), names: cfg.System}
+	}
+	if len(cfg.User) > 0 {
+		m.managers[User] = &manager{scope: User, address: dbus.SessionAddress(), names: cfg.User}
+	}
+	m.setUnits(System, nil)
+	m.setUnits(User, nil)
+	return m
+}
+
+func (m Model) Init() tea.Cmd {
+	return m.reconnect()
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case types.SizeMsg:
+		m.width = msg.Width
+		m.table.Height = max(msg.Height-3, 1) // header, status and help lines
+		return m, nil
+	case types.TickMsg:
+		m.now = time.Time(msg)
+		m.setRows() // relative times
+		m.ticks++
+		if m.ticks%retryEvery != 0 {
+			return m, nil
+		}
+		return m, m.reconnect()
+	case connectedMsg, busClosedMsg:
+		return m.handleConn(msg)
+	case signalMsg:
+		return m.handleSignal(msg)
+	case unitMsg:
+		if mg := m.managers[msg.unit.Scope]; mg != nil && mg.bus != nil {
+			m.setUnit(msg.unit)
+		}
+		return m, nil
+	case unitsMsg:
+		if mg := m.managers[msg.scope]; mg != nil && mg.bus != nil {
+			m.setUnits(msg.scope, msg.units)
+		}
+		return m, nil
+	case actionMsg:
+		m.pending = ""
+		m.actionErr = msg.err
+		if msg.err != nil && !errors.Is(msg.err, ErrPermission) {
+			// permission errors already say what was refused
+			m.actionErr = fmt.Errorf("%s %s: %w", msg.action, msg.unit.Name, msg.err)
+		}
+		return m, nil
+	case components.LogMsg:
+		if msg.View != m.journal {
+			return m, nil // closed since
+		}
+		return m, components.WaitLog(m.journal)
+	case tea.KeyMsg:
+		if m.journal != nil {
+			return m.updateJournal(msg)
+		}
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m Model) handleKey(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		m.table.Up()
+		return m, nil
+	case "down", "j":
+		m.table.Down()
+		return m, nil
+	}
+
+	u, ok := m.selected()
+	if !ok {
+		return m, nil
+	}
+	switch msg.String() {
+	case "s":
+		return m.do(u, Start)
+	case "x":
+		return m.do(u, Stop)
+	case "r":
+		return m.do(u, Restart)
+	case "l":
+		m.journal, m.scroll = followJournal(u), 0
+		return m, components.WaitLog(m.journal)
+	}
+	return m, nil
+}
+
+// do queues a job off the UI goroutine. The new state arrives as signals.
+func (m Model) do(u Unit, a Action) (Model, tea.Cmd) {
+	mg := m.managers[u.Scope]
+	if m.pending != "" || mg == nil || mg.bus == nil {
+		return m, nil // one at a time, and only when connected
+	}
+	m.pending = fmt.Sprintf("%s %s…", a, u.Name)
+	bus := mg.bus
+	return m, func() tea.Msg {
+		return actionMsg{unit: u, action: a, err: do(bus, u, a)}
+	}
+}
+
+// setUnits replaces the units of a scope. Without units (the manager is
+// unreachable) the configured names stay listed with an unknown state.
+func (m *Model) setUnits(scope Scope, units []Unit) {
+	mg := m.managers[scope]
+	if mg == nil {
+		return
+	}
+	if units == nil {
+		units = make([]Unit, len(mg.names))
+		for i, name := range mg.names {
+			units[i] = Unit{Scope: scope, Name: name}
+		}
+	}
+	prev, _ := m.selected()
+	var all []Unit
+	for _, s := range []Scope{System, User} {
+		if s == scope {
+			all = append(all, units...)
+			continue
+		}
+		for _, u := range m.units {
+			if u.Scope == s {
+				all = append(all, u)
+			}
+		}
+	}
+	m.units = all
+	for i, u := range m.units {
+		if u.Scope == prev.Scope && u.Name == prev.Name {
+			m.table.Selected = i // follow the unit, not the row
+		}
+	}
+	m.setRows()
+}
+
+func (m *Model) setUnit(u Unit) {
+	for i := range m.units {
+		if m.units[i].Scope == u.Scope && m.units[i].Name == u.Name {
+			m.units[i] = u
+		}
+	}
+	m.setRows()
+}
+
+func (m Model) byPath(scope Scope, path dbus.ObjectPath) int {
+	for i, u := range m.units {
+		if u.Scope == scope && u.Path == path {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m Model) selected() (Unit, bool) {
+	if m.table.Selected < 0 || m.table.Selected >= len(m.units) {
+		return Unit{}, false
+	}
+	return m.units[m.table.Selected], true
+}
+
+func (m Model) SetPosition(x, y int) {
+	m.pos.X = x
+	m.pos.Y = y
+}
+
+func (m Model) Name() string {
+	return "systemd"
+}
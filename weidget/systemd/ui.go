@@ -0,0 +1,99 @@
+package systemd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antiloger/termctlr/theme"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// setRows rebuilds the table from the units.
+func (m *Model) setRows() {
+	t := theme.Current()
+	rows := make([][]string, len(m.units))
+	for i, u := range m.units {
+		since := "-"
+		if !u.Since.IsZero() {
+			since = formatSince(m.now.Sub(u.Since))
+		}
+		desc := u.Description
+		if u.Err != nil {
+			desc = u.Err.Error()
+		}
+		rows[i] = []string{u.Name, string(u.Scope), stateLabel(t, u), since, desc}
+	}
+	m.table.SetRows(rows)
+}
+
+func stateLabel(t *theme.Theme, u Unit) string {
+	switch {
+	case u.Err != nil:
+		return t.Style(theme.Alert).Render("● error")
+	case u.ActiveState == "":
+		return t.Style(theme.Muted).Render("? unknown")
+	case u.LoadState == "not-found" || u.LoadState == "masked":
+		return t.Style(theme.Alert).Render("● " + u.LoadState)
+	}
+	label := fmt.Sprintf("%s (%s)", u.ActiveState, u.SubState)
+	switch u.ActiveState {
+	case "active", "reloading":
+		return t.Style(theme.Accent).Render("● " + label)
+	case "failed":
+		return t.Style(theme.Alert).Bold(true).Render("✕ " + label)
+	case "activating", "deactivating":
+		return t.Style(theme.GaugeMed).Render("◌ " + label)
+	}
+	return t.Style(theme.Muted).Render("○ " + label)
+}
+
+// formatSince is a short relative time: 45s ago, 12m ago, 3h ago, 5d ago.
+func formatSince(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", max(int(d.Seconds()), 0))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+}
+
+func (m Model) View() string {
+	t := theme.Current()
+	if len(m.managers) == 0 {
+		return strings.Join([]string{
+			t.Style(theme.Muted).Render("systemd: no units configured"),
+			t.Style(theme.Muted).Render(`list them under systemd.system and systemd.user, e.g. "system": ["sshd.service"]`),
+		}, "\n")
+	}
+
+	lines := []string{m.table.Render(m.width)}
+	failed := 0
+	for _, u := range m.units {
+		if u.Failed() {
+			failed++
+		}
+	}
+	for _, s := range []Scope{System, User} {
+		if mg := m.managers[s]; mg != nil && mg.bus == nil {
+			state := "connecting…"
+			if mg.err != nil {
+				state = "unreachable: " + mg.err.Error()
+			}
+			lines = append(lines, t.Style(theme.Alert).Render(fmt.Sprintf("%s manager %s", s, state)))
+		}
+	}
+	switch {
+	case m.actionErr != nil:
+		lines = append(lines, t.Style(theme.Alert).Render(m.actionErr.Error()))
+	case m.pending != "":
+		lines = append(lines, t.Style(theme.Muted).Render(m.pending))
+	case failed > 0:
+		lines = append(lines, t.Style(theme.Alert).Bold(true).Render(fmt.Sprintf("%d failed", failed)))
+	}
+	lines = append(lines, t.Style(theme.Muted).Render("↑ ↓ select · s start · x stop · r restart · l journal"))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
@@ -0,0 +1,130 @@
+// Package systemd watches configured systemd units, user and system, over
+// D-Bus and starts, stops and restarts them.
+package systemd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antiloger/termctlr/dbus"
+)
+
+// systemd D-Bus names, from org.freedesktop.systemd1(5).
+const (
+	systemdName = "org.freedesktop.systemd1"
+	managerPath = dbus.ObjectPath("/org/freedesktop/systemd1")
+	managerIfc  = "org.freedesktop.systemd1.Manager"
+	unitIfc     = "org.freedesktop.systemd1.Unit"
+)
+
+// matchRules select unit state changes and daemon reloads; the manager
+// only sends them once a client called Subscribe.
+var matchRules = []string{
+	"type='signal',sender='" + systemdName + "',interface='" + dbus.PropertiesIfc +
+		"',member='PropertiesChanged',path_namespace='/org/freedesktop/systemd1/unit'",
+	"type='signal',sender='" + systemdName + "',interface='" + managerIfc + "',member='Reloading'",
+}
+
+// Scope says which manager a unit belongs to.
+type Scope string
+
+const (
+	System Scope = "system"
+	User   Scope = "user"
+)
+
+// Unit is the state of one watched unit.
+type Unit struct {
+	Scope       Scope
+	Name        string
+	Path        dbus.ObjectPath
+	Description string
+	LoadState   string // loaded, not-found, masked, …
+	ActiveState string // active, inactive, failed, activating, …
+	SubState    string // running, exited, dead, …
+	Since       time.Time
+	Err         error // the unit could not be read
+}
+
+// Failed reports whether the unit needs attention.
+func (u Unit) Failed() bool {
+	return u.ActiveState == "failed" || u.LoadState == "not-found" || u.Err != nil
+}
+
+// apply updates the unit from org.freedesktop.systemd1.Unit properties.
+func (u *Unit) apply(props map[string]any) {
+	for k, v := range props {
+		switch k {
+		case "Description":
+			u.Description, _ = v.(string)
+		case "LoadState":
+			u.LoadState, _ = v.(string)
+		case "ActiveState":
+			u.ActiveState, _ = v.(string)
+		case "SubState":
+			u.SubState, _ = v.(string)
+		case "StateChangeTimestamp":
+			if us, _ := v.(uint64); us > 0 {
+				u.Since = time.UnixMicro(int64(us))
+			} else {
+				u.Since = time.Time{}
+			}
+		}
+	}
+}
+
+// loadUnit reads a unit, loading it first if systemd has not (inactive
+// units are unloaded until asked for).
+func loadUnit(bus *dbus.Conn, scope Scope, name string) Unit {
+	u := Unit{Scope: scope, Name: name}
+	body, err := bus.Call(systemdName, managerPath, managerIfc, "LoadUnit", name)
+	if err != nil {
+		u.Err = err
+		return u
+	}
+	if u.Path, err = dbus.First[dbus.ObjectPath]("LoadUnit", body); err != nil {
+		u.Err = err
+		return u
+	}
+	props, err := bus.GetAll(systemdName, u.Path, unitIfc)
+	if err != nil {
+		u.Err = err
+		return u
+	}
+	u.apply(props)
+	return u
+}
+
+// Action is a unit job.
+type Action string
+
+const (
+	Start   Action = "Start"
+	Stop    Action = "Stop"
+	Restart Action = "Restart"
+)
+
+// ErrPermission is wrapped by action errors the bus or polkit refused.
+var ErrPermission = errors.New("permission denied")
+
+// do queues a job for the unit. systemd replies once the job is queued;
+// the outcome arrives as property changes.
+func do(bus *dbus.Conn, u Unit, a Action) error {
+	_, err := bus.Call(systemdName, managerPath, managerIfc, string(a)+"Unit", u.Name, "replace")
+	var derr *dbus.Error
+	if errors.As(err, &derr) {
+		switch derr.Name {
+		case "org.freedesktop.DBus.Error.AccessDenied",
+			"org.freedesktop.DBus.Error.InteractiveAuthorizationRequired",
+			"org.freedesktop.PolicyKit1.Error.NotAuthorized":
+			hint := "needs root or a polkit rule"
+			if u.Scope == User {
+				hint = "refused by the user manager"
+			}
+			return fmt.Errorf("%w: %s %s (%s)", ErrPermission, strings.ToLower(string(a)), u.Name, hint)
+		}
+	}
+	return err
+}
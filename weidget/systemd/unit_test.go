@@ -0,0 +1,164 @@
+package systemd
+
+import (
+	"bufio"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antiloger/termctlr/dbus"
+)
+
+// privateBus starts a dbus-daemon of its own and returns its address.
+func privateBus(t *testing.T) string {
+	t.Helper()
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("no dbus-daemon to run a private bus")
+	}
+	cmd := exec.Command(daemon, "--session", "--nofork", "--print-address")
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	address, err := bufio.NewReader(out).ReadString('\n')
+	if err != nil {
+		t.Fatalf("dbus-daemon: %v", err)
+	}
+	return strings.TrimSpace(address)
+}
+
+// fakeManager owns the systemd name on a private bus and returns a
+// client connection to it. LoadUnit answers "empty.service" with nothing,
+// other units with a path; jobs on "locked.service" are refused with the
+// error named by the unit's action.
+func fakeManager(t *testing.T) *dbus.Conn {
+	t.Helper()
+	address := privateBus(t)
+	mgr, err := dbus.Dial(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { mgr.Close() })
+	refusals := map[string]string{
+		"StartUnit":   "org.freedesktop.DBus.Error.AccessDenied",
+		"RestartUnit": "org.freedesktop.DBus.Error.InteractiveAuthorizationRequired",
+		"StopUnit":    "org.freedesktop.PolicyKit1.Error.NotAuthorized",
+	}
+	mgr.Handle(func(m *dbus.Message) ([]any, error) {
+		name, _ := m.Body[0].(string)
+		switch {
+		case m.Member == "LoadUnit" && name == "empty.service":
+			return nil, nil
+		case m.Member == "LoadUnit":
+			return []any{dbus.ObjectPath("/org/freedesktop/systemd1/unit/web_2eservice")}, nil
+		case m.Member == "GetAll":
+			return []any{map[string]dbus.Variant{
+				"Description": dbus.MakeVariant("Web server"),
+				"LoadState":   dbus.MakeVariant("loaded"),
+				"ActiveState": dbus.MakeVariant("active"),
+				"SubState":    dbus.MakeVariant("running"),
+			}}, nil
+		case name == "locked.service":
+			return nil, &dbus.Error{Name: refusals[m.Member], Body: []any{"refused"}}
+		case name == "broken.service":
+			return nil, &dbus.Error{Name: "org.freedesktop.systemd1.NoSuchUnit", Body: []any{"Unit broken.service not found."}}
+		}
+		return []any{dbus.ObjectPath("/org/freedesktop/systemd1/job/1")}, nil
+	})
+	if err := mgr.RequestName(systemdName); err != nil {
+		t.Fatal(err)
+	}
+	bus, err := dbus.Dial(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { bus.Close() })
+	return bus
+}
+
+func TestLoadUnit(t *testing.T) {
+	bus := fakeManager(t)
+	u := loadUnit(bus, System, "web.service")
+	if u.Err != nil || u.Path != "/org/freedesktop/systemd1/unit/web_2eservice" {
+		t.Fatalf("unit = %+v", u)
+	}
+	if u.Description != "Web server" || u.ActiveState != "active" || u.SubState != "running" || u.Failed() {
+		t.Errorf("unit = %+v", u)
+	}
+
+	u = loadUnit(bus, System, "empty.service")
+	if u.Err == nil || u.Err.Error() != "dbus: LoadUnit: empty reply" || !u.Failed() {
+		t.Errorf("empty LoadUnit reply gave %+v", u)
+	}
+}
+
+func TestDoPermission(t *testing.T) {
+	bus := fakeManager(t)
+	for _, c := range []struct {
+		unit   Unit
+		action Action
+		want   string // the error, or "" for none
+	}{
+		{Unit{Scope: System, Name: "web.service"}, Restart, ""},
+		{Unit{Scope: System, Name: "locked.service"}, Start, "permission denied: start locked.service (needs root or a polkit rule)"},
+		{Unit{Scope: System, Name: "locked.service"}, Restart, "permission denied: restart locked.service (needs root or a polkit rule)"},
+		{Unit{Scope: User, Name: "locked.service"}, Stop, "permission denied: stop locked.service (refused by the user manager)"},
+	} {
+		err := do(bus, c.unit, c.action)
+		if c.want == "" {
+			if err != nil {
+				t.Errorf("%s %s: %v", c.action, c.unit.Name, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrPermission) || err.Error() != c.want {
+			t.Errorf("%s %s: err = %v, want %q", c.action, c.unit.Name, err, c.want)
+		}
+	}
+
+	// other errors are passed on as they are
+	err := do(bus, Unit{Scope: System, Name: "broken.service"}, Start)
+	var derr *dbus.Error
+	if errors.Is(err, ErrPermission) || !errors.As(err, &derr) || derr.Name != "org.freedesktop.systemd1.NoSuchUnit" {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestUnitApply(t *testing.T) {
+	u := Unit{Name: "web.service", SubState: "dead", Since: time.Unix(1, 0)}
+	u.apply(map[string]any{
+		"Description":          "Web server",
+		"ActiveState":          "failed",
+		"SubState":             "failed",
+		"StateChangeTimestamp": uint64(1718370000123456),
+		"MainPID":              uint32(42), // not kept
+	})
+	want := Unit{Name: "web.service", Description: "Web server", ActiveState: "failed", SubState: "failed",
+		Since: time.UnixMicro(1718370000123456)}
+	if u.Description != want.Description || u.ActiveState != want.ActiveState || u.SubState != want.SubState || !u.Since.Equal(want.Since) {
+		t.Errorf("unit = %+v", u)
+	}
+	if !u.Failed() {
+		t.Error("a failed unit is not Failed")
+	}
+
+	// a zero timestamp means never, and a mistyped value clears the field
+	u.apply(map[string]any{"StateChangeTimestamp": uint64(0), "LoadState": int32(1)})
+	if !u.Since.IsZero() || u.LoadState != "" {
+		t.Errorf("unit = %+v", u)
+	}
+	u.apply(map[string]any{"LoadState": "not-found", "ActiveState": "inactive"})
+	if !u.Failed() {
+		t.Error("a missing unit is not Failed")
+	}
+}
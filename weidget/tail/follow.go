@@ -0,0 +1,309 @@
+// Package tail follows log files, like tail -F, with highlighting, a live
+// filter and JSON lines shown as selected fields.
+package tail
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// pollEvery is how often the files are checked. Polling rather than
+	// inotify keeps rotation simple and works on network filesystems, as
+	// tail -F does.
+	pollEvery = 250 * time.Millisecond
+
+	chunkSize = 64 * 1024
+	// maxLineBytes cuts very long lines; the rest up to the newline is
+	// skipped.
+	maxLineBytes = 16 * 1024
+	// skipAhead is the most unread data read through after a pause, for
+	// a file that grew faster than it was followed; beyond it the
+	// follower jumps to the last lines, as at start.
+	skipAhead = 8 * 1024 * 1024
+	// maxScanBack bounds the search backwards for the last lines, for
+	// files with no newlines to find.
+	maxScanBack = 8 * 1024 * 1024
+)
+
+// entry is one line and the file it came from.
+type entry struct {
+	file int
+	text string
+}
+
+// follower reads one file by name, reopening it when it is rotated and
+// starting over when it is truncated.
+type follower struct {
+	path    string
+	f       *os.File
+	off     int64
+	partial []byte
+	skip    bool // dropping the rest of an overlong line
+	opened  bool // has been open once; a reopened file is read from the start
+	err     error
+}
+
+// tailer follows a set of files into one bounded buffer. Only the last
+// maxLines lines are kept, and files are read in chunks from where they
+// were left, so their size does not matter.
+type tailer struct {
+	labels   []string
+	backlog  int
+	maxLines int
+	updates  chan struct{} // signalled (never blocking) on new lines
+	stop     chan struct{}
+	stopped  sync.Once
+
+	mu      sync.Mutex
+	lines   []entry
+	dropped int     // lines dropped off the top of the buffer
+	errs    []error // per file, nil while it is followed
+}
+
+func startTailer(paths []string, backlog, maxLines int) *tailer {
+	t := &tailer{
+		labels:   labels(paths),
+		backlog:  backlog,
+		maxLines: maxLines,
+		updates:  make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		errs:     make([]error, len(paths)),
+	}
+	followers := make([]*follower, len(paths))
+	for i, p := range paths {
+		followers[i] = &follower{path: p}
+	}
+	go t.run(followers)
+	return t
+}
+
+// labels are the file names, with the parent directory for names that
+// are not unique (two access.log of different sites).
+func labels(paths []string) []string {
+	seen := map[string]int{}
+	for _, p := range paths {
+		seen[filepath.Base(p)]++
+	}
+	out := make([]string, len(paths))
+	for i, p := range paths {
+		out[i] = filepath.Base(p)
+		if seen[out[i]] > 1 {
+			out[i] = filepath.Join(filepath.Base(filepath.Dir(p)), out[i])
+		}
+	}
+	return out
+}
+
+func (t *tailer) run(followers []*follower) {
+	tick := time.NewTicker(pollEvery)
+	defer tick.Stop()
+	for {
+		for i, fl := range followers {
+			fl.poll(t.backlog, func(lines []string) { t.add(i, lines) })
+			t.setErr(i, fl.err)
+		}
+		select {
+		case <-tick.C:
+		case <-t.stop:
+			for _, fl := range followers {
+				if fl.f != nil {
+					fl.f.Close()
+				}
+			}
+			return
+		}
+	}
+}
+
+func (t *tailer) add(file int, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	t.mu.Lock()
+	for _, l := range lines {
+		t.lines = append(t.lines, entry{file: file, text: l})
+	}
+	if over := len(t.lines) - t.maxLines; over > 0 {
+		t.lines = t.lines[over:]
+		t.dropped += over
+	}
+	t.mu.Unlock()
+	t.notify()
+}
+
+func (t *tailer) setErr(file int, err error) {
+	t.mu.Lock()
+	changed := (t.errs[file] == nil) != (err == nil) || (err != nil && t.errs[file].Error() != err.Error())
+	t.errs[file] = err
+	t.mu.Unlock()
+	if changed {
+		t.notify()
+	}
+}
+
+func (t *tailer) notify() {
+	select {
+	case t.updates <- struct{}{}:
+	default:
+	}
+}
+
+// close stops following; the files are closed by the follower goroutine.
+func (t *tailer) close() {
+	t.stopped.Do(func() { close(t.stop) })
+}
+
+// snapshot returns the buffer as it is now. Entries are never changed
+// once added, so the slice can be read without the lock.
+func (t *tailer) snapshot() (lines []entry, dropped int, errs []error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.lines, t.dropped, append([]error(nil), t.errs...)
+}
+
+// poll reads what was added to the file since the last poll and follows
+// it through rotation and truncation.
+func (fl *follower) poll(backlog int, emit func([]string)) {
+	if fl.f == nil {
+		// only a file there from the start has a past to skip; one that
+		// appears later is new and read whole
+		first := !fl.opened
+		fl.opened = true
+		f, err := os.Open(fl.path)
+		if err != nil {
+			fl.err = err
+			return
+		}
+		fl.f, fl.err, fl.off, fl.partial, fl.skip = f, nil, 0, nil, false
+		if first {
+			fl.seekBack(backlog)
+		}
+	}
+
+	st, err := fl.f.Stat()
+	if err != nil {
+		fl.err = err
+		return
+	}
+	switch {
+	case st.Size() < fl.off:
+		// truncated in place (copytruncate, or > file): start over
+		fl.off, fl.partial, fl.skip = 0, nil, false
+		emit([]string{"··· truncated ···"})
+	case st.Size()-fl.off > skipAhead:
+		from := fl.off
+		fl.seekBack(backlog)
+		emit([]string{fmt.Sprintf("··· skipped %d MB ···", (fl.off-from)>>20)})
+	}
+	fl.drain(emit)
+
+	// a new file under the name: the old one was rotated away, and has
+	// just been read to its end
+	cur, err := os.Stat(fl.path)
+	if err == nil && !os.SameFile(cur, st) {
+		fl.flush(emit)
+		fl.f.Close()
+		fl.f = nil
+		fl.poll(backlog, emit)
+	}
+}
+
+// seekBack moves to the start of the last n lines.
+func (fl *follower) seekBack(n int) {
+	st, err := fl.f.Stat()
+	if err != nil {
+		return
+	}
+	end := st.Size()
+	off, found := end, 0
+	buf := make([]byte, chunkSize)
+	for off > 0 && end-off < maxScanBack {
+		size := min(int64(chunkSize), off)
+		off -= size
+		if _, err := fl.f.ReadAt(buf[:size], off); err != nil && err != io.EOF {
+			break
+		}
+		for i := size - 1; i >= 0; i-- {
+			// the newline ending the last line does not start one
+			if buf[i] == '\n' && off+i != end-1 {
+				found++
+				if found == n {
+					fl.off, fl.partial, fl.skip = off+i+1, nil, false
+					return
+				}
+			}
+		}
+	}
+	fl.off, fl.partial, fl.skip = off, nil, off > 0 // mid-line when the scan gave up
+}
+
+// drain reads to the end of the file, emitting complete lines.
+func (fl *follower) drain(emit func([]string)) {
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := fl.f.ReadAt(buf, fl.off)
+		fl.off += int64(n)
+		var lines []string
+		data := buf[:n]
+		for len(data) > 0 {
+			i := bytes.IndexByte(data, '\n')
+			if i < 0 {
+				fl.keep(data, &lines)
+				break
+			}
+			fl.keep(data[:i], &lines)
+			if !fl.skip {
+				lines = append(lines, string(bytes.TrimSuffix(fl.partial, []byte("\r"))))
+			}
+			fl.partial, fl.skip = fl.partial[:0], false
+			data = data[i+1:]
+		}
+		emit(lines)
+		if err != nil || n < len(buf) {
+			if err != nil && err != io.EOF {
+				fl.err = err
+			}
+			return
+		}
+	}
+}
+
+// keep adds part of a line to the partial one, cutting it at
+// maxLineBytes.
+func (fl *follower) keep(p []byte, lines *[]string) {
+	if fl.skip {
+		return
+	}
+	fl.partial = append(fl.partial, p...)
+	if len(fl.partial) > maxLineBytes {
+		*lines = append(*lines, string(fl.partial[:maxLineBytes])+"…")
+		fl.partial, fl.skip = fl.partial[:0], true
+	}
+}
+
+// flush emits a last line that had no newline, when the file is left.
+func (fl *follower) flush(emit func([]string)) {
+	if len(fl.partial) > 0 && !fl.skip {
+		emit([]string{string(fl.partial)})
+	}
+	fl.partial, fl.skip = nil, false
+}
+
+// outputMsg says the tailer has new lines or a file changed state.
+type outputMsg struct{ tailer *tailer }
+
+// waitOutput wakes the UI on new lines; re-issue it after each one.
+func waitOutput(t *tailer) tea.Cmd {
+	return func() tea.Msg {
+		<-t.updates
+		return outputMsg{tailer: t}
+	}
+}
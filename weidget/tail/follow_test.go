@@ -0,0 +1,157 @@
+package tail
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// polled polls fl once and returns the lines it emitted.
+func polled(t *testing.T, fl *follower, backlog int) []string {
+	t.Helper()
+	var out []string
+	fl.poll(backlog, func(lines []string) { out = append(out, lines...) })
+	return out
+}
+
+// newFollower follows path, closing the file at the end of the test.
+func newFollower(t *testing.T, path string) *follower {
+	fl := &follower{path: path}
+	t.Cleanup(func() {
+		if fl.f != nil {
+			fl.f.Close()
+		}
+	})
+	return fl
+}
+
+func appendFile(t *testing.T, path, data string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFollowerBacklogAndGrowth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "1\n2\n3\n4\n")
+	fl := newFollower(t, path)
+
+	if got := polled(t, fl, 2); !slices.Equal(got, []string{"3", "4"}) {
+		t.Errorf("backlog %q, want the last 2 lines", got)
+	}
+	if got := polled(t, fl, 2); len(got) != 0 {
+		t.Errorf("nothing new, got %q", got)
+	}
+	// a line is emitted once its newline is there; CRLF is trimmed
+	appendFile(t, path, "5\r\n6")
+	if got := polled(t, fl, 2); !slices.Equal(got, []string{"5"}) {
+		t.Errorf("got %q, want the complete line", got)
+	}
+	appendFile(t, path, "7\n")
+	if got := polled(t, fl, 2); !slices.Equal(got, []string{"67"}) {
+		t.Errorf("got %q, want the finished line", got)
+	}
+}
+
+func TestFollowerRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "a\n")
+	fl := newFollower(t, path)
+	polled(t, fl, 10)
+
+	// written to before and after the move, then replaced
+	appendFile(t, path, "b\n")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path+".1", "unterminated")
+	appendFile(t, path, "c\nd\n")
+
+	// the old file is read to its end, the new one from its start
+	want := []string{"b", "unterminated", "c", "d"}
+	if got := polled(t, fl, 1); !slices.Equal(got, want) {
+		t.Errorf("across rotation %q, want %q", got, want)
+	}
+	appendFile(t, path, "e\n")
+	if got := polled(t, fl, 1); !slices.Equal(got, []string{"e"}) {
+		t.Errorf("after rotation %q", got)
+	}
+}
+
+func TestFollowerCopytruncate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "old 1\nold 2\n")
+	fl := newFollower(t, path)
+	polled(t, fl, 10)
+
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path, "new\n")
+	want := []string{"··· truncated ···", "new"}
+	if got := polled(t, fl, 10); !slices.Equal(got, want) {
+		t.Errorf("after truncation %q, want %q", got, want)
+	}
+}
+
+func TestFollowerMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	fl := newFollower(t, path)
+	if got := polled(t, fl, 1); len(got) != 0 || !errors.Is(fl.err, fs.ErrNotExist) {
+		t.Fatalf("missing file: lines %q, err %v", got, fl.err)
+	}
+	// a file that appears later is new: all of it is read
+	appendFile(t, path, "x\ny\n")
+	if got := polled(t, fl, 1); !slices.Equal(got, []string{"x", "y"}) || fl.err != nil {
+		t.Errorf("created file: lines %q, err %v", got, fl.err)
+	}
+}
+
+func TestFollowerSkipsAhead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "first\n")
+	fl := newFollower(t, path)
+	polled(t, fl, 1)
+
+	filler := strings.Repeat(strings.Repeat("x", 1023)+"\n", skipAhead/1024+1024)
+	appendFile(t, path, filler+"second\nlast\n")
+	got := polled(t, fl, 2)
+	if len(got) != 3 || !strings.HasPrefix(got[0], "··· skipped 9 MB") || got[1] != "second" || got[2] != "last" {
+		t.Errorf("after a burst of %d MB: %.80q", len(filler)>>20, got)
+	}
+}
+
+func TestFollowerCutsLongLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "")
+	fl := newFollower(t, path)
+	polled(t, fl, 10)
+
+	// written in pieces, the line is cut once it is too long and the
+	// rest of it up to the newline dropped
+	long := strings.Repeat("y", maxLineBytes)
+	appendFile(t, path, long[:100])
+	if got := polled(t, fl, 10); len(got) != 0 {
+		t.Errorf("half a line emitted: %.20q", got)
+	}
+	appendFile(t, path, long[100:]+"zzz")
+	got := polled(t, fl, 10)
+	if len(got) != 1 || got[0] != long+"…" {
+		t.Fatalf("long line %d lines, want it cut at %d bytes", len(got), maxLineBytes)
+	}
+	appendFile(t, path, strings.Repeat("z", chunkSize+10)+"\nshort\n")
+	if got := polled(t, fl, 10); !slices.Equal(got, []string{"short"}) {
+		t.Errorf("after the cut %.40q, want the next line only", got)
+	}
+}
@@ -0,0 +1,174 @@
+package tail
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/antiloger/termctlr/config"
+	"github.com/antiloger/termctlr/theme"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// rule colours the matches of a pattern, or the whole line.
+type rule struct {
+	re   *regexp.Regexp
+	role theme.Role
+	line bool
+}
+
+// defaultRules apply when none are configured: errors and warnings, by
+// the words logs commonly use for them.
+var defaultRules = []config.HighlightConfig{
+	{Pattern: `(?i)\b(error|err|fatal|panic|crit(ical)?|emerg(ency)?|alert)\b`, Role: string(theme.Alert)},
+	{Pattern: `(?i)\b(warn(ing)?)\b`, Role: string(theme.GaugeMed)},
+}
+
+func compileRules(cfgs []config.HighlightConfig) ([]rule, []error) {
+	if len(cfgs) == 0 {
+		cfgs = defaultRules
+	}
+	var rules []rule
+	var errs []error
+	for _, c := range cfgs {
+		re, err := regexp.Compile(c.Pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("highlight %q: %w", c.Pattern, err))
+			continue
+		}
+		role := theme.Role(c.Role)
+		if role == "" {
+			role = theme.Alert
+		}
+		rules = append(rules, rule{re: re, role: role, line: c.Line})
+	}
+	return rules, errs
+}
+
+// sanitize makes a log line safe to draw: escape sequences and control
+// characters are dropped and tabs expanded.
+func sanitize(s string) string {
+	s = ansi.Strip(s)
+	if !strings.ContainsFunc(s, func(r rune) bool { return r < 0x20 || r == 0x7f }) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\t':
+			b.WriteString("    ")
+		case r < 0x20 || r == 0x7f:
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// formatJSON shows a JSON object line as the values of fields, in order,
+// each as key=value but for the usual time, level and message fields,
+// which read fine bare. Fields may be dotted paths into nested objects.
+// Lines that are not objects, or have none of the fields, stay as they
+// are.
+func formatJSON(line string, fields []string) string {
+	trimmed := strings.TrimSpace(line)
+	if len(fields) == 0 || !strings.HasPrefix(trimmed, "{") {
+		return line
+	}
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(trimmed), &obj); err != nil {
+		return line
+	}
+	var parts []string
+	for _, f := range fields {
+		v, ok := lookup(obj, f)
+		if !ok {
+			continue
+		}
+		s := jsonString(v)
+		switch strings.ToLower(f) {
+		case "time", "ts", "timestamp", "@timestamp", "level", "lvl", "severity", "msg", "message":
+			parts = append(parts, s)
+		default:
+			parts = append(parts, f+"="+s)
+		}
+	}
+	if len(parts) == 0 {
+		return line
+	}
+	return strings.Join(parts, " ")
+}
+
+// lookup finds a dotted path, preferring a key with the dots in it as is
+// (some loggers flatten nested keys that way).
+func lookup(obj map[string]any, path string) (any, bool) {
+	if v, ok := obj[path]; ok {
+		return v, true
+	}
+	head, rest, ok := strings.Cut(path, ".")
+	if !ok {
+		return nil, false
+	}
+	inner, ok := obj[head].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	return lookup(inner, rest)
+}
+
+func jsonString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case nil:
+		return "null"
+	case float64, bool:
+		return fmt.Sprint(v)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// highlight styles the text by the rules. The first rule to cover a byte
+// wins; a line rule colours what no other rule matched.
+func highlight(t *theme.Theme, s string, rules []rule, base theme.Role) string {
+	roles := make([]theme.Role, len(s))
+	lineRole := base
+	lineSet := false
+	for _, r := range rules {
+		locs := r.re.FindAllStringIndex(s, -1)
+		if len(locs) == 0 {
+			continue
+		}
+		if r.line {
+			if !lineSet {
+				lineRole, lineSet = r.role, true
+			}
+			continue
+		}
+		for _, loc := range locs {
+			for i := loc[0]; i < loc[1]; i++ {
+				if roles[i] == "" {
+					roles[i] = r.role
+				}
+			}
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		role := roles[i]
+		j := i + 1
+		for j < len(s) && roles[j] == role {
+			j++
+		}
+		if role == "" {
+			b.WriteString(t.Style(lineRole).Render(s[i:j]))
+		} else {
+			b.WriteString(t.Style(role).Bold(true).Render(s[i:j]))
+		}
+		i = j
+	}
+	return b.String()
+}
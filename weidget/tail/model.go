@@ -0,0 +1,259 @@
+package tail
+
+import (
+	"path/filepath"
+	"regexp"
+
+	"github.com/antiloger/termctlr/config"
+	"github.com/antiloger/termctlr/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Model is the tail widget. Files are read off the UI goroutine; Update
+// only moves the view over the lines kept.
+type Model struct {
+	name       string
+	files      []string
+	t          *tailer // nil without files
+	rules      []rule
+	ruleErrs   []error
+	jsonFields []string
+	raw        bool // JSON lines as they are, not as fields
+
+	filter        *regexp.Regexp // nil shows every line
+	filterText    string
+	filterLiteral bool // the filter is not a valid regexp and matches as text
+
+	prompting bool   // the filter prompt is open and has the keys
+	input     string // what is typed at the prompt
+	before    string // the filter when the prompt was opened, for esc
+
+	paused bool
+	anchor int // while paused, the absolute number of the line after the last shown
+	offset int // matching lines scrolled back from the anchor
+
+	width, height int
+	pos           types.Position
+}
+
+func NewModel(cfg config.TailConfig) Model {
+	name := cfg.Name
+	if name == "" {
+		name = "tail"
+	}
+	backlog := cfg.Backlog
+	if backlog <= 0 {
+		backlog = 100
+	}
+	maxLines := cfg.MaxLines
+	if maxLines <= 0 {
+		maxLines = 5000
+	}
+	m := Model{
+		name:       name,
+		jsonFields: cfg.JSONFields,
+		width:      60,
+		height:     10,
+	}
+	for _, f := range cfg.Files {
+		m.files = append(m.files, filepath.Clean(config.ExpandPath(f)))
+	}
+	m.rules, m.ruleErrs = compileRules(cfg.Highlight)
+	if len(m.files) > 0 {
+		m.t = startTailer(m.files, backlog, maxLines)
+	}
+	return m
+}
+
+func (m Model) Init() tea.Cmd {
+	if m.t == nil {
+		return nil
+	}
+	return waitOutput(m.t)
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case types.SizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+	case outputMsg:
+		if msg.tailer != m.t {
+			return m, nil
+		}
+		return m, waitOutput(m.t)
+	case types.MouseMsg:
+		switch msg.Button {
+		case tea.MouseButtonWheelUp:
+			m.scrollBy(3)
+		case tea.MouseButtonWheelDown:
+			m.scrollBy(-3)
+		}
+		return m, nil
+	case tea.KeyMsg:
+		if m.prompting {
+			return m.handlePrompt(msg), nil
+		}
+		return m.handleKey(msg), nil
+	}
+	return m, nil
+}
+
+func (m Model) handleKey(msg tea.KeyMsg) Model {
+	switch msg.String() {
+	case "/":
+		m.prompting, m.input, m.before = true, m.filterText, m.filterText
+	case "esc":
+		m.setFilter("")
+	case " ", "p":
+		if m.paused {
+			m.resume()
+		} else {
+			m.pause()
+		}
+	case "up", "k":
+		m.scrollBy(1)
+	case "down", "j":
+		m.scrollBy(-1)
+	case "pgup":
+		m.scrollBy(m.bodyHeight())
+	case "pgdown":
+		m.scrollBy(-m.bodyHeight())
+	case "g", "home":
+		m.scrollBy(m.maxLines())
+	case "G", "end":
+		m.resume()
+	case "J":
+		if len(m.jsonFields) > 0 {
+			m.raw = !m.raw
+		}
+	}
+	return m
+}
+
+// handlePrompt edits the filter, which applies as it is typed.
+func (m Model) handlePrompt(msg tea.KeyMsg) Model {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.prompting = false
+		return m
+	case tea.KeyEsc:
+		m.prompting = false
+		m.setFilter(m.before)
+		return m
+	case tea.KeyBackspace:
+		if r := []rune(m.input); len(r) > 0 {
+			m.input = string(r[:len(r)-1])
+		}
+	case tea.KeyCtrlU:
+		m.input = ""
+	case tea.KeySpace:
+		m.input += " "
+	case tea.KeyRunes:
+		m.input += string(msg.Runes)
+	default:
+		return m
+	}
+	m.setFilter(m.input)
+	return m
+}
+
+// setFilter shows only lines matching text, case-insensitively. Text that
+// is not a valid regexp, often one half typed, matches literally.
+func (m *Model) setFilter(text string) {
+	m.filterText, m.filter, m.filterLiteral, m.offset = text, nil, false, 0
+	if text == "" {
+		return
+	}
+	re, err := regexp.Compile("(?i)" + text)
+	if err != nil {
+		re = regexp.MustCompile("(?i)" + regexp.QuoteMeta(text))
+		m.filterLiteral = true
+	}
+	m.filter = re
+}
+
+// pause holds the view at the lines shown now; new lines are counted
+// below it.
+func (m *Model) pause() {
+	if m.t == nil || m.paused {
+		return
+	}
+	lines, dropped, _ := m.t.snapshot()
+	m.paused, m.anchor = true, dropped+len(lines)
+}
+
+// resume follows new lines again.
+func (m *Model) resume() {
+	m.paused, m.offset = false, 0
+}
+
+// scrollBy moves the view n matching lines back, or forward when
+// negative. Scrolling back pauses; scrolling forward past the last line
+// held resumes following.
+func (m *Model) scrollBy(n int) {
+	if m.t == nil {
+		return
+	}
+	if n < 0 && m.offset == 0 {
+		m.resume()
+		return
+	}
+	if n > 0 {
+		m.pause()
+	}
+	lines, dropped, _ := m.t.snapshot()
+	_, m.offset = m.window(lines, dropped, max(m.offset+n, 0), m.bodyHeight())
+}
+
+func (m Model) maxLines() int {
+	if m.t == nil {
+		return 0
+	}
+	return m.t.maxLines
+}
+
+// window returns up to n lines passing the filter, ending offset matching
+// lines above the anchor, oldest first, and the offset in effect once
+// clamped to the lines there are.
+func (m Model) window(lines []entry, dropped, offset, n int) ([]entry, int) {
+	end := len(lines)
+	if m.paused {
+		end = max(min(m.anchor-dropped, len(lines)), 0)
+	}
+	want := offset + n
+	var picked []entry // newest first
+	for i := end - 1; i >= 0 && len(picked) < want; i-- {
+		if m.filter == nil || m.filter.MatchString(lines[i].text) {
+			picked = append(picked, lines[i])
+		}
+	}
+	offset = max(min(offset, len(picked)-n), 0)
+	shown := picked[offset:min(offset+n, len(picked))]
+	out := make([]entry, len(shown))
+	for i, e := range shown {
+		out[len(shown)-1-i] = e
+	}
+	return out, offset
+}
+
+// CapturesKeys gives the filter prompt every key while it is open.
+func (m Model) CapturesKeys() bool {
+	return m.prompting
+}
+
+// Close stops following the files.
+func (m Model) Close() {
+	if m.t != nil {
+		m.t.close()
+	}
+}
+
+func (m Model) SetPosition(x, y int) {
+	m.pos.X = x
+	m.pos.Y = y
+}
+
+func (m Model) Name() string {
+	return m.name
+}
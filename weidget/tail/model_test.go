@@ -0,0 +1,122 @@
+package tail
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/antiloger/termctlr/config"
+	"github.com/antiloger/termctlr/types"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// testModel is a widget showing lines as if read from app.log.
+func testModel(cfg config.TailConfig, lines ...string) Model {
+	m := NewModel(cfg)
+	m.files = []string{"app.log"}
+	m.t = &tailer{labels: []string{"app.log"}, maxLines: 100, errs: make([]error, 1)}
+	m.t.add(0, lines)
+	next, _ := m.Update(types.SizeMsg{Width: 80, Height: 3 + len(lines)})
+	return next.(Model)
+}
+
+func press(m Model, keys ...string) Model {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "backspace":
+			msg = tea.KeyMsg{Type: tea.KeyBackspace}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		next, _ := m.Update(msg)
+		m = next.(Model)
+	}
+	return m
+}
+
+// body is the shown lines, without the title, status and help lines.
+func body(m Model) []string {
+	lines := strings.Split(ansi.Strip(m.View()), "\n")
+	var out []string
+	for _, l := range lines[1 : len(lines)-2] {
+		if l = strings.TrimRight(l, " "); l != "" {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+func TestLiveFilter(t *testing.T) {
+	m := testModel(config.TailConfig{}, "GET /", "ERROR disk full", "GET /a", "error: [x] gone")
+
+	// the filter applies while it is typed, case-insensitively
+	m = press(m, "/", "e", "r")
+	if got := body(m); len(got) != 2 || got[0] != "ERROR disk full" {
+		t.Errorf("filtered by %q: %q", m.input, got)
+	}
+	if !m.CapturesKeys() {
+		t.Error("prompt open without the keys")
+	}
+	m = press(m, "backspace", "backspace", "G", "E", "T", "enter")
+	if got := body(m); len(got) != 2 || got[1] != "GET /a" || m.CapturesKeys() {
+		t.Errorf("kept filter %q: %q", m.filterText, got)
+	}
+
+	// half a regexp matches as text
+	m = press(m, "/", "backspace", "backspace", "backspace", "[", "x")
+	if got := body(m); len(got) != 1 || !m.filterLiteral || !strings.Contains(ansi.Strip(m.View()), "filter matches as text") {
+		t.Errorf("literal filter: %q", got)
+	}
+	m = press(m, "esc")
+	if m.filterText != "GET" || len(body(m)) != 2 {
+		t.Errorf("esc at the prompt left filter %q, want the one before", m.filterText)
+	}
+	if m = press(m, "esc"); m.filter != nil || len(body(m)) != 4 {
+		t.Errorf("esc did not clear filter %q", m.filterText)
+	}
+
+	m = press(m, "/", "n", "o", "p", "e", "enter")
+	if got := body(m); len(got) != 1 || got[0] != "(no matching lines)" {
+		t.Errorf("no matches: %q", got)
+	}
+}
+
+func TestJSONFields(t *testing.T) {
+	line := `{"time":"12:00","level":"warn","msg":"slow","req":{"path":"/api","ms":1200},"user.id":7,"tags":["a"],"err":null}`
+	fields := []string{"time", "level", "msg", "req.path", "req.ms", "user.id", "tags", "err", "missing"}
+	for _, c := range []struct{ in, want string }{
+		{line, `12:00 warn slow req.path=/api req.ms=1200 user.id=7 tags=["a"] err=null`},
+		{`  {"msg": "indented"}`, "indented"},
+		{`{"other": 1}`, `{"other": 1}`},
+		{`{"msg": "broken"`, `{"msg": "broken"`},
+		{`["msg"]`, `["msg"]`},
+		{"plain text", "plain text"},
+	} {
+		if got := formatJSON(c.in, fields); got != c.want {
+			t.Errorf("formatJSON(%s)\n got %s\nwant %s", c.in, got, c.want)
+		}
+	}
+	if got := formatJSON(line, nil); got != line {
+		t.Errorf("without fields: %s", got)
+	}
+
+	// J toggles between fields and the raw line
+	m := testModel(config.TailConfig{JSONFields: []string{"level", "msg"}}, `{"level":"info","msg":"up"}`)
+	if got := body(m); len(got) != 1 || got[0] != "info up" {
+		t.Errorf("view %q", got)
+	}
+	if got := body(press(m, "J")); len(got) != 1 || got[0] != `{"level":"info","msg":"up"}` {
+		t.Errorf("raw view %q", got)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	if got := sanitize("\x1b[31mred\x1b[0m\ta\x07b"); got != "red    ab" {
+		t.Errorf("sanitize = %q", got)
+	}
+}
@@ -0,0 +1,142 @@
+package tail
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/antiloger/termctlr/theme"
+	"github.com/antiloger/termctlr/weidget/components"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// maxLabel is the widest file label column, with several files.
+const maxLabel = 16
+
+// bodyHeight is the number of lines shown, below the title and above the
+// status and help lines.
+func (m Model) bodyHeight() int {
+	return max(m.height-3, 1)
+}
+
+func (m Model) View() string {
+	t := theme.Current()
+	muted := t.Style(theme.Muted)
+	if m.t == nil {
+		return strings.Join([]string{
+			muted.Render(m.name + ": no files configured"),
+			muted.Render(`add some under tails, e.g. {"name": "nginx", "files": ["/var/log/nginx/access.log"]}`),
+		}, "\n")
+	}
+
+	lines, dropped, errs := m.t.snapshot()
+	h := m.bodyHeight()
+	shown, offset := m.window(lines, dropped, m.offset, h)
+
+	title := t.Style(theme.Accent).Bold(true).Render(m.name) + " " + muted.Render(strings.Join(m.t.labels, ", "))
+	if m.filter != nil && !m.prompting {
+		title += t.Style(theme.Focus).Render(" /" + m.filterText + "/")
+	}
+
+	labelW := 0
+	if len(m.files) > 1 {
+		for _, l := range m.t.labels {
+			labelW = max(labelW, min(ansi.StringWidth(l), maxLabel))
+		}
+	}
+	rules := m.rules
+	if m.filter != nil {
+		rules = append([]rule{{re: m.filter, role: theme.Focus}}, rules...)
+	}
+
+	body := make([]string, h)
+	for i := range body {
+		switch {
+		case i < len(shown):
+			body[i] = m.renderLine(t, shown[i], labelW, rules)
+		case i == 0 && len(shown) == 0 && m.filter != nil:
+			body[i] = muted.Render(components.Fit("(no matching lines)", m.width))
+		case i == 0 && len(shown) == 0:
+			body[i] = muted.Render(components.Fit("(waiting for lines)", m.width))
+		default:
+			body[i] = components.Fit("", m.width)
+		}
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		components.Fit(title, m.width),
+		strings.Join(body, "\n"),
+		components.Fit(m.status(t, lines, dropped, errs, offset), m.width),
+		m.helpLine(t),
+	)
+}
+
+func (m Model) renderLine(t *theme.Theme, e entry, labelW int, rules []rule) string {
+	prefix := ""
+	if labelW > 0 {
+		prefix = t.Style(theme.Muted).Render(components.Fit(ansi.Truncate(m.t.labels[e.file], labelW, "…"), labelW) + " │ ")
+	}
+	text := sanitize(e.text)
+	if !m.raw {
+		text = formatJSON(text, m.jsonFields)
+	}
+	// cut before colouring, so long lines cost only what is shown
+	room := max(m.width-ansi.StringWidth(prefix), 0)
+	text = ansi.Truncate(text, room, "…")
+	return components.Fit(prefix+highlight(t, text, rules, theme.Text), m.width)
+}
+
+// status says whether the view follows or is paused, and which files
+// cannot be read.
+func (m Model) status(t *theme.Theme, lines []entry, dropped int, errs []error, offset int) string {
+	muted := t.Style(theme.Muted)
+	var s string
+	if m.paused {
+		s = t.Style(theme.GaugeMed).Render("❚❚ paused")
+		if n := dropped + len(lines) - m.anchor; n > 0 {
+			s += muted.Render(fmt.Sprintf(" · %d new", n))
+		}
+		if offset > 0 {
+			s += muted.Render(fmt.Sprintf(" · %d lines up", offset))
+		}
+	} else {
+		s = t.Style(theme.Accent).Render("● following")
+	}
+	if m.filterLiteral {
+		s += muted.Render(" · filter matches as text")
+	}
+	for i, err := range errs {
+		if err != nil {
+			s += t.Style(theme.Alert).Render(" · " + m.t.labels[i] + ": " + errText(err))
+		}
+	}
+	for _, err := range m.ruleErrs {
+		s += t.Style(theme.Alert).Render(" · " + err.Error())
+	}
+	return s
+}
+
+// errText is an error without the path, which the label already gives.
+func errText(err error) string {
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		return pe.Err.Error()
+	}
+	return err.Error()
+}
+
+func (m Model) helpLine(t *theme.Theme) string {
+	if m.prompting {
+		return components.Fit(t.Style(theme.Focus).Render("/"+m.input+"█")+t.Style(theme.Muted).Render("  enter keep · esc cancel"), m.width)
+	}
+	help := "/ filter · space pause · ↑ ↓ pgup pgdn scroll · G follow"
+	if m.filter != nil {
+		help += " · esc clear"
+	}
+	if len(m.jsonFields) > 0 {
+		help += " · J raw"
+	}
+	return t.Style(theme.Muted).Render(components.Fit(help, m.width))
+}
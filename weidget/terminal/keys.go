@@ -0,0 +1,80 @@
+package terminal
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// special are the xterm sequences of keys that are not characters.
+// Cursor keys differ in application cursor mode; see keyBytes.
+var special = map[tea.KeyType]string{
+	tea.KeyUp:             "\x1b[A",
+	tea.KeyDown:           "\x1b[B",
+	tea.KeyRight:          "\x1b[C",
+	tea.KeyLeft:           "\x1b[D",
+	tea.KeyHome:           "\x1b[H",
+	tea.KeyEnd:            "\x1b[F",
+	tea.KeyShiftTab:       "\x1b[Z",
+	tea.KeyInsert:         "\x1b[2~",
+	tea.KeyDelete:         "\x1b[3~",
+	tea.KeyPgUp:           "\x1b[5~",
+	tea.KeyPgDown:         "\x1b[6~",
+	tea.KeyShiftUp:        "\x1b[1;2A",
+	tea.KeyShiftDown:      "\x1b[1;2B",
+	tea.KeyShiftRight:     "\x1b[1;2C",
+	tea.KeyShiftLeft:      "\x1b[1;2D",
+	tea.KeyShiftHome:      "\x1b[1;2H",
+	tea.KeyShiftEnd:       "\x1b[1;2F",
+	tea.KeyCtrlUp:         "\x1b[1;5A",
+	tea.KeyCtrlDown:       "\x1b[1;5B",
+	tea.KeyCtrlRight:      "\x1b[1;5C",
+	tea.KeyCtrlLeft:       "\x1b[1;5D",
+	tea.KeyCtrlHome:       "\x1b[1;5H",
+	tea.KeyCtrlEnd:        "\x1b[1;5F",
+	tea.KeyCtrlPgUp:       "\x1b[5;5~",
+	tea.KeyCtrlPgDown:     "\x1b[6;5~",
+	tea.KeyCtrlShiftUp:    "\x1b[1;6A",
+	tea.KeyCtrlShiftDown:  "\x1b[1;6B",
+	tea.KeyCtrlShiftRight: "\x1b[1;6C",
+	tea.KeyCtrlShiftLeft:  "\x1b[1;6D",
+	tea.KeyF1:             "\x1bOP",
+	tea.KeyF2:             "\x1bOQ",
+	tea.KeyF3:             "\x1bOR",
+	tea.KeyF4:             "\x1bOS",
+	tea.KeyF5:             "\x1b[15~",
+	tea.KeyF6:             "\x1b[17~",
+	tea.KeyF7:             "\x1b[18~",
+	tea.KeyF8:             "\x1b[19~",
+	tea.KeyF9:             "\x1b[20~",
+	tea.KeyF10:            "\x1b[21~",
+	tea.KeyF11:            "\x1b[23~",
+	tea.KeyF12:            "\x1b[24~",
+}
+
+// keyBytes is what a terminal sends for a key press.
+func keyBytes(k tea.KeyMsg, appCursor, bracketPaste bool) []byte {
+	var out string
+	switch {
+	case k.Type == tea.KeyRunes:
+		out = string(k.Runes)
+		if k.Paste && bracketPaste {
+			out = "\x1b[200~" + out + "\x1b[201~"
+		}
+	case k.Type == tea.KeySpace:
+		out = " "
+	case k.Type >= 0 && k.Type <= 127:
+		out = string(rune(k.Type)) // control characters are their own codes
+	default:
+		seq, ok := special[k.Type]
+		if !ok {
+			return nil
+		}
+		if appCursor && len(seq) == 3 && seq[1] == '[' && seq[2] >= 'A' && seq[2] <= 'H' {
+			seq = "\x1bO" + seq[2:]
+		}
+		out = seq
+	}
+	if k.Alt {
+		out = "\x1b" + out
+	}
+	return []byte(out)
+}
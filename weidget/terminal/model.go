@@ -0,0 +1,236 @@
+// Package terminal runs a command, by default the user's shell, in a
+// pseudo-terminal drawn inside a pane.
+package terminal
+
+import (
+	"os"
+	"sync/atomic"
+
+	"github.com/antiloger/termctlr/config"
+	"github.com/antiloger/termctlr/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// releaseKey hands the keyboard back to the dashboard, as in telnet.
+const releaseKey = "ctrl+]"
+
+// lastID numbers the widgets, so each knows its own messages: the screen
+// hands every message to every widget.
+var lastID atomic.Int64
+
+// Model is the terminal widget. The program starts at a default size;
+// layouts that allot panes a size resize it to fit.
+type Model struct {
+	id         int64
+	name       string
+	argv       []string
+	scrollback int
+
+	sess     *session
+	starting bool
+	err      error // the program could not be started
+
+	captured bool // keys go to the program
+	scroll   int  // lines scrolled back, 0 at the live screen
+
+	width, height int
+	pos           types.Position
+}
+
+func NewModel(cfg config.TerminalConfig) Model {
+	name := cfg.Name
+	if name == "" {
+		name = "terminal"
+	}
+	argv := []string{"sh", "-c", cfg.Command}
+	if cfg.Command == "" {
+		shell := os.Getenv("SHELL")
+		if shell == "" {
+			shell = "/bin/sh"
+		}
+		argv = []string{shell}
+	}
+	scrollback := cfg.Scrollback
+	if scrollback <= 0 {
+		scrollback = 1000
+	}
+	return Model{
+		id:         lastID.Add(1),
+		name:       name,
+		argv:       argv,
+		scrollback: scrollback,
+		captured:   true,
+		starting:   true, // by Init
+		width:      80,
+		height:     24,
+	}
+}
+
+// startedMsg carries a session started off the UI goroutine for widget id.
+type startedMsg struct {
+	id   int64
+	sess *session
+	err  error
+}
+
+func (m Model) Init() tea.Cmd {
+	_, cmd := m.start()
+	return cmd
+}
+
+// termSize is the size of the terminal: the pane less the status line.
+func (m Model) termSize() (int, int) {
+	return max(m.width, 1), max(m.height-1, 1)
+}
+
+func (m Model) start() (Model, tea.Cmd) {
+	m.starting, m.err, m.scroll, m.captured = true, nil, 0, true
+	id, argv, scrollback := m.id, m.argv, m.scrollback
+	w, h := m.termSize()
+	return m, func() tea.Msg {
+		s, err := startSession(argv, w, h, scrollback)
+		return startedMsg{id: id, sess: s, err: err}
+	}
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case types.SizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		if m.sess != nil {
+			m.sess.resize(m.termSize())
+		}
+		return m, nil
+	case startedMsg:
+		if msg.id != m.id {
+			return m, nil // another terminal's program
+		}
+		m.starting = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		if m.sess != nil {
+			go m.sess.close()
+		}
+		m.sess = msg.sess
+		m.sess.resize(m.termSize()) // the pane may have changed meanwhile
+		return m, waitOutput(m.sess)
+	case outputMsg:
+		if msg.session != m.sess {
+			return m, nil // a session that has been replaced
+		}
+		if m.exited() {
+			m.captured = false // nothing left to type into
+			return m, nil
+		}
+		return m, waitOutput(m.sess)
+	case types.MouseMsg:
+		switch msg.Button {
+		case tea.MouseButtonWheelUp:
+			m.scrollBy(3)
+		case tea.MouseButtonWheelDown:
+			m.scrollBy(-3)
+		}
+		return m, nil
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m Model) handleKey(msg tea.KeyMsg) (Model, tea.Cmd) {
+	if m.CapturesKeys() {
+		if msg.String() == releaseKey {
+			m.captured = false
+			return m, nil
+		}
+		m.sess.mu.Lock()
+		p := keyBytes(msg, m.sess.screen.appCursor, m.sess.screen.bracketPaste)
+		m.sess.mu.Unlock()
+		if len(p) > 0 {
+			m.sess.send(p)
+			m.scroll = 0 // typing shows the live screen, as terminals do
+		}
+		return m, nil
+	}
+
+	_, h := m.termSize()
+	switch msg.String() {
+	case "enter", "i":
+		switch {
+		case m.exited() || m.err != nil:
+			if !m.starting {
+				return m.start()
+			}
+		case m.sess != nil:
+			m.captured, m.scroll = true, 0
+		}
+	case "r":
+		if (m.exited() || m.err != nil) && !m.starting {
+			return m.start()
+		}
+	case "up", "k":
+		m.scrollBy(1)
+	case "down", "j":
+		m.scrollBy(-1)
+	case "pgup":
+		m.scrollBy(h)
+	case "pgdown":
+		m.scrollBy(-h)
+	case "home", "g":
+		m.scrollBy(m.scrollback)
+	case "end", "G":
+		m.scroll = 0
+	}
+	return m, nil
+}
+
+// scrollBy moves the view n lines back into the scrollback, or forward
+// when negative.
+func (m *Model) scrollBy(n int) {
+	if m.sess == nil {
+		return
+	}
+	m.sess.mu.Lock()
+	back := len(m.sess.screen.scrollback)
+	if m.sess.screen.alt {
+		back = 0 // full screen programs have no scrollback of their own
+	}
+	m.sess.mu.Unlock()
+	m.scroll = max(min(m.scroll+n, back), 0)
+}
+
+func (m Model) exited() bool {
+	if m.sess == nil {
+		return false
+	}
+	select {
+	case <-m.sess.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// CapturesKeys makes the dashboard pass every key, tab included, while
+// the program runs and has not been released with ctrl+].
+func (m Model) CapturesKeys() bool {
+	return m.captured && m.sess != nil && !m.exited()
+}
+
+// Close hangs up on the program when the dashboard exits.
+func (m Model) Close() {
+	if m.sess != nil {
+		m.sess.close()
+	}
+}
+
+func (m Model) SetPosition(x, y int) {
+	m.pos.X = x
+	m.pos.Y = y
+}
+
+func (m Model) Name() string {
+	return m.name
+}
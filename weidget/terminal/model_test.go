@@ -0,0 +1,25 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/antiloger/termctlr/config"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestStartedGoesToItsOwnTerminal(t *testing.T) {
+	var a, b tea.Model = NewModel(config.TerminalConfig{Command: "sleep 5"}), NewModel(config.TerminalConfig{Command: "sleep 5"})
+	msg := a.Init()()
+	defer a.(Model).Close()
+	defer b.(Model).Close()
+
+	// the screen hands every message to every widget
+	b, _ = b.Update(msg)
+	a, _ = a.Update(msg)
+	if b.(Model).sess != nil || !b.(Model).starting {
+		t.Error("b adopted a's session")
+	}
+	if a.(Model).sess != msg.(startedMsg).sess {
+		t.Error("a did not take its session")
+	}
+}
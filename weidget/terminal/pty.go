@@ -0,0 +1,207 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/sys/unix"
+)
+
+// inputQueue bounds key presses waiting for the program to read them;
+// beyond it they are dropped rather than stalling the UI.
+const inputQueue = 256
+
+// openPTY opens a pseudo-terminal pair. The master is non-blocking, so
+// its reads go through the runtime poller and end when it is closed.
+func openPTY() (master, slave *os.File, err error) {
+	fd, err := unix.Open("/dev/ptmx", unix.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, nil, &os.PathError{Op: "open", Path: "/dev/ptmx", Err: err}
+	}
+	master = os.NewFile(uintptr(fd), "/dev/ptmx")
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil { // unlockpt
+		master.Close()
+		return nil, nil, os.NewSyscallError("unlockpt", err)
+	}
+	n, err := unix.IoctlGetUint32(fd, unix.TIOCGPTN) // ptsname
+	if err != nil {
+		master.Close()
+		return nil, nil, os.NewSyscallError("ptsname", err)
+	}
+	name := fmt.Sprintf("/dev/pts/%d", n)
+	sfd, err := unix.Open(name, unix.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	return master, os.NewFile(uintptr(sfd), name), nil
+}
+
+func setSize(f *os.File, w, h int) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var serr error
+	err = conn.Control(func(fd uintptr) {
+		serr = unix.IoctlSetWinsize(int(fd), unix.TIOCSWINSZ, &unix.Winsize{Row: uint16(h), Col: uint16(w)})
+	})
+	return errors.Join(err, serr)
+}
+
+// session is a program running on a pseudo-terminal, with the screen its
+// output is drawn on.
+type session struct {
+	master  *os.File
+	cmd     *exec.Cmd
+	input   chan []byte   // to the program, written in order by one goroutine
+	updates chan struct{} // signalled (never blocking) on output and exit
+	done    chan struct{} // closed when the program exited
+
+	mu       sync.Mutex
+	screen   *screen
+	exited   bool
+	exitCode int
+	err      error
+}
+
+// startSession runs argv on a new pseudo-terminal of w×h cells.
+func startSession(argv []string, w, h, scrollback int) (*session, error) {
+	master, slave, err := openPTY()
+	if err != nil {
+		return nil, err
+	}
+	defer slave.Close() // the program has its own copy
+
+	if err := setSize(master, w, h); err != nil {
+		master.Close()
+		return nil, err
+	}
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Env = append(os.Environ(), "TERM=xterm-256color", "COLORTERM=truecolor")
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	// a session of its own with the terminal as its controlling tty, so
+	// job control and ^C work as in any terminal
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: 0}
+	if err := cmd.Start(); err != nil {
+		master.Close()
+		return nil, err
+	}
+
+	s := &session{
+		master:  master,
+		cmd:     cmd,
+		input:   make(chan []byte, inputQueue),
+		updates: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	s.screen = newScreen(w, h, scrollback, s.send)
+	go s.read()
+	go s.write()
+	return s, nil
+}
+
+func (s *session) read() {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := s.master.Read(buf)
+		if n > 0 {
+			s.mu.Lock()
+			s.screen.Write(buf[:n])
+			s.mu.Unlock()
+			s.notify()
+		}
+		if err != nil {
+			break // EIO once the program and its children closed the tty
+		}
+	}
+	err := s.cmd.Wait()
+	code := 0
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		code, err = exit.ExitCode(), nil
+		if code < 0 {
+			err = errors.New(exit.String()) // killed by a signal
+		}
+	}
+	s.mu.Lock()
+	s.exited, s.exitCode, s.err = true, code, err
+	s.mu.Unlock()
+	s.master.Close()
+	close(s.done)
+	s.notify()
+}
+
+func (s *session) write() {
+	for {
+		select {
+		case p := <-s.input:
+			if _, err := s.master.Write(p); err != nil && !errors.Is(err, io.ErrShortWrite) {
+				return
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// send queues input for the program without blocking.
+func (s *session) send(p []byte) {
+	select {
+	case s.input <- p:
+	default:
+	}
+}
+
+func (s *session) notify() {
+	select {
+	case s.updates <- struct{}{}:
+	default:
+	}
+}
+
+// resize changes the terminal size; the kernel tells the program with
+// SIGWINCH.
+func (s *session) resize(w, h int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.exited || (w == s.screen.w && h == s.screen.h) {
+		return
+	}
+	s.screen.resize(w, h)
+	setSize(s.master, w, h)
+}
+
+// close hangs up on the program, as closing a terminal window does, and
+// kills it if it is still there shortly after.
+func (s *session) close() {
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	syscall.Kill(-s.cmd.Process.Pid, syscall.SIGHUP)
+	select {
+	case <-s.done:
+	case <-time.After(2 * time.Second):
+		syscall.Kill(-s.cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// outputMsg says the session has new output or ended.
+type outputMsg struct{ session *session }
+
+// waitOutput wakes the UI on output; re-issue it after each one.
+func waitOutput(s *session) tea.Cmd {
+	return func() tea.Msg {
+		<-s.updates
+		return outputMsg{session: s}
+	}
+}
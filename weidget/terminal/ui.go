@@ -0,0 +1,72 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antiloger/termctlr/theme"
+	"github.com/antiloger/termctlr/weidget/components"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func (m Model) View() string {
+	t := theme.Current()
+	w, h := m.termSize()
+
+	var rows []string
+	title, back, scroll := "", 0, m.scroll
+	var exitCode int
+	var exitErr error
+	if m.sess != nil {
+		m.sess.mu.Lock()
+		if m.sess.screen.alt {
+			scroll = 0
+		}
+		rows = m.sess.screen.render(scroll, m.CapturesKeys())
+		title, back = m.sess.screen.title, len(m.sess.screen.scrollback)
+		exitCode, exitErr = m.sess.exitCode, m.sess.err
+		m.sess.mu.Unlock()
+	}
+
+	body := make([]string, h)
+	for i := range body {
+		switch {
+		case i < len(rows):
+			body[i] = components.Fit(rows[i], w)
+		case i == 0 && m.err != nil:
+			body[i] = t.Style(theme.Alert).Render(components.Fit("✕ "+m.err.Error(), w))
+		case i == 0 && m.sess == nil:
+			body[i] = t.Style(theme.Muted).Render(components.Fit("starting "+strings.Join(m.argv, " ")+"…", w))
+		default:
+			body[i] = components.Fit("", w)
+		}
+	}
+
+	muted := t.Style(theme.Muted)
+	nameStyle := t.Style(theme.Accent).Bold(true)
+	if m.CapturesKeys() {
+		nameStyle = t.Style(theme.Focus).Bold(true)
+	}
+	status := nameStyle.Render(m.name)
+	if title != "" {
+		status += muted.Render(" · " + title)
+	}
+	switch {
+	case m.err != nil:
+		status += muted.Render(" · r retry")
+	case m.exited() && exitErr != nil:
+		status += t.Style(theme.Alert).Render(" · ✕ "+exitErr.Error()) + muted.Render(" · enter restart")
+	case m.exited() && exitCode != 0:
+		status += t.Style(theme.Alert).Render(fmt.Sprintf(" · ✕ exit %d", exitCode)) + muted.Render(" · enter restart")
+	case m.exited():
+		status += muted.Render(" · exited · enter restart")
+	case m.CapturesKeys():
+		status += muted.Render(" · " + releaseKey + " release")
+	case m.sess != nil:
+		status += muted.Render(" · enter type · ↑ ↓ pgup pgdn scroll")
+	}
+	if scroll > 0 {
+		status += muted.Render(fmt.Sprintf(" · %d/%d lines back", scroll, back))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, strings.Join(body, "\n"), components.Fit(status, w))
+}
@@ -0,0 +1,1019 @@
+package terminal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// color is a cell colour: the terminal default, one of the 256 indexed
+// colours, or 24 bit RGB.
+type color struct {
+	kind uint8 // colorDefault, colorIndexed, colorRGB
+	v    uint32
+}
+
+const (
+	colorDefault = iota
+	colorIndexed
+	colorRGB
+)
+
+type attrs uint8
+
+const (
+	attrBold attrs = 1 << iota
+	attrDim
+	attrItalic
+	attrUnderline
+	attrBlink
+	attrReverse
+	attrHidden
+	attrStrike
+)
+
+type style struct {
+	fg, bg color
+	attr   attrs
+}
+
+// cell is one character cell. A wide character fills two: the second has
+// width 0 and is skipped when drawing.
+type cell struct {
+	r     rune // 0 for blank
+	width int8
+	st    style
+}
+
+type line []cell
+
+// cursor is the cursor state saved by DECSC and restored by DECRC.
+type cursor struct {
+	x, y     int
+	st       style
+	origin   bool
+	graphics [2]bool
+	shift    int
+}
+
+// parser states
+const (
+	stGround = iota
+	stEscape
+	stEscInter // ESC followed by an intermediate, e.g. ESC ( 0
+	stCSI
+	stOSC
+	stOSCEsc // ESC inside an OSC, maybe the start of ST
+	stString // DCS, SOS, PM and APC, ignored up to ST
+	stStringEsc
+)
+
+// screen is a VT100/xterm screen: a grid of cells with a cursor, an
+// alternate screen, and scrollback for lines scrolled off the top of the
+// main screen. Write feeds it output from the program; the escape
+// sequences it understands are the common xterm subset that shells,
+// pagers and full screen programs use.
+type screen struct {
+	w, h       int
+	grid       []line
+	other      []line // the inactive one of main and alternate
+	alt        bool
+	scrollback []line
+	maxBack    int
+
+	x, y     int
+	wrapNext bool // the last column was written; the next character wraps
+	st       style
+	top, bot int // scroll region, inclusive
+	saved    [2]cursor
+	tabs     []bool
+	graphics [2]bool // G0 and G1 are DEC special graphics
+	shift    int     // active charset, 0 or 1
+	last     rune    // last printed character, for REP
+
+	autowrap      bool
+	origin        bool
+	insert        bool
+	cursorVisible bool
+	appCursor     bool
+	bracketPaste  bool
+	title         string
+
+	reply func([]byte) // answers to status queries
+
+	state  int
+	params []byte // raw CSI parameter bytes
+	inter  []byte
+	osc    []byte
+	utf    []byte // an incomplete UTF-8 sequence from the last write
+}
+
+func newScreen(w, h, maxBack int, reply func([]byte)) *screen {
+	s := &screen{maxBack: maxBack, reply: reply}
+	s.w, s.h = max(w, 1), max(h, 1)
+	s.reset()
+	return s
+}
+
+// reset is RIS: everything but the scrollback back to power-on state.
+func (s *screen) reset() {
+	s.grid = s.blankLines(s.h)
+	s.other = s.blankLines(s.h)
+	s.alt = false
+	s.x, s.y, s.wrapNext = 0, 0, false
+	s.st = style{}
+	s.top, s.bot = 0, s.h-1
+	s.saved = [2]cursor{}
+	s.graphics, s.shift = [2]bool{}, 0
+	s.autowrap, s.origin, s.insert = true, false, false
+	s.cursorVisible, s.appCursor, s.bracketPaste = true, false, false
+	s.resetTabs()
+}
+
+func (s *screen) resetTabs() {
+	s.tabs = make([]bool, s.w)
+	for i := 8; i < s.w; i += 8 {
+		s.tabs[i] = true
+	}
+}
+
+func (s *screen) blankLine() line {
+	l := make(line, s.w)
+	for i := range l {
+		l[i] = cell{width: 1, st: style{bg: s.st.bg}}
+	}
+	return l
+}
+
+func (s *screen) blankLines(n int) []line {
+	lines := make([]line, n)
+	for i := range lines {
+		lines[i] = s.blankLine()
+	}
+	return lines
+}
+
+// Write interprets program output.
+func (s *screen) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(s.utf) > 0 {
+		p = append(s.utf, p...)
+		s.utf = nil
+	}
+	for len(p) > 0 {
+		b := p[0]
+		if b < utf8.RuneSelf || s.state != stGround {
+			s.byte(b)
+			p = p[1:]
+			continue
+		}
+		if !utf8.FullRune(p) {
+			s.utf = append([]byte(nil), p...)
+			break
+		}
+		r, size := utf8.DecodeRune(p)
+		s.print(r)
+		p = p[size:]
+	}
+	return n, nil
+}
+
+func (s *screen) byte(b byte) {
+	// C0 controls act in the middle of most sequences too
+	if b < 0x20 && s.state != stOSC && s.state != stString {
+		switch b {
+		case 0x1b:
+			s.enter(stEscape)
+			return
+		case 0x18, 0x1a: // CAN, SUB abort a sequence
+			s.state = stGround
+			return
+		}
+		if s.state == stOSCEsc || s.state == stStringEsc {
+			s.state = stGround
+		}
+		s.control(b)
+		return
+	}
+
+	switch s.state {
+	case stGround:
+		if b == 0x7f {
+			return
+		}
+		s.print(rune(b))
+	case stEscape:
+		switch {
+		case b == '[':
+			s.enter(stCSI)
+		case b == ']':
+			s.enter(stOSC)
+		case b == 'P' || b == 'X' || b == '^' || b == '_':
+			s.enter(stString)
+		case b >= 0x20 && b <= 0x2f:
+			s.inter = append(s.inter, b)
+			s.state = stEscInter
+		default:
+			s.state = stGround
+			s.escape(b)
+		}
+	case stEscInter:
+		if b >= 0x20 && b <= 0x2f {
+			s.inter = append(s.inter, b)
+			return
+		}
+		s.state = stGround
+		s.escInter(s.inter[0], b)
+	case stCSI:
+		switch {
+		case b >= 0x30 && b <= 0x3f:
+			s.params = append(s.params, b)
+		case b >= 0x20 && b <= 0x2f:
+			s.inter = append(s.inter, b)
+		case b >= 0x40 && b <= 0x7e:
+			s.state = stGround
+			s.csi(b)
+		default:
+			s.state = stGround
+		}
+	case stOSC:
+		switch b {
+		case 0x07:
+			s.state = stGround
+			s.oscDone()
+		case 0x1b:
+			s.state = stOSCEsc
+		default:
+			if len(s.osc) < 4096 {
+				s.osc = append(s.osc, b)
+			}
+		}
+	case stOSCEsc:
+		s.state = stGround
+		if b == '\\' {
+			s.oscDone()
+		}
+	case stString:
+		if b == 0x1b {
+			s.state = stStringEsc
+		} else if b == 0x07 {
+			s.state = stGround
+		}
+	case stStringEsc:
+		if b == '\\' {
+			s.state = stGround
+		} else {
+			s.state = stString
+		}
+	}
+}
+
+func (s *screen) enter(state int) {
+	s.state = state
+	s.params, s.inter, s.osc = s.params[:0], s.inter[:0], s.osc[:0]
+}
+
+func (s *screen) control(b byte) {
+	switch b {
+	case 0x08: // BS
+		s.wrapNext = false
+		if s.x > 0 {
+			s.x--
+		}
+	case 0x09: // HT
+		s.tab(1)
+	case 0x0a, 0x0b, 0x0c: // LF, VT, FF
+		s.index()
+	case 0x0d: // CR
+		s.x, s.wrapNext = 0, false
+	case 0x0e: // SO
+		s.shift = 1
+	case 0x0f: // SI
+		s.shift = 0
+	}
+}
+
+// dec is the DEC special graphics set, drawn with box drawing characters.
+var dec = map[rune]rune{
+	'`': '◆', 'a': '▒', 'f': '°', 'g': '±', 'j': '┘', 'k': '┐', 'l': '┌',
+	'm': '└', 'n': '┼', 'o': '⎺', 'p': '⎻', 'q': '─', 'r': '⎼', 's': '⎽',
+	't': '├', 'u': '┤', 'v': '┴', 'w': '┬', 'x': '│', 'y': '≤', 'z': '≥',
+	'{': 'π', '|': '≠', '}': '£', '~': '·',
+}
+
+func (s *screen) print(r rune) {
+	if s.graphics[s.shift] {
+		if g, ok := dec[r]; ok {
+			r = g
+		}
+	}
+	w := runewidth.RuneWidth(r)
+	if w == 0 {
+		return // combining marks are dropped
+	}
+	if s.wrapNext && s.autowrap {
+		s.x = 0
+		s.index()
+	}
+	s.wrapNext = false
+	if w == 2 && s.x == s.w-1 {
+		if !s.autowrap || s.w < 2 {
+			return
+		}
+		s.grid[s.y][s.x] = cell{width: 1, st: style{bg: s.st.bg}}
+		s.x = 0
+		s.index()
+	}
+	row := s.grid[s.y]
+	if s.insert {
+		s.insertCells(s.x, w)
+	}
+	s.clearWide(s.y, s.x)
+	if w == 2 {
+		s.clearWide(s.y, s.x+1)
+		row[s.x+1] = cell{width: 0, st: s.st}
+	}
+	row[s.x] = cell{r: r, width: int8(w), st: s.st}
+	s.last = r
+	if s.x+w >= s.w {
+		s.x = s.w - 1
+		s.wrapNext = true
+	} else {
+		s.x += w
+	}
+}
+
+// clearWide blanks the other half of a wide character about to be
+// partly overwritten at x.
+func (s *screen) clearWide(y, x int) {
+	row := s.grid[y]
+	if row[x].width == 0 && x > 0 {
+		row[x-1] = cell{width: 1, st: row[x-1].st}
+	}
+	if row[x].width == 2 && x+1 < s.w {
+		row[x+1] = cell{width: 1, st: row[x+1].st}
+	}
+}
+
+// insertCells shifts the cursor line right from x by n blank cells.
+// A wide character pushed half off the end is blanked.
+func (s *screen) insertCells(x, n int) {
+	row := s.grid[s.y]
+	if row[x].width == 0 {
+		s.erase(s.y, x, x+1) // splits a wide character
+	}
+	copy(row[x+n:], row[x:])
+	if last := row[s.w-1]; last.width == 2 {
+		row[s.w-1] = cell{width: 1, st: last.st}
+	}
+	s.blank(s.y, x, x+n)
+}
+
+// deleteCells removes n cells from x, shifting the rest of the cursor
+// line left and filling in blanks.
+func (s *screen) deleteCells(x, n int) {
+	row := s.grid[s.y]
+	if x+n < s.w && row[x+n].width == 0 {
+		s.erase(s.y, x+n, x+n+1) // the second half would stay
+	}
+	s.clearWide(s.y, x)
+	copy(row[x:], row[x+n:])
+	s.blank(s.y, s.w-n, s.w)
+}
+
+// index moves the cursor down, scrolling at the bottom of the region.
+func (s *screen) index() {
+	switch {
+	case s.y == s.bot:
+		s.scrollUp(1)
+	case s.y < s.h-1:
+		s.y++
+	}
+}
+
+func (s *screen) reverseIndex() {
+	switch {
+	case s.y == s.top:
+		s.scrollDown(1)
+	case s.y > 0:
+		s.y--
+	}
+}
+
+// scrollUp moves the scroll region up n lines. Lines leaving the top of
+// the whole main screen go to the scrollback.
+func (s *screen) scrollUp(n int) {
+	n = min(n, s.bot-s.top+1)
+	if !s.alt && s.top == 0 {
+		s.scrollback = append(s.scrollback, s.grid[:n]...)
+		s.trimScrollback()
+	}
+	region := s.grid[s.top : s.bot+1]
+	copy(region, region[n:])
+	for i := len(region) - n; i < len(region); i++ {
+		region[i] = s.blankLine()
+	}
+}
+
+// trimScrollback drops the oldest lines beyond maxBack, a quarter at a
+// time so that a scrolling program does not copy the scrollback per line.
+func (s *screen) trimScrollback() {
+	if len(s.scrollback) > s.maxBack+s.maxBack/4 {
+		s.scrollback = append([]line(nil), s.scrollback[len(s.scrollback)-s.maxBack:]...)
+	}
+}
+
+func (s *screen) scrollDown(n int) {
+	n = min(n, s.bot-s.top+1)
+	region := s.grid[s.top : s.bot+1]
+	copy(region[n:], region)
+	for i := 0; i < n; i++ {
+		region[i] = s.blankLine()
+	}
+}
+
+func (s *screen) tab(n int) {
+	for ; n > 0 && s.x < s.w-1; n-- {
+		s.x++
+		for s.x < s.w-1 && !s.tabs[s.x] {
+			s.x++
+		}
+	}
+	for ; n < 0 && s.x > 0; n++ {
+		s.x--
+		for s.x > 0 && !s.tabs[s.x] {
+			s.x--
+		}
+	}
+}
+
+// moveTo places the cursor, relative to the scroll region in origin mode.
+func (s *screen) moveTo(x, y int) {
+	minY, maxY := 0, s.h-1
+	if s.origin {
+		y += s.top
+		minY, maxY = s.top, s.bot
+	}
+	s.x = max(min(x, s.w-1), 0)
+	s.y = max(min(y, maxY), minY)
+	s.wrapNext = false
+}
+
+func (s *screen) escape(b byte) {
+	switch b {
+	case '7':
+		s.saveCursor()
+	case '8':
+		s.restoreCursor()
+	case 'D':
+		s.index()
+	case 'E':
+		s.x = 0
+		s.index()
+	case 'M':
+		s.reverseIndex()
+	case 'H':
+		s.tabs[s.x] = true
+	case 'c':
+		s.reset()
+	}
+}
+
+func (s *screen) escInter(inter, b byte) {
+	switch inter {
+	case '(', ')':
+		s.graphics[inter-'('] = b == '0'
+	case '#':
+		if b == '8' { // DECALN fills the screen with E
+			for _, row := range s.grid {
+				for i := range row {
+					row[i] = cell{r: 'E', width: 1}
+				}
+			}
+		}
+	}
+}
+
+func (s *screen) saveCursor() {
+	s.saved[s.altIndex()] = cursor{x: s.x, y: s.y, st: s.st, origin: s.origin, graphics: s.graphics, shift: s.shift}
+}
+
+func (s *screen) restoreCursor() {
+	c := s.saved[s.altIndex()]
+	s.st, s.origin, s.graphics, s.shift = c.st, c.origin, c.graphics, c.shift
+	s.x, s.y, s.wrapNext = min(c.x, s.w-1), min(c.y, s.h-1), false
+}
+
+func (s *screen) altIndex() int {
+	if s.alt {
+		return 1
+	}
+	return 0
+}
+
+// csiParams splits CSI parameters. Colon separated sub-parameters stay
+// together in one group; missing values are -1.
+func (s *screen) csiParams() (private byte, groups [][]int) {
+	p := s.params
+	if len(p) > 0 && p[0] >= '<' && p[0] <= '?' {
+		private, p = p[0], p[1:]
+	}
+	if len(p) == 0 {
+		return private, nil
+	}
+	for _, g := range strings.Split(string(p), ";") {
+		var group []int
+		for _, v := range strings.Split(g, ":") {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				n = -1
+			}
+			group = append(group, min(n, 65535))
+		}
+		groups = append(groups, group)
+	}
+	return private, groups
+}
+
+func (s *screen) csi(final byte) {
+	private, groups := s.csiParams()
+	// arg is parameter i, or def when it is missing or 0
+	arg := func(i, def int) int {
+		if i < len(groups) && groups[i][0] > 0 {
+			return groups[i][0]
+		}
+		return def
+	}
+	if len(s.inter) > 0 {
+		return // DECSCUSR, DECSTR and friends: nothing to draw
+	}
+	if private == '?' {
+		switch final {
+		case 'h', 'l':
+			for _, g := range groups {
+				s.decMode(g[0], final == 'h')
+			}
+		}
+		return
+	}
+	if private == '>' {
+		if final == 'c' && s.reply != nil {
+			s.reply([]byte("\x1b[>0;10;0c"))
+		}
+		return
+	}
+	if private != 0 {
+		return
+	}
+
+	switch final {
+	case '@': // ICH
+		s.insertCells(s.x, min(arg(0, 1), s.w-s.x))
+	case 'A':
+		s.moveTo(s.x, s.y-arg(0, 1)-s.originTop())
+	case 'B', 'e':
+		s.moveTo(s.x, s.y+arg(0, 1)-s.originTop())
+	case 'C', 'a':
+		s.moveTo(s.x+arg(0, 1), s.y-s.originTop())
+	case 'D':
+		s.moveTo(s.x-arg(0, 1), s.y-s.originTop())
+	case 'E':
+		s.moveTo(0, s.y+arg(0, 1)-s.originTop())
+	case 'F':
+		s.moveTo(0, s.y-arg(0, 1)-s.originTop())
+	case 'G', '`':
+		s.moveTo(arg(0, 1)-1, s.y-s.originTop())
+	case 'H', 'f':
+		s.moveTo(arg(1, 1)-1, arg(0, 1)-1)
+	case 'I':
+		s.tab(arg(0, 1))
+	case 'Z':
+		s.tab(-arg(0, 1))
+	case 'J':
+		s.eraseDisplay(arg(0, 0))
+	case 'K':
+		switch arg(0, 0) {
+		case 0:
+			s.erase(s.y, s.x, s.w)
+		case 1:
+			s.erase(s.y, 0, s.x+1)
+		case 2:
+			s.erase(s.y, 0, s.w)
+		}
+	case 'L': // IL
+		if s.y >= s.top && s.y <= s.bot {
+			top := s.top
+			s.top = s.y
+			s.scrollDown(arg(0, 1))
+			s.top = top
+			s.x = 0
+		}
+	case 'M': // DL
+		if s.y >= s.top && s.y <= s.bot {
+			top := s.top
+			s.top = s.y
+			alt := s.alt
+			s.alt = true // deleted lines are not scrollback
+			s.scrollUp(arg(0, 1))
+			s.top, s.alt = top, alt
+			s.x = 0
+		}
+	case 'P': // DCH
+		s.deleteCells(s.x, min(arg(0, 1), s.w-s.x))
+	case 'S':
+		s.scrollUp(arg(0, 1))
+	case 'T':
+		s.scrollDown(arg(0, 1))
+	case 'X': // ECH
+		s.erase(s.y, s.x, min(s.x+arg(0, 1), s.w))
+	case 'b': // REP
+		if s.last != 0 {
+			for range min(arg(0, 1), s.w*s.h) {
+				s.print(s.last)
+			}
+		}
+	case 'c':
+		if s.reply != nil {
+			s.reply([]byte("\x1b[?62;22c"))
+		}
+	case 'd':
+		s.moveTo(s.x, arg(0, 1)-1)
+	case 'g':
+		switch arg(0, 0) {
+		case 0:
+			s.tabs[s.x] = false
+		case 3:
+			s.tabs = make([]bool, s.w)
+		}
+	case 'h', 'l':
+		if arg(0, 0) == 4 {
+			s.insert = final == 'h'
+		}
+	case 'm':
+		s.sgr(groups)
+	case 'n':
+		if s.reply == nil {
+			return
+		}
+		switch arg(0, 0) {
+		case 5:
+			s.reply([]byte("\x1b[0n"))
+		case 6:
+			s.reply([]byte(fmt.Sprintf("\x1b[%d;%dR", s.y-s.originTop()+1, s.x+1)))
+		}
+	case 'r': // DECSTBM
+		top, bot := arg(0, 1)-1, arg(1, s.h)-1
+		if bot >= s.h {
+			bot = s.h - 1
+		}
+		if top < bot {
+			s.top, s.bot = top, bot
+			s.moveTo(0, 0)
+		}
+	case 's':
+		s.saveCursor()
+	case 'u':
+		s.restoreCursor()
+	}
+}
+
+func (s *screen) originTop() int {
+	if s.origin {
+		return s.top
+	}
+	return 0
+}
+
+func (s *screen) decMode(mode int, on bool) {
+	switch mode {
+	case 1:
+		s.appCursor = on
+	case 6:
+		s.origin = on
+		s.moveTo(0, 0)
+	case 7:
+		s.autowrap = on
+	case 25:
+		s.cursorVisible = on
+	case 47, 1047:
+		s.setAlt(on, false)
+	case 1048:
+		if on {
+			s.saveCursor()
+		} else {
+			s.restoreCursor()
+		}
+	case 1049:
+		if on {
+			s.saveCursor()
+			s.setAlt(true, true)
+		} else {
+			s.setAlt(false, false)
+			s.restoreCursor()
+		}
+	case 2004:
+		s.bracketPaste = on
+	}
+}
+
+// setAlt switches between the main and alternate screens.
+func (s *screen) setAlt(on, clear bool) {
+	if on != s.alt {
+		s.grid, s.other = s.other, s.grid
+		s.alt = on
+	}
+	if on && clear {
+		s.grid = s.blankLines(s.h)
+	}
+	s.top, s.bot = 0, s.h-1
+}
+
+func (s *screen) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		s.erase(s.y, s.x, s.w)
+		for y := s.y + 1; y < s.h; y++ {
+			s.erase(y, 0, s.w)
+		}
+	case 1:
+		for y := 0; y < s.y; y++ {
+			s.erase(y, 0, s.w)
+		}
+		s.erase(s.y, 0, s.x+1)
+	case 2:
+		for y := 0; y < s.h; y++ {
+			s.erase(y, 0, s.w)
+		}
+	case 3:
+		s.scrollback = nil
+	}
+}
+
+// erase blanks cells [from, to) of row y with the current background,
+// and the other halves of wide characters cut at either end.
+func (s *screen) erase(y, from, to int) {
+	from, to = max(from, 0), min(to, s.w)
+	if from < to {
+		s.clearWide(y, from)
+		s.clearWide(y, to-1)
+	}
+	s.blank(y, from, to)
+}
+
+// blank is erase without the care for wide characters, for cells that
+// were just copied elsewhere.
+func (s *screen) blank(y, from, to int) {
+	row := s.grid[y]
+	for x := max(from, 0); x < min(to, s.w); x++ {
+		row[x] = cell{width: 1, st: style{bg: s.st.bg}}
+	}
+	s.wrapNext = false
+}
+
+func (s *screen) sgr(groups [][]int) {
+	if len(groups) == 0 {
+		s.st = style{}
+		return
+	}
+	for i := 0; i < len(groups); i++ {
+		g := groups[i]
+		switch p := g[0]; {
+		case p <= 0:
+			s.st = style{}
+		case p == 1:
+			s.st.attr |= attrBold
+		case p == 2:
+			s.st.attr |= attrDim
+		case p == 3:
+			s.st.attr |= attrItalic
+		case p == 4:
+			if len(g) > 1 && g[1] == 0 {
+				s.st.attr &^= attrUnderline // 4:0, no underline
+			} else {
+				s.st.attr |= attrUnderline
+			}
+		case p == 5 || p == 6:
+			s.st.attr |= attrBlink
+		case p == 7:
+			s.st.attr |= attrReverse
+		case p == 8:
+			s.st.attr |= attrHidden
+		case p == 9:
+			s.st.attr |= attrStrike
+		case p == 21 || p == 22:
+			s.st.attr &^= attrBold | attrDim
+		case p == 23:
+			s.st.attr &^= attrItalic
+		case p == 24:
+			s.st.attr &^= attrUnderline
+		case p == 25:
+			s.st.attr &^= attrBlink
+		case p == 27:
+			s.st.attr &^= attrReverse
+		case p == 28:
+			s.st.attr &^= attrHidden
+		case p == 29:
+			s.st.attr &^= attrStrike
+		case p >= 30 && p <= 37:
+			s.st.fg = color{colorIndexed, uint32(p - 30)}
+		case p == 38 || p == 48:
+			var c color
+			c, i = extColor(groups, i)
+			if p == 38 {
+				s.st.fg = c
+			} else {
+				s.st.bg = c
+			}
+		case p == 39:
+			s.st.fg = color{}
+		case p >= 40 && p <= 47:
+			s.st.bg = color{colorIndexed, uint32(p - 40)}
+		case p == 49:
+			s.st.bg = color{}
+		case p >= 90 && p <= 97:
+			s.st.fg = color{colorIndexed, uint32(p - 90 + 8)}
+		case p >= 100 && p <= 107:
+			s.st.bg = color{colorIndexed, uint32(p - 100 + 8)}
+		}
+	}
+}
+
+// extColor reads a 38/48 colour at groups[i], either as colon
+// sub-parameters (38:5:n, 38:2::r:g:b) or as the following parameters
+// (38;5;n, 38;2;r;g;b). It returns the index of the last group used.
+func extColor(groups [][]int, i int) (color, int) {
+	var vals []int
+	if g := groups[i]; len(g) > 1 {
+		vals = g[1:]
+		if len(vals) == 5 && vals[0] == 2 {
+			vals = append(vals[:1], vals[2:]...) // colour space id
+		}
+	} else {
+		for _, g := range groups[i+1:] {
+			vals = append(vals, g[0])
+		}
+	}
+	clamp := func(v int) uint32 { return uint32(max(min(v, 255), 0)) }
+	switch {
+	case len(vals) >= 2 && vals[0] == 5:
+		if len(groups[i]) == 1 {
+			i += 2
+		}
+		return color{colorIndexed, clamp(vals[1])}, i
+	case len(vals) >= 4 && vals[0] == 2:
+		if len(groups[i]) == 1 {
+			i += 4
+		}
+		return color{colorRGB, clamp(vals[1])<<16 | clamp(vals[2])<<8 | clamp(vals[3])}, i
+	}
+	return color{}, len(groups) // malformed, ignore the rest
+}
+
+func (s *screen) oscDone() {
+	cmd, text, ok := strings.Cut(string(s.osc), ";")
+	if ok && (cmd == "0" || cmd == "2") {
+		s.title = text
+	}
+}
+
+// resize changes the grid size. Lines the cursor would fall off the
+// bottom with go to the scrollback, as in xterm.
+func (s *screen) resize(w, h int) {
+	w, h = max(w, 1), max(h, 1)
+	if w == s.w && h == s.h {
+		return
+	}
+	for i, grid := range [][]line{s.grid, s.other} {
+		main := (i == 0) != s.alt
+		cursorY := s.y
+		if i == 1 {
+			cursorY = 0
+		}
+		if drop := cursorY - (h - 1); drop > 0 {
+			if main {
+				s.scrollback = append(s.scrollback, grid[:drop]...)
+			}
+			grid = grid[drop:]
+			if i == 0 {
+				s.y -= drop
+			}
+		}
+		for len(grid) < h {
+			grid = append(grid, nil)
+		}
+		grid = grid[:h]
+		for y, row := range grid {
+			grid[y] = resizeLine(row, w)
+		}
+		if i == 0 {
+			s.grid = grid
+		} else {
+			s.other = grid
+		}
+	}
+	for y, row := range s.scrollback {
+		s.scrollback[y] = resizeLine(row, w)
+	}
+	s.trimScrollback()
+	s.w, s.h = w, h
+	s.top, s.bot = 0, h-1
+	s.x, s.y = min(s.x, w-1), min(s.y, h-1)
+	s.wrapNext = false
+	s.resetTabs()
+}
+
+func resizeLine(l line, w int) line {
+	if len(l) >= w {
+		l = l[:w]
+		if w > 0 && l[w-1].width == 2 {
+			l[w-1] = cell{width: 1, st: l[w-1].st} // half a wide character
+		}
+		return l
+	}
+	out := make(line, w)
+	copy(out, l)
+	for x := len(l); x < w; x++ {
+		out[x] = cell{width: 1}
+	}
+	return out
+}
+
+// render draws the screen scrolled up by scroll lines, with the cursor
+// when showCursor is set and the view is at the bottom.
+func (s *screen) render(scroll int, showCursor bool) []string {
+	scroll = max(min(scroll, len(s.scrollback)), 0)
+	rows := make([]line, 0, s.h)
+	if scroll > 0 {
+		rows = append(rows, s.scrollback[len(s.scrollback)-scroll:]...)
+	}
+	rows = append(rows, s.grid...)
+	rows = rows[:s.h]
+
+	out := make([]string, len(rows))
+	for y, row := range rows {
+		cx := -1
+		if showCursor && scroll == 0 && s.cursorVisible && y == s.y {
+			cx = s.x
+		}
+		out[y] = renderLine(row, cx)
+	}
+	return out
+}
+
+func renderLine(row line, cursorX int) string {
+	var b strings.Builder
+	cur := style{}
+	for x, c := range row {
+		if c.width == 0 {
+			continue
+		}
+		st := c.st
+		if x == cursorX {
+			st.attr ^= attrReverse
+		}
+		if st != cur {
+			b.WriteString(sgrOf(st))
+			cur = st
+		}
+		switch {
+		case c.r == 0 || st.attr&attrHidden != 0:
+			b.WriteString(strings.Repeat(" ", int(c.width)))
+		default:
+			b.WriteRune(c.r)
+		}
+	}
+	if cur != (style{}) {
+		b.WriteString("\x1b[0m")
+	}
+	return b.String()
+}
+
+// sgrOf is the sequence that sets st from scratch.
+func sgrOf(st style) string {
+	parts := []string{"0"}
+	for i, code := range []string{"1", "2", "3", "4", "5", "7", "", "9"} {
+		if code != "" && st.attr&(1<<i) != 0 {
+			parts = append(parts, code)
+		}
+	}
+	parts = appendColor(parts, st.fg, 30, 90, "38")
+	parts = appendColor(parts, st.bg, 40, 100, "48")
+	return "\x1b[" + strings.Join(parts, ";") + "m"
+}
+
+func appendColor(parts []string, c color, base, bright int, ext string) []string {
+	switch {
+	case c.kind == colorIndexed && c.v < 8:
+		return append(parts, strconv.Itoa(base+int(c.v)))
+	case c.kind == colorIndexed && c.v < 16:
+		return append(parts, strconv.Itoa(bright+int(c.v)-8))
+	case c.kind == colorIndexed:
+		return append(parts, ext, "5", strconv.Itoa(int(c.v)))
+	case c.kind == colorRGB:
+		return append(parts, ext, "2", strconv.Itoa(int(c.v>>16&0xff)), strconv.Itoa(int(c.v>>8&0xff)), strconv.Itoa(int(c.v&0xff)))
+	}
+	return parts
+}
@@ -0,0 +1,190 @@
+package terminal
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/mattn/go-runewidth"
+)
+
+func TestScreen(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		w, h  int
+		input string
+		want  []string
+	}{
+		{"text", 4, 2, "ab\r\ncd", []string{"ab  ", "cd  "}},
+		{"autowrap", 4, 2, "abcde", []string{"abcd", "e   "}},
+		{"no autowrap", 4, 1, "\x1b[?7labcdef", []string{"abcf"}},
+		{"scrolls", 3, 2, "a\r\nb\r\nc", []string{"b  ", "c  "}},
+		{"tab", 10, 1, "a\tb", []string{"a       b "}},
+		{"backspace", 3, 1, "ab\bc", []string{"ac "}},
+
+		{"cup", 5, 3, "\x1b[2;3HX\x1b[HY\x1b[CZ", []string{"Y Z  ", "  X  ", "     "}},
+		{"relative moves", 4, 3, "ab\x1b[2Dc\x1b[2B\x1b[Cd\x1b[Ae", []string{"cb  ", "   e", "  d "}},
+		{"moves stop at the edges", 3, 2, "\x1b[9A\x1b[9DX\x1b[9B\x1b[9CY", []string{"X  ", "  Y"}},
+		{"column and row", 4, 2, "\x1b[3GX\x1b[2dY", []string{"  X ", "   Y"}},
+		{"save and restore", 4, 2, "a\x1b7\x1b[2;4Hb\x1b8c", []string{"ac  ", "   b"}},
+
+		{"erase to end of line", 4, 1, "abcd\x1b[3G\x1b[K", []string{"ab  "}},
+		{"erase to start of line", 4, 1, "abcd\x1b[3G\x1b[1K", []string{"   d"}},
+		{"erase below", 3, 3, "abc\r\ndef\r\nghi\x1b[2;2H\x1b[J", []string{"abc", "d  ", "   "}},
+		{"insert and delete chars", 5, 1, "abcd\x1b[2G\x1b[2@X\x1b[4G\x1b[P", []string{"aX c "}},
+		{"insert and delete lines", 2, 3, "a\r\nb\r\nc\x1b[2H\x1b[L\x1b[M\x1b[3H\x1b[L", []string{"a ", "b ", "  "}},
+		{"repeat", 5, 1, "x\x1b[3b", []string{"xxxx "}},
+		{"dec graphics", 3, 1, "\x1b(0lqk\x1b(B", []string{"┌─┐"}},
+
+		{"scroll region", 3, 4, "1\r\n2\r\n3\r\n4\x1b[2;3r\x1b[3H\nX", []string{"1  ", "3  ", "X  ", "4  "}},
+		{"reverse index in region", 3, 4, "1\r\n2\r\n3\r\n4\x1b[2;3r\x1b[2H\x1bMX", []string{"1  ", "X  ", "2  ", "4  "}},
+		{"origin mode", 3, 4, "\x1b[2;3r\x1b[?6h\x1b[9HX", []string{"   ", "   ", "X  ", "   "}},
+		{"scroll up and down", 2, 3, "a\r\nb\r\nc\x1b[S\x1b[2T", []string{"  ", "  ", "b "}},
+
+		{"alt screen", 6, 2, "main\x1b[?1049h\x1b[Halt", []string{"alt   ", "      "}},
+		{"alt screen left", 6, 2, "main\x1b[?1049h\x1b[Halt\x1b[?1049lX", []string{"mainX ", "      "}},
+		{"alt screen keeps no scrollback", 2, 2, "\x1b[?1049ha\r\nb\r\nc\x1b[?1049l", []string{"  ", "  "}},
+
+		{"wide", 5, 1, "a世b", []string{"a世b "}},
+		{"wide wraps at the last column", 3, 2, "ab世", []string{"ab ", "世 "}},
+		{"overwrite first half", 3, 1, "世\x1b[1GX", []string{"X  "}},
+		{"overwrite second half", 3, 1, "世\x1b[2GX", []string{" X "}},
+		{"insert before wide", 4, 1, "世\x1b[1G\x1b[@", []string{" 世 "}},
+		{"insert inside wide", 4, 1, "世\x1b[2G\x1b[@", []string{"    "}},
+		{"insert pushes wide off", 3, 1, "a世\x1b[1G\x1b[@", []string{" a "}},
+		{"delete wide", 4, 1, "a世b\x1b[2G\x1b[P", []string{"a b "}},
+		{"insert mode", 4, 1, "世b\x1b[1G\x1b[4hx", []string{"x世b"}},
+		{"combining marks dropped", 3, 1, "e\u0301x", []string{"ex "}},
+		{"split utf-8", 2, 1, "\xe4\xb8", []string{"  "}},
+
+		{"sgr", 2, 1, "\x1b[1;31mA\x1b[0mB", []string{"\x1b[0;1;31mA\x1b[0mB"}},
+		{"sgr bright and 256", 2, 1, "\x1b[91;48;5;200mA", []string{"\x1b[0;91;48;5;200mA\x1b[0m "}},
+		{"sgr rgb", 1, 1, "\x1b[38;2;1;2;3mA", []string{"\x1b[0;38;2;1;2;3mA\x1b[0m"}},
+		{"sgr reset by empty", 2, 1, "\x1b[7mA\x1b[mB", []string{"\x1b[0;7mA\x1b[0mB"}},
+		{"hidden", 2, 1, "\x1b[8mA", []string{"\x1b[0m \x1b[0m "}},
+		{"erase keeps the background", 2, 1, "\x1b[44m\x1b[K", []string{"\x1b[0;44m  \x1b[0m"}},
+
+		{"osc title ignored", 3, 1, "\x1b]0;title\x07ab", []string{"ab "}},
+		{"dcs ignored", 3, 1, "\x1bPq#0\x1b\\ab", []string{"ab "}},
+		{"can aborts", 3, 1, "\x1b[3\x18ab", []string{"ab "}},
+		{"reset", 3, 1, "\x1b[1mab\x1bc", []string{"   "}},
+	} {
+		s := newScreen(c.w, c.h, 100, nil)
+		s.Write([]byte(c.input))
+		if got := s.render(0, false); !slices.Equal(got, c.want) {
+			t.Errorf("%s:\n got %q\nwant %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestScreenTitle(t *testing.T) {
+	s := newScreen(3, 1, 0, nil)
+	s.Write([]byte("\x1b]2;vim\x1b\\"))
+	if s.title != "vim" {
+		t.Errorf("title %q", s.title)
+	}
+}
+
+func TestScreenCursor(t *testing.T) {
+	s := newScreen(3, 1, 0, nil)
+	s.Write([]byte("ab"))
+	if got, want := s.render(0, true)[0], "ab\x1b[0;7m \x1b[0m"; got != want {
+		t.Errorf("cursor %q, want %q", got, want)
+	}
+	s.Write([]byte("\x1b[?25l"))
+	if got := s.render(0, true)[0]; got != "ab " {
+		t.Errorf("hidden cursor %q", got)
+	}
+}
+
+func TestScreenReplies(t *testing.T) {
+	var replies []string
+	s := newScreen(5, 3, 0, func(b []byte) { replies = append(replies, string(b)) })
+	s.Write([]byte("\x1b[2;4H\x1b[6n\x1b[5n\x1b[c"))
+	if want := []string{"\x1b[2;4R", "\x1b[0n", "\x1b[?62;22c"}; !slices.Equal(replies, want) {
+		t.Errorf("replies %q, want %q", replies, want)
+	}
+}
+
+func TestScrollback(t *testing.T) {
+	s := newScreen(2, 2, 3, nil)
+	s.Write([]byte("1\r\n2\r\n3\r\n4"))
+	if got, want := s.render(2, false), []string{"1 ", "2 "}; !slices.Equal(got, want) {
+		t.Errorf("scrolled up 2: %q, want %q", got, want)
+	}
+	if got, want := s.render(9, false), []string{"1 ", "2 "}; !slices.Equal(got, want) {
+		t.Errorf("scrolled past the top: %q, want %q", got, want)
+	}
+	// trimmed a quarter at a time past maxBack
+	s.Write([]byte("\r\n5\r\n6\r\n7\r\n8"))
+	if len(s.scrollback) > 3+3/4 {
+		t.Errorf("%d lines of scrollback, max 3", len(s.scrollback))
+	}
+	s.Write([]byte("\x1b[3J"))
+	if len(s.scrollback) != 0 {
+		t.Error("ED 3 kept the scrollback")
+	}
+}
+
+func TestScreenResize(t *testing.T) {
+	s := newScreen(4, 3, 100, nil)
+	s.Write([]byte("a\r\nb\r\nc世"))
+
+	// the cursor's line stays on screen, the lines above it scroll back
+	s.resize(2, 2)
+	if got, want := s.render(0, false), []string{"b ", "c "}; !slices.Equal(got, want) {
+		t.Errorf("shrunk: %q, want %q", got, want)
+	}
+	if got := s.render(1, false)[0]; got != "a " {
+		t.Errorf("scrollback after shrinking: %q", got)
+	}
+	if s.x != 1 || s.y != 1 {
+		t.Errorf("cursor at %d,%d", s.x, s.y)
+	}
+
+	s.resize(3, 3)
+	if got, want := s.render(0, false), []string{"b  ", "c  ", "   "}; !slices.Equal(got, want) {
+		t.Errorf("grown: %q, want %q", got, want)
+	}
+	s.Write([]byte("\x1b[3;1Hxyz\r\nw"))
+	if got, want := s.render(0, false), []string{"c  ", "xyz", "w  "}; !slices.Equal(got, want) {
+		t.Errorf("after writing: %q, want %q", got, want)
+	}
+}
+
+// FuzzScreen feeds arbitrary output, resizing in between, and checks that
+// the screen neither panics nor renders lines of the wrong size.
+func FuzzScreen(f *testing.F) {
+	for _, seed := range []string{
+		"hello\r\nworld",
+		"\x1b[2;3r\x1b[?6h\x1b[9;9H\x1b[L\x1b[M\x1b[S\x1b[T",
+		"\x1b[?1049h世界\x1b[1;2H\x1b[@\x1b[P\x1b[?1049l",
+		"\x1b[38:2::1:2:3m\x1b[48;5;300m\x1b[1;2;3;4;5;7;8;9mx\x1b[m",
+		"\x1b]0;t\x1b\\\x1bP\x1b\\\x1b(0qqq\x1b#8",
+		"\x1b[99999b\x1b[0;0r\x1b[5;2r\x1bM\x1bD\x1bE",
+	} {
+		f.Add([]byte(seed), uint8(10), uint8(4))
+	}
+	f.Fuzz(func(t *testing.T, data []byte, w, h uint8) {
+		s := newScreen(int(w%40)+1, int(h%20)+1, 10, func([]byte) {})
+		half := len(data) / 2
+		s.Write(data[:half])
+		s.resize(int(h%40)+1, int(w%20)+1)
+		s.Write(data[half:])
+		for _, scroll := range []int{0, 5} {
+			lines := s.render(scroll, true)
+			if len(lines) != s.h {
+				t.Fatalf("%d lines, want %d", len(lines), s.h)
+			}
+			for y, l := range lines {
+				width := 0
+				for _, r := range ansi.Strip(l) {
+					width += runewidth.RuneWidth(r)
+				}
+				if width != s.w {
+					t.Fatalf("line %d is %d wide, want %d: %q", y, width, s.w, l)
+				}
+			}
+		}
+	})
+}
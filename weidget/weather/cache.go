@@ -0,0 +1,71 @@
+package weather
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/antiloger/termctlr/config"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// cacheFile is the last report, kept across restarts so the widget has
+// something to show at once and while offline. It is for one provider,
+// place and forecast length; another key is not used.
+type cacheFile struct {
+	Key    string  `json:"key"`
+	Report *Report `json:"report"`
+}
+
+// cacheKey identifies what a report was fetched for.
+func cacheKey(p Provider, loc Location, days int) string {
+	if f, ok := p.(FileProvider); ok {
+		return fmt.Sprintf("file %s %d", f.Path, days)
+	}
+	return fmt.Sprintf("%s %.4f,%.4f %d", p.Name(), loc.Latitude, loc.Longitude, days)
+}
+
+type cacheLoadedMsg struct {
+	report *Report
+	err    error
+}
+
+type cacheSavedMsg struct {
+	err error
+}
+
+// loadCache reads the cached report for key; a missing or other file
+// gives none.
+func loadCache(path, key string) tea.Cmd {
+	return func() tea.Msg {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return cacheLoadedMsg{}
+		}
+		if err != nil {
+			return cacheLoadedMsg{err: err}
+		}
+		var c cacheFile
+		if err := json.Unmarshal(data, &c); err != nil {
+			return cacheLoadedMsg{err: fmt.Errorf("weather cache: %w", err)}
+		}
+		if c.Key != key {
+			return cacheLoadedMsg{}
+		}
+		return cacheLoadedMsg{report: c.Report}
+	}
+}
+
+// saveCache writes the report off the UI goroutine, replacing the file
+// atomically.
+func saveCache(path, key string, r *Report) tea.Cmd {
+	return func() tea.Msg {
+		data, err := json.MarshalIndent(cacheFile{Key: key, Report: r}, "", "  ")
+		if err != nil {
+			return cacheSavedMsg{err}
+		}
+		return cacheSavedMsg{config.WriteState(path, data)}
+	}
+}
@@ -0,0 +1,80 @@
+package weather
+
+import "github.com/antiloger/termctlr/theme"
+
+// Icons are drawn with the same heavy box-drawing strokes as the clock's
+// digits, 6 cells by 3 rows.
+var icons = map[Sky][3]string{
+	Clear: {
+		" ╲╻╱  ",
+		"╺━╋━╸ ",
+		" ╱╹╲  ",
+	},
+	PartlyCloudy: {
+		"╲╻┏━┓ ",
+		"━╋┛ ┗┓",
+		" ┗━━━┛",
+	},
+	Cloudy: {
+		" ┏━━┓ ",
+		"┏┛  ┗┓",
+		"┗━━━━┛",
+	},
+	Fog: {
+		"╺━━━━╸",
+		" ╺━━━╸",
+		"╺━━━╸ ",
+	},
+	Drizzle: {
+		"┏━━━━┓",
+		"┗━━━━┛",
+		" ╹ ╹ ╹",
+	},
+	Rain: {
+		"┏━━━━┓",
+		"┗━━━━┛",
+		"╱ ╱ ╱ ",
+	},
+	Snow: {
+		"┏━━━━┓",
+		"┗━━━━┛",
+		" ╳ ╳ ╳",
+	},
+	Thunder: {
+		"┏━━━━┓",
+		"┗━┳━━┛",
+		"  ┗┓  ",
+	},
+}
+
+// moon stands in for the sun at night.
+var moon = [3]string{
+	" ┏━╸  ",
+	" ┃    ",
+	" ┗━╸  ",
+}
+
+func icon(c Code, day bool) [3]string {
+	if c.Sky() == Clear && !day {
+		return moon
+	}
+	return icons[c.Sky()]
+}
+
+// skyRole colours an icon or a description: warm for sun, cool for rain.
+func skyRole(c Code, day bool) theme.Role {
+	switch c.Sky() {
+	case Clear, PartlyCloudy:
+		if day {
+			return theme.GaugeMed
+		}
+		return theme.Text
+	case Cloudy, Fog:
+		return theme.Muted
+	case Drizzle, Rain:
+		return theme.Accent
+	case Thunder:
+		return theme.Alert
+	}
+	return theme.Text
+}
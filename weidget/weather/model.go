@@ -0,0 +1,180 @@
+package weather
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/antiloger/termctlr/config"
+	"github.com/antiloger/termctlr/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxRetry is the longest wait before fetching again after a failure.
+const maxRetry = 5 * time.Minute
+
+// Units picks how values are shown; providers always report metric.
+type Units int
+
+const (
+	Metric Units = iota
+	Imperial
+)
+
+// Model is the weather widget. A report is fetched when the cached one is
+// older than the refresh interval; until then, and while fetching fails,
+// the cached one is shown.
+type Model struct {
+	provider  Provider // nil when not configured
+	loc       Location
+	place     string
+	units     Units
+	ttl       time.Duration
+	days      int
+	cachePath string
+	cacheKey  string
+	setupErr  error // the configuration cannot work
+
+	report   *Report
+	err      error // the last fetch failed; a report shown is stale
+	saveErr  error
+	fetching bool
+	nextAt   time.Time
+	now      time.Time
+
+	width, height int
+	pos           types.Position
+}
+
+func NewModel(cfg config.WeatherConfig) Model {
+	ttl, err := time.ParseDuration(cfg.Refresh)
+	if err != nil || ttl <= 0 {
+		ttl = 30 * time.Minute
+	}
+	days := cfg.Days
+	if days <= 0 {
+		days = 5
+	}
+	m := Model{
+		loc:       Location{Latitude: cfg.Latitude, Longitude: cfg.Longitude},
+		place:     cfg.Place,
+		ttl:       ttl,
+		days:      min(days, 16), // as far as Open-Meteo forecasts
+		cachePath: filepath.Join(config.StateDir(), "weather.json"),
+		now:       time.Now(),
+		width:     60,
+		height:    10,
+	}
+	switch cfg.Units {
+	case "", "metric":
+	case "imperial":
+		m.units = Imperial
+	default:
+		m.setupErr = fmt.Errorf("unknown units %q, want metric or imperial", cfg.Units)
+	}
+
+	switch cfg.Provider {
+	case "", "open-meteo":
+		if cfg.Latitude != 0 || cfg.Longitude != 0 {
+			m.provider = NewOpenMeteo(cfg.URL)
+		}
+	case "file":
+		if cfg.File != "" {
+			m.provider = FileProvider{Path: config.ExpandPath(cfg.File)}
+		}
+	default:
+		m.setupErr = fmt.Errorf("unknown provider %q, want open-meteo or file", cfg.Provider)
+	}
+	if m.provider != nil {
+		m.cacheKey = cacheKey(m.provider, m.loc, m.days)
+	}
+	if m.place == "" {
+		m.place = "weather"
+	}
+	return m
+}
+
+type fetchedMsg struct {
+	report *Report
+	err    error
+}
+
+func (m Model) Init() tea.Cmd {
+	if m.provider == nil || m.setupErr != nil {
+		return nil
+	}
+	return loadCache(m.cachePath, m.cacheKey)
+}
+
+func (m Model) fetch() (Model, tea.Cmd) {
+	m.fetching = true
+	p, loc, days := m.provider, m.loc, m.days
+	return m, func() tea.Msg {
+		r, err := p.Fetch(context.Background(), loc, days)
+		return fetchedMsg{report: r, err: err}
+	}
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case types.SizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+	case types.TickMsg:
+		m.now = time.Time(msg)
+		if m.provider == nil || m.setupErr != nil || m.fetching || m.nextAt.IsZero() || m.now.Before(m.nextAt) {
+			return m, nil
+		}
+		return m.fetch()
+	case cacheLoadedMsg:
+		// an unreadable cache is as good as none: the report is fetched
+		if msg.report != nil {
+			m.report = msg.report
+			if m.now.Sub(msg.report.Fetched) < m.ttl {
+				m.nextAt = msg.report.Fetched.Add(m.ttl)
+				return m, nil
+			}
+		}
+		return m.fetch()
+	case fetchedMsg:
+		m.fetching = false
+		if msg.err != nil {
+			m.err = msg.err
+			m.nextAt = m.now.Add(min(m.ttl, maxRetry))
+			return m, nil
+		}
+		msg.report.Fetched = time.Now()
+		m.report, m.err = msg.report, nil
+		m.nextAt = msg.report.Fetched.Add(m.ttl)
+		return m, saveCache(m.cachePath, m.cacheKey, msg.report)
+	case cacheSavedMsg:
+		m.saveErr = msg.err
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "r":
+			if m.provider != nil && m.setupErr == nil && !m.fetching {
+				return m.fetch()
+			}
+		case "u":
+			m.units = 1 - m.units
+		}
+	}
+	return m, nil
+}
+
+// stale reports a report shown only because a newer one could not be
+// fetched.
+func (m Model) stale() bool {
+	return m.report != nil && m.err != nil
+}
+
+func (m Model) SetPosition(x, y int) {
+	m.pos.X = x
+	m.pos.Y = y
+}
+
+func (m Model) Name() string {
+	return "weather"
+}
@@ -0,0 +1,173 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antiloger/termctlr/config"
+	"github.com/antiloger/termctlr/types"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// fakeProvider answers with the testdata report, or err.
+type fakeProvider struct {
+	err error
+}
+
+func (fakeProvider) Name() string { return "fake" }
+
+func (f fakeProvider) Fetch(ctx context.Context, loc Location, days int) (*Report, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return FileProvider{Path: "testdata/openmeteo.json"}.Fetch(ctx, loc, days)
+}
+
+// testModel is a widget on p with its cache in a temporary directory.
+func testModel(t *testing.T, p Provider) Model {
+	m := NewModel(config.WeatherConfig{Place: "Berlin", Latitude: 52.52, Longitude: 13.41, Refresh: "30m"})
+	m.provider = p
+	m.cacheKey = cacheKey(p, m.loc, m.days)
+	m.cachePath = filepath.Join(t.TempDir(), "weather.json")
+	return m
+}
+
+// update applies msg and runs the command it returns, if any, once.
+func update(m Model, msg tea.Msg) (Model, tea.Msg) {
+	next, cmd := m.Update(msg)
+	if cmd == nil {
+		return next.(Model), nil
+	}
+	return next.(Model), cmd()
+}
+
+func TestCacheKey(t *testing.T) {
+	berlin := Location{Latitude: 52.52, Longitude: 13.41}
+	om := NewOpenMeteo("")
+	key := cacheKey(om, berlin, 5)
+	if key != "open-meteo 52.5200,13.4100 5" {
+		t.Errorf("key = %q", key)
+	}
+	for name, other := range map[string]string{
+		"another place":  cacheKey(om, Location{Latitude: 48.14, Longitude: 11.58}, 5),
+		"more days":      cacheKey(om, berlin, 7),
+		"another source": cacheKey(fakeProvider{}, berlin, 5),
+	} {
+		if other == key {
+			t.Errorf("%s shares the key %q", name, key)
+		}
+	}
+	// a file is for one place, whatever the configured location
+	if a, b := cacheKey(FileProvider{Path: "/a.json"}, berlin, 5), cacheKey(FileProvider{Path: "/a.json"}, Location{}, 5); a != b {
+		t.Errorf("file keys %q and %q differ by location", a, b)
+	}
+}
+
+func TestCacheRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "weather.json")
+	r := &Report{Fetched: time.Date(2024, 6, 14, 13, 0, 0, 0, time.UTC), Daily: []Day{{Max: 20, PrecipProb: -1}}}
+	if msg := saveCache(path, "k", r)().(cacheSavedMsg); msg.err != nil {
+		t.Fatal(msg.err)
+	}
+	got := loadCache(path, "k")().(cacheLoadedMsg)
+	if got.err != nil || got.report == nil || !got.report.Fetched.Equal(r.Fetched) || got.report.Daily[0].PrecipProb != -1 {
+		t.Errorf("loaded %+v, %v", got.report, got.err)
+	}
+	if other := loadCache(path, "other")().(cacheLoadedMsg); other.report != nil || other.err != nil {
+		t.Errorf("another key loaded %+v, %v", other.report, other.err)
+	}
+	if none := loadCache(filepath.Join(t.TempDir(), "missing.json"), "k")().(cacheLoadedMsg); none.report != nil || none.err != nil {
+		t.Errorf("missing file loaded %+v, %v", none.report, none.err)
+	}
+	os.WriteFile(path, []byte("{"), 0o644)
+	if bad := loadCache(path, "k")().(cacheLoadedMsg); bad.err == nil {
+		t.Error("corrupt cache loaded without an error")
+	}
+}
+
+func TestFreshCacheIsNotFetched(t *testing.T) {
+	m := testModel(t, fakeProvider{err: errors.New("must not fetch")})
+	fetched := m.now.Add(-10 * time.Minute)
+	m, msg := update(m, cacheLoadedMsg{report: &Report{Fetched: fetched}})
+	if msg != nil || m.fetching {
+		t.Fatalf("a fresh cache was fetched again (%v)", msg)
+	}
+	if !m.nextAt.Equal(fetched.Add(30 * time.Minute)) {
+		t.Errorf("next fetch at %v, want when the cache expires", m.nextAt)
+	}
+
+	// ticks before then do nothing, the first one after fetches
+	if _, msg := update(m, types.TickMsg(m.nextAt.Add(-time.Second))); msg != nil {
+		t.Errorf("fetched before expiry: %v", msg)
+	}
+	m, msg = update(m, types.TickMsg(m.nextAt))
+	if _, ok := msg.(fetchedMsg); !ok || !m.fetching {
+		t.Errorf("expiry tick gave %v", msg)
+	}
+}
+
+func TestExpiredCacheIsShownAndFetched(t *testing.T) {
+	m := testModel(t, fakeProvider{})
+	old := &Report{Fetched: m.now.Add(-2 * time.Hour), Current: Conditions{Temp: 3}}
+	m, msg := update(m, cacheLoadedMsg{report: old})
+	if m.report != old || !m.fetching {
+		t.Fatalf("report %v fetching %v", m.report, m.fetching)
+	}
+	m, msg = update(m, msg)
+	if _, ok := msg.(cacheSavedMsg); !ok {
+		t.Fatalf("fetch gave %T, want the report saved", msg)
+	}
+	m, _ = update(m, msg)
+	if m.report.Current.Temp != 21.4 || m.err != nil || m.saveErr != nil {
+		t.Errorf("after fetching: temp %v err %v save %v", m.report.Current.Temp, m.err, m.saveErr)
+	}
+	if got := loadCache(m.cachePath, m.cacheKey)().(cacheLoadedMsg); got.report == nil || got.report.Current.Temp != 21.4 {
+		t.Error("fetched report not cached")
+	}
+}
+
+func TestStaleAfterFailedFetch(t *testing.T) {
+	m := testModel(t, fakeProvider{})
+	m, _ = update(m, cacheLoadedMsg{report: &Report{Fetched: m.now.Add(-2 * time.Hour)}})
+	if !strings.Contains(ansi.Strip(m.View()), "refreshing…") {
+		t.Errorf("view while fetching:\n%s", ansi.Strip(m.View()))
+	}
+
+	m, _ = update(m, fetchedMsg{err: errors.New("no route to host")})
+	if !m.stale() || m.fetching {
+		t.Fatalf("stale %v fetching %v", m.stale(), m.fetching)
+	}
+	view := ansi.Strip(m.View())
+	if !strings.Contains(view, "updated 2h ago · stale · no route to host") {
+		t.Errorf("view lacks the stale marker:\n%s", view)
+	}
+	if want := m.now.Add(maxRetry); !m.nextAt.Equal(want) {
+		t.Errorf("retry at %v, want %v", m.nextAt, want)
+	}
+
+	// a later success clears it
+	m, msg := update(m, types.TickMsg(m.nextAt))
+	m, _ = update(m, msg)
+	if m.stale() || strings.Contains(ansi.Strip(m.View()), "stale") {
+		t.Errorf("still stale after a successful fetch:\n%s", ansi.Strip(m.View()))
+	}
+}
+
+func TestFailedFetchWithoutReport(t *testing.T) {
+	m := testModel(t, fakeProvider{})
+	m, _ = update(m, cacheLoadedMsg{})
+	m, _ = update(m, fetchedMsg{err: errors.New("offline")})
+	if m.stale() {
+		t.Error("no report, yet stale")
+	}
+	view := ansi.Strip(m.View())
+	if !strings.Contains(view, "✕ offline") || !strings.Contains(view, "retrying in 5m") {
+		t.Errorf("view:\n%s", view)
+	}
+}
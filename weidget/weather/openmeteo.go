@@ -0,0 +1,169 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+const requestTimeout = 15 * time.Second
+
+// OpenMeteo is the free Open-Meteo forecast API, which needs no key.
+type OpenMeteo struct {
+	BaseURL string // default https://api.open-meteo.com/v1/forecast
+	http    *http.Client
+}
+
+func NewOpenMeteo(baseURL string) *OpenMeteo {
+	if baseURL == "" {
+		baseURL = "https://api.open-meteo.com/v1/forecast"
+	}
+	return &OpenMeteo{BaseURL: baseURL, http: &http.Client{Timeout: requestTimeout}}
+}
+
+func (o *OpenMeteo) Name() string { return "open-meteo" }
+
+// Query is the request for loc, in the API's default metric units.
+// Times come in the place's own time zone.
+func (o *OpenMeteo) Query(loc Location, days int) url.Values {
+	return url.Values{
+		"latitude":      {strconv.FormatFloat(loc.Latitude, 'f', 4, 64)},
+		"longitude":     {strconv.FormatFloat(loc.Longitude, 'f', 4, 64)},
+		"current":       {"temperature_2m,apparent_temperature,relative_humidity_2m,weather_code,wind_speed_10m,wind_direction_10m,is_day"},
+		"daily":         {"weather_code,temperature_2m_max,temperature_2m_min,precipitation_sum,precipitation_probability_max"},
+		"timezone":      {"auto"},
+		"forecast_days": {strconv.Itoa(days)},
+	}
+}
+
+func (o *OpenMeteo) Fetch(ctx context.Context, loc Location, days int) (*Report, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.BaseURL+"?"+o.Query(loc, days).Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "termctrl")
+	resp, err := o.http.Do(req)
+	if err != nil {
+		var ue *url.Error
+		if errors.As(err, &ue) {
+			err = ue.Err // the query is long and says nothing
+		}
+		return nil, fmt.Errorf("open-meteo: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		var body struct {
+			Reason string `json:"reason"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&body)
+		if body.Reason == "" {
+			body.Reason = resp.Status
+		}
+		return nil, fmt.Errorf("open-meteo: %s", body.Reason)
+	}
+	return decodeOpenMeteo(io.LimitReader(resp.Body, 1024*1024))
+}
+
+// FileProvider reads a saved Open-Meteo response instead of asking the
+// network, for offline use and for testing the widget. Make one with
+//
+//	curl -o weather.json 'https://api.open-meteo.com/v1/forecast?latitude=52.52&longitude=13.41&current=temperature_2m,apparent_temperature,relative_humidity_2m,weather_code,wind_speed_10m,wind_direction_10m,is_day&daily=weather_code,temperature_2m_max,temperature_2m_min,precipitation_sum,precipitation_probability_max&timezone=auto'
+type FileProvider struct {
+	Path string
+}
+
+func (f FileProvider) Name() string { return "file" }
+
+// Fetch ignores the location: the file is for one place. Days beyond
+// what the file has are not made up.
+func (f FileProvider) Fetch(ctx context.Context, loc Location, days int) (*Report, error) {
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	r, err := decodeOpenMeteo(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.Path, err)
+	}
+	if len(r.Daily) > days {
+		r.Daily = r.Daily[:days]
+	}
+	return r, nil
+}
+
+// omResponse is the part of an Open-Meteo forecast the widget uses.
+type omResponse struct {
+	UTCOffset int `json:"utc_offset_seconds"`
+	Current   struct {
+		Time      string  `json:"time"`
+		Temp      float64 `json:"temperature_2m"`
+		FeelsLike float64 `json:"apparent_temperature"`
+		Humidity  float64 `json:"relative_humidity_2m"`
+		Code      int     `json:"weather_code"`
+		Wind      float64 `json:"wind_speed_10m"`
+		WindDir   float64 `json:"wind_direction_10m"`
+		IsDay     int     `json:"is_day"`
+	} `json:"current"`
+	Daily struct {
+		Time       []string   `json:"time"`
+		Code       []int      `json:"weather_code"`
+		Max        []float64  `json:"temperature_2m_max"`
+		Min        []float64  `json:"temperature_2m_min"`
+		Precip     []float64  `json:"precipitation_sum"`
+		PrecipProb []*float64 `json:"precipitation_probability_max"` // null where unknown
+	} `json:"daily"`
+}
+
+func decodeOpenMeteo(r io.Reader) (*Report, error) {
+	var om omResponse
+	if err := json.NewDecoder(r).Decode(&om); err != nil {
+		return nil, fmt.Errorf("open-meteo: %w", err)
+	}
+	zone := time.FixedZone("", om.UTCOffset)
+	now, err := time.ParseInLocation("2006-01-02T15:04", om.Current.Time, zone)
+	if err != nil {
+		return nil, fmt.Errorf("open-meteo: current time: %w", err)
+	}
+	rep := &Report{Current: Conditions{
+		Time:      now,
+		Temp:      om.Current.Temp,
+		FeelsLike: om.Current.FeelsLike,
+		Humidity:  int(om.Current.Humidity),
+		Wind:      om.Current.Wind,
+		WindDir:   int(om.Current.WindDir),
+		Code:      Code(om.Current.Code),
+		Day:       om.Current.IsDay == 1,
+	}}
+	d := om.Daily
+	for i, s := range d.Time {
+		date, err := time.ParseInLocation("2006-01-02", s, zone)
+		if err != nil {
+			return nil, fmt.Errorf("open-meteo: daily time: %w", err)
+		}
+		day := Day{Date: date, Code: Code(at(d.Code, i)), Max: at(d.Max, i), Min: at(d.Min, i), Precip: at(d.Precip, i), PrecipProb: -1}
+		if i < len(d.PrecipProb) && d.PrecipProb[i] != nil {
+			day.PrecipProb = int(*d.PrecipProb[i])
+		}
+		rep.Daily = append(rep.Daily, day)
+	}
+	return rep, nil
+}
+
+// at is s[i], or zero for a series shorter than the days.
+func at[T any](s []T, i int) T {
+	var zero T
+	if i < len(s) {
+		return s[i]
+	}
+	return zero
+}
@@ -0,0 +1,136 @@
+package weather
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeOpenMeteo(t *testing.T) {
+	f, err := os.Open("testdata/openmeteo.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := decodeOpenMeteo(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zone := time.FixedZone("", 2*60*60)
+	c := r.Current
+	if !c.Time.Equal(time.Date(2024, 6, 14, 15, 45, 0, 0, zone)) {
+		t.Errorf("current time = %v", c.Time)
+	}
+	if c.Temp != 21.4 || c.FeelsLike != 20.1 || c.Humidity != 48 || c.Wind != 14.8 || c.WindDir != 247 || c.Code != 2 || !c.Day {
+		t.Errorf("current = %+v", c)
+	}
+
+	if len(r.Daily) != 5 {
+		t.Fatalf("%d days, want 5", len(r.Daily))
+	}
+	d := r.Daily[1]
+	if !d.Date.Equal(time.Date(2024, 6, 15, 0, 0, 0, 0, zone)) || d.Code != 61 || d.Max != 19.6 || d.Min != 13.4 || d.Precip != 4.2 || d.PrecipProb != 78 {
+		t.Errorf("day 1 = %+v", d)
+	}
+	// null is unknown, not 0%
+	for _, i := range []int{3, 4} {
+		if r.Daily[i].PrecipProb != -1 {
+			t.Errorf("day %d precipitation probability = %d, want -1", i, r.Daily[i].PrecipProb)
+		}
+	}
+}
+
+func TestDecodeOpenMeteoShortSeries(t *testing.T) {
+	const body = `{
+		"utc_offset_seconds": 0,
+		"current": {"time": "2024-06-14T12:00", "temperature_2m": 10, "weather_code": 0, "is_day": 0},
+		"daily": {
+			"time": ["2024-06-14", "2024-06-15", "2024-06-16"],
+			"weather_code": [1, 3],
+			"temperature_2m_max": [15],
+			"temperature_2m_min": [5, 6, 7],
+			"precipitation_probability_max": [20]
+		}
+	}`
+	r, err := decodeOpenMeteo(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Daily) != 3 {
+		t.Fatalf("%d days, want one per time", len(r.Daily))
+	}
+	last := r.Daily[2]
+	if last.Code != 0 || last.Max != 0 || last.Min != 7 || last.Precip != 0 || last.PrecipProb != -1 {
+		t.Errorf("day past the short series = %+v", last)
+	}
+	if r.Daily[0].PrecipProb != 20 || r.Daily[1].Code != 3 {
+		t.Errorf("days = %+v", r.Daily)
+	}
+	if r.Current.Day {
+		t.Error("is_day 0 read as day")
+	}
+}
+
+func TestDecodeOpenMeteoErrors(t *testing.T) {
+	for name, body := range map[string]string{
+		"not json":     `<html>`,
+		"current time": `{"current": {"time": "yesterday"}}`,
+		"daily time":   `{"current": {"time": "2024-06-14T12:00"}, "daily": {"time": ["14/06/2024"]}}`,
+	} {
+		if _, err := decodeOpenMeteo(strings.NewReader(body)); err == nil || !strings.HasPrefix(err.Error(), "open-meteo: ") {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+}
+
+func TestOpenMeteoFetch(t *testing.T) {
+	data, err := os.ReadFile("testdata/openmeteo.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("latitude") == "0.0000" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": true, "reason": "Latitude must be in range of -90 to 90°."}`))
+			return
+		}
+		if q.Get("latitude") != "52.5200" || q.Get("forecast_days") != "5" || q.Get("timezone") != "auto" {
+			t.Errorf("query = %s", r.URL.RawQuery)
+		}
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	om := NewOpenMeteo(srv.URL)
+	r, err := om.Fetch(context.Background(), Location{Latitude: 52.52, Longitude: 13.41}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Daily) != 5 {
+		t.Errorf("%d days", len(r.Daily))
+	}
+
+	_, err = om.Fetch(context.Background(), Location{}, 5)
+	if err == nil || err.Error() != "open-meteo: Latitude must be in range of -90 to 90°." {
+		t.Errorf("err = %v, want the API's reason", err)
+	}
+}
+
+func TestFileProviderTrimsDays(t *testing.T) {
+	r, err := FileProvider{Path: "testdata/openmeteo.json"}.Fetch(context.Background(), Location{}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Daily) != 3 {
+		t.Errorf("%d days, want 3", len(r.Daily))
+	}
+	if _, err := (FileProvider{Path: "testdata/missing.json"}).Fetch(context.Background(), Location{}, 3); err == nil {
+		t.Error("missing file read")
+	}
+}
@@ -0,0 +1,136 @@
+// Package weather shows current conditions and a short forecast from a
+// pluggable provider, cached on disk.
+package weather
+
+import (
+	"context"
+	"time"
+)
+
+// Provider fetches the weather for a place. Values are metric (°C, km/h,
+// mm); the widget converts them for display.
+type Provider interface {
+	Name() string // short id, part of the cache key
+	Fetch(ctx context.Context, loc Location, days int) (*Report, error)
+}
+
+// Location is where the weather is for.
+type Location struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Report is a provider's answer, and what is cached.
+type Report struct {
+	Fetched time.Time  `json:"fetched"`
+	Current Conditions `json:"current"`
+	Daily   []Day      `json:"daily"`
+}
+
+type Conditions struct {
+	Time      time.Time `json:"time"`
+	Temp      float64   `json:"temp"`
+	FeelsLike float64   `json:"feels_like"`
+	Humidity  int       `json:"humidity"` // percent
+	Wind      float64   `json:"wind"`     // km/h
+	WindDir   int       `json:"wind_dir"` // degrees the wind comes from
+	Code      Code      `json:"code"`
+	Day       bool      `json:"day"` // daylight, for a sun or a moon
+}
+
+type Day struct {
+	Date       time.Time `json:"date"`
+	Min        float64   `json:"min"`
+	Max        float64   `json:"max"`
+	Precip     float64   `json:"precip"`      // mm
+	PrecipProb int       `json:"precip_prob"` // percent, -1 when unknown
+	Code       Code      `json:"code"`
+}
+
+// Code is a WMO weather interpretation code, as Open-Meteo and most
+// other services report conditions.
+type Code int
+
+// Sky is the kind of weather a code stands for, one per icon.
+type Sky int
+
+const (
+	Clear Sky = iota
+	PartlyCloudy
+	Cloudy
+	Fog
+	Drizzle
+	Rain
+	Snow
+	Thunder
+)
+
+// Sky groups the code's detail away.
+func (c Code) Sky() Sky {
+	switch {
+	case c <= 1:
+		return Clear
+	case c == 2:
+		return PartlyCloudy
+	case c == 3:
+		return Cloudy
+	case c == 45 || c == 48:
+		return Fog
+	case c >= 51 && c <= 57:
+		return Drizzle
+	case c >= 71 && c <= 77, c == 85 || c == 86:
+		return Snow
+	case c >= 95:
+		return Thunder
+	}
+	return Rain // 61–67, 80–82
+}
+
+// String describes the conditions in a few words.
+func (c Code) String() string {
+	switch c {
+	case 0:
+		return "clear"
+	case 1:
+		return "mainly clear"
+	case 2:
+		return "partly cloudy"
+	case 3:
+		return "overcast"
+	case 45:
+		return "fog"
+	case 48:
+		return "rime fog"
+	case 51, 53, 55:
+		return "drizzle"
+	case 56, 57:
+		return "freezing drizzle"
+	case 61:
+		return "light rain"
+	case 63:
+		return "rain"
+	case 65:
+		return "heavy rain"
+	case 66, 67:
+		return "freezing rain"
+	case 71:
+		return "light snow"
+	case 73:
+		return "snow"
+	case 75:
+		return "heavy snow"
+	case 77:
+		return "snow grains"
+	case 80, 81:
+		return "showers"
+	case 82:
+		return "heavy showers"
+	case 85, 86:
+		return "snow showers"
+	case 95:
+		return "thunderstorm"
+	case 96, 99:
+		return "thunderstorm, hail"
+	}
+	return "unknown"
+}
@@ -0,0 +1,149 @@
+package weather
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antiloger/termctlr/theme"
+	"github.com/antiloger/termctlr/weidget/components"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func (m Model) View() string {
+	t := theme.Current()
+	muted := t.Style(theme.Muted)
+	switch {
+	case m.setupErr != nil:
+		return t.Style(theme.Alert).Render(components.Fit("weather: "+m.setupErr.Error(), m.width))
+	case m.provider == nil:
+		return strings.Join([]string{
+			muted.Render("weather: no place configured"),
+			muted.Render(`set one under weather, e.g. {"place": "Berlin", "latitude": 52.52, "longitude": 13.41}`),
+		}, "\n")
+	}
+
+	title := t.Style(theme.Accent).Bold(true).Render(m.place)
+	if m.report != nil {
+		title += muted.Render(" · " + m.report.Current.Code.String())
+	}
+	lines := []string{components.Fit(title, m.width)}
+
+	if m.report == nil {
+		switch {
+		case m.err != nil:
+			lines = append(lines,
+				t.Style(theme.Alert).Render(components.Fit("✕ "+m.err.Error(), m.width)),
+				muted.Render(components.Fit("retrying in "+formatAge(m.nextAt.Sub(m.now))+" · r retry", m.width)))
+		default:
+			lines = append(lines, muted.Render(components.Fit("fetching…", m.width)))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	r := m.report
+	c := r.Current
+	ic := icon(c.Code, c.Day)
+	iconStyle := t.Style(skyRole(c.Code, c.Day))
+	details := [3]string{
+		t.Style(theme.Text).Bold(true).Render(m.temp(c.Temp)+m.tempUnit()) + muted.Render("  feels "+m.temp(c.FeelsLike)+"°"),
+		muted.Render(fmt.Sprintf("humidity %d%% · wind %s %s", c.Humidity, m.speed(c.Wind), compass(c.WindDir))),
+		m.freshness(t),
+	}
+	for i := range ic {
+		lines = append(lines, components.Fit(iconStyle.Render(ic[i])+"  "+details[i], m.width))
+	}
+
+	for _, d := range r.Daily[:min(len(r.Daily), max(m.height-5, 0))] {
+		lines = append(lines, components.Fit(m.dayLine(t, d, c.Time), m.width))
+	}
+
+	lines = append(lines, muted.Render(components.Fit("r refresh · u "+m.otherUnits(), m.width)))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// freshness says how old the report is, and marks it stale when a newer
+// one could not be fetched.
+func (m Model) freshness(t *theme.Theme) string {
+	muted := t.Style(theme.Muted)
+	s := muted.Render("updated " + formatAge(m.now.Sub(m.report.Fetched)) + " ago")
+	switch {
+	case m.fetching:
+		s += muted.Render(" · refreshing…")
+	case m.stale():
+		s += t.Style(theme.Alert).Bold(true).Render(" · stale") + muted.Render(" · "+m.err.Error())
+	case m.saveErr != nil:
+		s += muted.Render(" · not cached: " + m.saveErr.Error())
+	}
+	return s
+}
+
+func (m Model) dayLine(t *theme.Theme, d Day, today time.Time) string {
+	name := d.Date.Format("Mon")
+	if y, mo, dd := d.Date.Date(); y == today.Year() && mo == today.Month() && dd == today.Day() {
+		name = "Today"
+	}
+	prob := "   "
+	if d.PrecipProb >= 0 {
+		prob = fmt.Sprintf("%2d%%", d.PrecipProb)
+	}
+	return t.Style(theme.Text).Render(fmt.Sprintf("%-6s", name)) +
+		t.Style(skyRole(d.Code, true)).Render(components.Fit(d.Code.String(), 18)) +
+		t.Style(theme.Text).Render(components.Fit(fmt.Sprintf("%3s° / %s°", m.temp(d.Min), m.temp(d.Max)), 12)) +
+		t.Style(theme.Muted).Render(fmt.Sprintf("  %s %s", prob, m.precip(d.Precip)))
+}
+
+func (m Model) temp(c float64) string {
+	if m.units == Imperial {
+		c = c*9/5 + 32
+	}
+	return fmt.Sprintf("%.0f", c)
+}
+
+func (m Model) tempUnit() string {
+	if m.units == Imperial {
+		return "°F"
+	}
+	return "°C"
+}
+
+// otherUnits names what u switches to.
+func (m Model) otherUnits() string {
+	if m.units == Imperial {
+		return "°C"
+	}
+	return "°F"
+}
+
+func (m Model) speed(kmh float64) string {
+	if m.units == Imperial {
+		return fmt.Sprintf("%.0f mph", kmh/1.609344)
+	}
+	return fmt.Sprintf("%.0f km/h", kmh)
+}
+
+func (m Model) precip(mm float64) string {
+	if m.units == Imperial {
+		return fmt.Sprintf("%.2f in", mm/25.4)
+	}
+	return fmt.Sprintf("%.1f mm", mm)
+}
+
+// compass is the point a wind blows from.
+func compass(deg int) string {
+	points := []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+	return points[((deg%360+360)%360+22)/45%8]
+}
+
+// formatAge is a short duration: 40s, 12m, 3h, 2d.
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", max(int(d.Seconds()), 0))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}